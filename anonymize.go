@@ -0,0 +1,50 @@
+package sanic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Anonymize returns a stand-in for id for export to parties who may bucket
+// IDs by time but must not learn their exact time or sequence. It keeps the
+// top keepTimestampBits bits of id's timestamp, and fills every bit below
+// them with the first bytes of HMAC-SHA256 keyed with salt over id as 8
+// big-endian bytes. The result is still a valid ID for the layout, and sorts
+// with the others by the kept time bucket, though its decomposed time is
+// anywhere within the bucket and its worker ID and sequence are noise.
+// keepTimestampBits above TimeStampBits is treated as TimeStampBits.
+//
+// Without salt the hidden bits cannot be recovered or recomputed. Distinct
+// IDs collide only if they share a time bucket and the hash bits: with
+// r = TotalBits - 1 - keepTimestampBits hash bits and n IDs in one bucket,
+// the chance of any collision in it is at most n*n / 2^(r+1). For example
+// NewWorker10 IDs keeping 20 timestamp bits, about 35 minute buckets, have
+// r = 39, so 30,000 IDs per bucket collide with probability under 0.1%. For
+// a million the bound is 91%; the real chance, 1 - e^(-n*n / 2^(r+1)), is
+// about 60%.
+func (w *Worker) Anonymize(id int64, keepTimestampBits uint64, salt []byte) int64 {
+	keep := min(keepTimestampBits, w.TimeStampBits)
+	hashBits := w.TotalBits - 1 - keep
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(id))
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(buf[:])
+	h := binary.BigEndian.Uint64(mac.Sum(nil))
+
+	mask := uint64(1)<<hashBits - 1
+	return int64(uint64(id)&^mask | h&mask)
+}
+
+// AnonymizeString is Anonymize for IDString forms. It returns the errors
+// ParseIDString does.
+func (w *Worker) AnonymizeString(s string, keepTimestampBits uint64,
+	salt []byte) (string, error) {
+
+	id, err := w.ParseIDString(s)
+	if err != nil {
+		return "", err
+	}
+	return w.IDString(w.Anonymize(id, keepTimestampBits, salt)), nil
+}
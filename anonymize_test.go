@@ -0,0 +1,103 @@
+package sanic
+
+import (
+	"math/bits"
+	"testing"
+)
+
+func TestAnonymizeKeepsTimeBucket(t *testing.T) {
+	w := NewWorker10(5)
+	salt := []byte("salt")
+	const keep = 20
+	drop := w.TotalBits - 1 - keep
+	for range 1000 {
+		id := w.NextID()
+		a := w.Anonymize(id, keep, salt)
+		if a>>drop != id>>drop {
+			t.Fatalf("Anonymize(%d) = %d changed the kept timestamp bits", id, a)
+		}
+		if err := w.Validate(a); err != nil {
+			t.Fatalf("Anonymize(%d) = %d is not valid: %v", id, a, err)
+		}
+		if w.Anonymize(id, keep, salt) != a {
+			t.Fatal("Anonymize is not deterministic")
+		}
+	}
+	id := w.NextID()
+	if w.Anonymize(id, 99, salt) != w.Anonymize(id, w.TimeStampBits, salt) {
+		t.Error("keepTimestampBits above TimeStampBits not clamped")
+	}
+}
+
+func TestAnonymizeHidesLowBits(t *testing.T) {
+	w := NewWorker10(5)
+	const keep = 20
+	hashBits := int(w.TotalBits - 1 - keep)
+	mask := int64(1)<<hashBits - 1
+
+	// Neighbouring IDs, and the same ID under another salt, give hidden
+	// bits that differ in about half their positions, so nothing about the
+	// original's low bits survives without the salt.
+	var flips, trials int
+	for range 2000 {
+		id := w.NextID()
+		a := w.Anonymize(id, keep, []byte("a"))
+		for _, b := range []int64{
+			w.Anonymize(id+1, keep, []byte("a")),
+			w.Anonymize(id, keep, []byte("b")),
+		} {
+			flips += bits.OnesCount64(uint64((a ^ b) & mask))
+			trials++
+		}
+		if a&mask == id&mask {
+			t.Errorf("hidden bits of %d unchanged", id)
+		}
+	}
+	mean := float64(flips) / float64(trials) / float64(hashBits)
+	if mean < 0.45 || mean > 0.55 {
+		t.Errorf("hidden bits differ in %.3f of positions, want about 0.5", mean)
+	}
+}
+
+func TestAnonymizeCollisionBound(t *testing.T) {
+	// Keeping every timestamp bit leaves r = 18 hash bits, few enough to
+	// measure collisions per one-tick bucket.
+	w := NewWorker10(0)
+	keep := w.TimeStampBits
+	r := w.TotalBits - 1 - keep
+	const buckets, n = 200, 1000
+	salt := []byte("collisions")
+
+	pairs := 0
+	base := w.NextID() >> r << r
+	for b := range int64(buckets) {
+		seen := map[int64]int{}
+		for i := range int64(n) {
+			id := base + b<<r + i<<6 // distinct IDs within the tick
+			a := w.Anonymize(id, keep, salt)
+			pairs += seen[a]
+			seen[a]++
+		}
+	}
+	// The documented bound on the chance of any collision, n*n / 2^(r+1),
+	// is also a bound on the expected number of colliding pairs.
+	bound := float64(n) * n / float64(uint64(1)<<(r+1))
+	if got := float64(pairs) / buckets; got > 1.2*bound {
+		t.Errorf("%.2f colliding pairs per bucket, documented bound %.2f", got, bound)
+	}
+}
+
+func TestAnonymizeString(t *testing.T) {
+	w := NewWorker10(5)
+	id := w.NextID()
+	s, err := w.AnonymizeString(w.IDString(id), 20, []byte("salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := w.IDString(w.Anonymize(id, 20, []byte("salt"))); s != want {
+		t.Errorf("AnonymizeString = %q, want %q", s, want)
+	}
+	if _, err := w.AnonymizeString("short", 20, nil); err == nil {
+		t.Error("malformed string accepted")
+	}
+}
@@ -0,0 +1,116 @@
+package sanic
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// BenchmarkNextID runs NextID with 1, 2, 4 and 8 goroutines, first all
+// sharing one worker and then each with a worker of its own, as
+// sanictest.RunBenchMatrix does, so changes to the generation path can be
+// compared with and without lock contention.
+func BenchmarkNextID(b *testing.B) {
+	for _, contended := range []bool{true, false} {
+		name := "uncontended"
+		if contended {
+			name = "contended"
+		}
+		for _, n := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", name, n), func(b *testing.B) {
+				workers := make([]*Worker, n)
+				for i := range workers {
+					if contended && i > 0 {
+						workers[i] = workers[0]
+					} else {
+						workers[i] = NewWorker10(int64(i))
+					}
+				}
+				benchGoroutines(b, n, func(g, count int) {
+					w := workers[g]
+					for range count {
+						w.NextID()
+					}
+				})
+			})
+		}
+	}
+}
+
+func BenchmarkNextIDs(b *testing.B) {
+	w := NewWorker10(1)
+	ids := make([]int64, 64)
+	for i := 0; i < b.N; i += len(ids) {
+		w.NextIDs(ids)
+	}
+}
+
+func BenchmarkUnsafeNextID(b *testing.B) {
+	w := NewWorker10(1)
+	for range b.N {
+		w.UnsafeNextID()
+	}
+}
+
+// benchGoroutines splits b.N calls between n goroutines, running f with
+// each goroutine's index and share, and times them all.
+func benchGoroutines(b *testing.B, n int, f func(g, count int)) {
+	b.ReportAllocs()
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for g := range n {
+		count := b.N / n
+		if g < b.N%n {
+			count++
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f(g, count)
+		}()
+	}
+	wg.Wait()
+}
+
+// BenchmarkBuffered is BenchmarkNextID's contended case through a
+// BufferedWorker.
+func BenchmarkBuffered(b *testing.B) {
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("goroutines=%d", n), func(b *testing.B) {
+			buf := Buffered(NewWorker10(1), 4096, 1024)
+			defer buf.Close()
+			benchGoroutines(b, n, func(_, count int) {
+				for range count {
+					buf.NextID()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkFill fills a struct with its plan cached, as after the first
+// call, and with the cache dropped before every call, which is the cost of
+// examining the type each time.
+func BenchmarkFill(b *testing.B) {
+	for _, cached := range []bool{true, false} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			w := NewWorker10(1)
+			b.ReportAllocs()
+			for range b.N {
+				if !cached {
+					fillPlans.Lock()
+					fillPlans.plans, fillPlans.errs = nil, nil
+					fillPlans.Unlock()
+				}
+				u := fillUser{Orders: make([]fillOrder, 4)}
+				if err := Fill(w, &u); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package sanic
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BindID parses raw, such as a path parameter or query value, as an ID of
+// w's layout in either its numeric or its IDString form. Surrounding
+// whitespace is ignored. A value of exactly StringLength characters is read
+// as an ID string first, even if it is all digits, since a numeric ID that
+// short would date from the first moments of the epoch; any other value of
+// only digits is read as a decimal number, leading zeros allowed. Errors
+// are *BindError.
+func BindID(w *Worker, raw string) (int64, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, &BindError{raw, fmt.Errorf("%w: empty", ErrMalformedID)}
+	}
+
+	numeric := strings.Trim(s, "0123456789") == ""
+	if !numeric || len(s) == w.StringLength() {
+		id, err := w.ParseIDString(s)
+		if err == nil {
+			return id, nil
+		}
+		if !numeric {
+			return 0, &BindError{raw, err}
+		}
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, &BindError{raw, fmt.Errorf("%w: %q is out of range",
+			ErrMalformedID, s)}
+	}
+	if err := w.Validate(id); err != nil {
+		return 0, &BindError{raw, err}
+	}
+	return id, nil
+}
+
+// IDVar is BindID for the request's path value name, as matched by a
+// net/http ServeMux pattern such as "/items/{id}", or if there is none, for
+// the query parameter name.
+func IDVar(w *Worker, r *http.Request, name string) (int64, error) {
+	raw := r.PathValue(name)
+	if raw == "" {
+		raw = r.URL.Query().Get(name)
+	}
+	return BindID(w, raw)
+}
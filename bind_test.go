@@ -0,0 +1,98 @@
+package sanic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestBindID(t *testing.T) {
+	w := NewWorker10(7)
+	id := w.NextID()
+	num, str := strconv.FormatInt(id, 10), w.IDString(id)
+	for _, raw := range []string{num, str, " " + num + "\n", "\t" + str, "000" + num} {
+		if got, err := BindID(w, raw); got != id || err != nil {
+			t.Errorf("BindID(%q) = %d, %v, want %d", raw, got, err, id)
+		}
+	}
+}
+
+// An all-digit value of StringLength characters is read as an ID string.
+func TestBindIDPrecedence(t *testing.T) {
+	w := NewWorker10(7)
+	raw := "1234567890"
+	if len(raw) != w.StringLength() {
+		t.Fatalf("StringLength is %d", w.StringLength())
+	}
+	want, err := w.ParseIDString(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := BindID(w, raw); got != want || err != nil {
+		t.Errorf("BindID(%q) = %d, %v, want the string form %d", raw, got, err, want)
+	}
+	if got, err := BindID(w, "0"+raw); got != 1234567890 || err != nil {
+		t.Errorf("BindID(%q) = %d, %v, want the number", "0"+raw, got, err)
+	}
+}
+
+func TestBindIDErrors(t *testing.T) {
+	w := NewWorker10(7)
+	for _, tc := range []struct {
+		raw      string
+		notFound bool
+	}{
+		{"", false},
+		{"  ", false},
+		{"abc", false},
+		{"-1", false},
+		{"12.5", false},
+		{"99999999999999999999", false},
+		{"0", true},
+		{strconv.FormatInt(1<<62, 10), true},
+		{"----------", true},
+	} {
+		_, err := BindID(w, tc.raw)
+		var be *BindError
+		if !errors.As(err, &be) || be.Raw != tc.raw {
+			t.Errorf("BindID(%q) = %v, want a *BindError", tc.raw, err)
+			continue
+		}
+		want := ErrMalformedID
+		if tc.notFound {
+			want = ErrInvalidID
+		}
+		if be.NotFound() != tc.notFound || !errors.Is(err, want) {
+			t.Errorf("BindID(%q) = %v, NotFound %v", tc.raw, err, be.NotFound())
+		}
+	}
+}
+
+func TestIDVar(t *testing.T) {
+	w := NewWorker10(7)
+	id := w.NextID()
+	var got int64
+	var gotErr error
+	mux := http.NewServeMux()
+	handler := func(_ http.ResponseWriter, r *http.Request) { got, gotErr = IDVar(w, r, "id") }
+	mux.HandleFunc("/items/{id}", handler)
+	mux.HandleFunc("/items", handler)
+
+	for _, path := range []string{
+		"/items/" + w.IDString(id),
+		"/items/" + strconv.FormatInt(id, 10),
+		"/items?id=" + w.IDString(id),
+	} {
+		got, gotErr = 0, nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		if got != id || gotErr != nil {
+			t.Errorf("%s: IDVar = %d, %v, want %d", path, got, gotErr, id)
+		}
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil))
+	if !errors.Is(gotErr, ErrMalformedID) {
+		t.Errorf("no id: IDVar = %d, %v", got, gotErr)
+	}
+}
@@ -0,0 +1,49 @@
+package sanic
+
+import "time"
+
+// BorrowPolicy configures borrowing of future ticks. A worker whose sequence
+// is exhausted would normally wait for the clock to reach the next tick.
+// Once that has happened Exhaustions times within Window, it instead moves
+// straight on to the next tick, as long as that keeps it no more than
+// MaxLead ticks ahead of the clock. When the clock catches up the worker is
+// back in step with it.
+//
+// IDs stay unique and increasing, and their timestamps are never more than
+// MaxLead ticks later than the time they were generated.
+type BorrowPolicy struct {
+	Exhaustions int
+	Window      time.Duration
+	MaxLead     int64
+}
+
+// borrow records a sequence exhaustion at tick now and reports whether the
+// worker may move to the next tick without waiting. w.mutex must be held.
+func (w *Worker) borrow(now int64) bool {
+	p := w.Borrow
+	if p == nil || p.Exhaustions <= 0 {
+		return false
+	}
+	if w.exhaustions == nil {
+		w.exhaustions = make([]int64, p.Exhaustions)
+	}
+	oldest := w.exhaustions[w.exhaustNext]
+	w.exhaustions[w.exhaustNext] = now
+	w.exhaustNext = (w.exhaustNext + 1) % len(w.exhaustions)
+
+	window := int64(p.Window / w.Frequency)
+	if oldest == 0 || now-oldest > window ||
+		w.LastTimeStamp+1-now > p.MaxLead {
+		return false
+	}
+	w.borrowed = w.LastTimeStamp + 1
+	w.stats.Borrowed++
+	return true
+}
+
+// leading reports whether the clock reading now is behind the worker only
+// because it borrowed ticks. w.mutex must be held.
+func (w *Worker) leading(now int64) bool {
+	return w.Borrow != nil && w.LastTimeStamp <= w.borrowed &&
+		w.LastTimeStamp-now <= w.Borrow.MaxLead
+}
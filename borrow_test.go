@@ -0,0 +1,89 @@
+package sanic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBorrowBurst(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	w.Borrow = &BorrowPolicy{Exhaustions: 2, Window: 10 * time.Millisecond, MaxLead: 2}
+
+	var all []int64
+	batch := func(n int64) int64 {
+		ids := make([]int64, n)
+		w.NextIDs(ids)
+		all = append(all, ids...)
+		return ids[n-1]
+	}
+	waitFor := func(now int64) int64 {
+		ch := make(chan int64)
+		go func() { ch <- w.NextID() }()
+		c.spinning()
+		c.set(now)
+		id := <-ch
+		all = append(all, id)
+		return id
+	}
+	perTick := w.MaxSequence + 1
+
+	// The first two exhaustions in the window wait for the clock.
+	batch(perTick)
+	if p := w.Decompose(waitFor(tick + 1)); p.Tick != tick+1 {
+		t.Fatalf("first exhaustion moved to tick %d", p.Tick-tick)
+	}
+	batch(perTick - 1)
+	waitFor(tick + 2)
+	batch(perTick - 1)
+
+	// Later ones move on to the next tick without the clock, up to MaxLead
+	// ticks ahead of it.
+	for lead := int64(1); lead <= 2; lead++ {
+		if p := w.Decompose(batch(perTick)); p.Tick != tick+2+lead || p.Sequence != w.MaxSequence {
+			t.Fatalf("borrowed batch ended at tick +%d sequence %d", p.Tick-tick, p.Sequence)
+		}
+	}
+	ch := make(chan int64, 1)
+	go func() { ch <- w.NextID() }()
+	if !blocked(ch) {
+		t.Fatal("worker borrowed past MaxLead")
+	}
+	// The clock catches up, and the worker is back in step with it.
+	c.set(tick + 5)
+	id := <-ch
+	all = append(all, id)
+	if p := w.Decompose(id); p.Tick != tick+5 || p.Sequence != 0 {
+		t.Errorf("after catching up, ID at tick +%d sequence %d", p.Tick-tick, p.Sequence)
+	}
+	if s := w.Stats(); s.Borrowed != 2 || s.ClockBackwards != 0 {
+		t.Errorf("stats %+v, want 2 borrowed and no clock backwards", s)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i] <= all[i-1] {
+			t.Fatalf("ID %d not after %d", all[i], all[i-1])
+		}
+	}
+}
+
+// Exhaustions further apart than Window never borrow.
+func TestBorrowWindow(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	w.Borrow = &BorrowPolicy{Exhaustions: 1, Window: 2 * time.Millisecond, MaxLead: 2}
+	ids := make([]int64, w.MaxSequence+1)
+	w.NextIDs(ids)
+	for now := tick + 3; now < tick+30; now += 3 {
+		ch := make(chan int64)
+		go func() { ch <- w.NextID() }()
+		c.spinning()
+		c.set(now)
+		<-ch
+		w.NextIDs(ids[:w.MaxSequence])
+	}
+	if s := w.Stats(); s.Borrowed != 0 {
+		t.Errorf("borrowed %d times across spaced-out exhaustions", s.Borrowed)
+	}
+}
@@ -0,0 +1,199 @@
+package sanic
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// BufferedWorker hands out IDs from a lock-free ring buffer that a
+// background goroutine keeps filled from a Worker, so NextID only blocks when
+// the buffer is completely empty.
+type BufferedWorker struct {
+	w     *Worker
+	cells []cell
+	mask  uint64
+	low   uint64
+
+	enqueue atomic.Uint64
+	_       [56]byte // keep the two cursors on separate cache lines
+	dequeue atomic.Uint64
+	_       [56]byte
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	closed  atomic.Bool
+
+	waitMutex sync.Mutex
+	waitCond  *sync.Cond
+	waiters   atomic.Int64
+}
+
+type cell struct {
+	seq atomic.Uint64
+	id  int64
+}
+
+// Buffered returns a BufferedWorker holding up to size IDs from w, rounded
+// up to a power of two of at least two. The buffer is refilled whenever it
+// holds fewer than lowWatermark IDs. IDs are reserved from w in batches, so
+// any left in the buffer at Close have used up sequence space; Close
+// returns them.
+func Buffered(w *Worker, size, lowWatermark int) *BufferedWorker {
+	n := 2
+	for n < size {
+		n <<= 1
+	}
+	if lowWatermark < 1 {
+		lowWatermark = 1
+	}
+	if lowWatermark > n {
+		lowWatermark = n
+	}
+	b := &BufferedWorker{
+		w:       w,
+		cells:   make([]cell, n),
+		mask:    uint64(n - 1),
+		low:     uint64(lowWatermark),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for i := range b.cells {
+		b.cells[i].seq.Store(uint64(i))
+	}
+	b.waitCond = sync.NewCond(&b.waitMutex)
+	go b.fill()
+	b.nudge()
+	return b
+}
+
+// NextID returns the next buffered ID. If the buffer is empty it waits for
+// the filler, and after Close it falls back to the underlying worker.
+func (b *BufferedWorker) NextID() int64 {
+	if id, ok := b.take(); ok {
+		return id
+	}
+
+	b.nudge()
+	b.waitMutex.Lock()
+	b.waiters.Add(1)
+	for {
+		if id, ok := b.take(); ok {
+			b.waiters.Add(-1)
+			b.waitMutex.Unlock()
+			return id
+		}
+		if b.closed.Load() {
+			b.waiters.Add(-1)
+			b.waitMutex.Unlock()
+			return b.w.NextID()
+		}
+		b.waitCond.Wait()
+	}
+}
+
+// Close stops the filler and returns the IDs that were reserved but never
+// handed out. Calling Close more than once returns nil.
+func (b *BufferedWorker) Close() []int64 {
+	if b.closed.Swap(true) {
+		return nil
+	}
+	close(b.done)
+	<-b.stopped
+
+	b.waitMutex.Lock()
+	b.waitCond.Broadcast()
+	b.waitMutex.Unlock()
+
+	var ids []int64
+	for {
+		id, ok := b.pop()
+		if !ok {
+			return ids
+		}
+		ids = append(ids, id)
+	}
+}
+
+// take pops an ID for NextID, asking the filler for more if that leaves the
+// buffer below the low watermark.
+func (b *BufferedWorker) take() (int64, bool) {
+	id, ok := b.pop()
+	if ok && b.enqueue.Load()-b.dequeue.Load() < b.low {
+		b.nudge()
+	}
+	return id, ok
+}
+
+func (b *BufferedWorker) nudge() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *BufferedWorker) fill() {
+	defer close(b.stopped)
+	batch := make([]int64, len(b.cells))
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.wake:
+		}
+
+		free := uint64(len(b.cells)) - b.enqueue.Load() + b.dequeue.Load()
+		ids := batch[:free]
+		b.w.NextIDs(ids)
+		for _, id := range ids {
+			// The slot is free, but push fails until a consumer that has
+			// claimed it finishes reading it.
+			for !b.push(id) {
+			}
+		}
+
+		if b.waiters.Load() > 0 {
+			b.waitMutex.Lock()
+			b.waitCond.Broadcast()
+			b.waitMutex.Unlock()
+		}
+	}
+}
+
+// push and pop implement a bounded multi-producer, multi-consumer queue in
+// which each cell's sequence number says whether it is ready to be written
+// or read at a given position.
+func (b *BufferedWorker) push(id int64) bool {
+	for {
+		pos := b.enqueue.Load()
+		c := &b.cells[pos&b.mask]
+		switch seq := c.seq.Load(); {
+		case seq == pos:
+			if b.enqueue.CompareAndSwap(pos, pos+1) {
+				c.id = id
+				c.seq.Store(pos + 1)
+				return true
+			}
+		case seq < pos:
+			return false
+		}
+	}
+}
+
+func (b *BufferedWorker) pop() (int64, bool) {
+	for {
+		pos := b.dequeue.Load()
+		c := &b.cells[pos&b.mask]
+		switch seq := c.seq.Load(); {
+		case seq == pos+1:
+			if b.dequeue.CompareAndSwap(pos, pos+1) {
+				id := c.id
+				c.seq.Store(pos + b.mask + 1)
+				return id, true
+			}
+		case seq < pos+1:
+			return 0, false
+		}
+	}
+}
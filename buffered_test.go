@@ -0,0 +1,89 @@
+package sanic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBufferedConcurrent(t *testing.T) {
+	b := Buffered(NewWorker10(5), 100, 20)
+	const goroutines, each = 8, 5000
+	got := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for g := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]int64, each)
+			for i := range ids {
+				ids[i] = b.NextID()
+			}
+			got[g] = ids
+		}()
+	}
+	wg.Wait()
+	left := b.Close()
+	if len(left) > 128 {
+		t.Errorf("Close returned %d IDs from a buffer of 128", len(left))
+	}
+
+	seen := map[int64]bool{}
+	for _, ids := range append(got, left) {
+		for i, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+			if i > 0 && id <= ids[i-1] {
+				t.Fatalf("IDs out of order: %d after %d", id, ids[i-1])
+			}
+		}
+	}
+}
+
+// A buffer of one is empty after every NextID, so callers wait for the
+// filler each time.
+func TestBufferedEmpty(t *testing.T) {
+	b := Buffered(NewWorker10(5), 1, 1)
+	defer b.Close()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 500 {
+				id := b.NextID()
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate ID %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBufferedClose(t *testing.T) {
+	w := NewWorker10(5)
+	b := Buffered(w, 64, 8)
+	first := b.NextID()
+	left := b.Close()
+	if len(left) == 0 {
+		t.Fatal("Close returned no unconsumed IDs")
+	}
+	if again := b.Close(); again != nil {
+		t.Errorf("second Close returned %d IDs", len(again))
+	}
+	if first >= left[0] {
+		t.Errorf("unconsumed ID %d not after handed-out %d", left[0], first)
+	}
+	// Once closed, NextID falls back to the worker, after everything it
+	// reserved.
+	if id := b.NextID(); id <= left[len(left)-1] {
+		t.Errorf("NextID after Close returned %d, not after %d", id, left[len(left)-1])
+	}
+}
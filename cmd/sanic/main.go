@@ -0,0 +1,192 @@
+// Command sanic works with sanic IDs from the command line.
+//
+// Usage:
+//
+//	sanic decode-file [-layout name] [-policy abort|skip|annotate] [-no-header] [file]
+//
+// decode-file reads newline-delimited ID strings from file, or standard
+// input if it is absent or "-", and writes CSV rows of id,
+// timestamp_rfc3339nano, worker_id and sequence, as sanic.DecodeReader does.
+// The number of malformed lines skipped or annotated is reported on
+// standard error.
+//
+//	sanic inspect [-layout name] [-format auto|string|hex|decimal] id...
+//
+// inspect prints each id in its decimal, string and hex forms, followed by
+// the fields packed into it. With -format auto, an id with a "0x" prefix, or
+// of 16 hex digits that starts with 0 or has a letter in it, is read as hex;
+// one of the layout's string length as an ID string; and anything else as
+// decimal.
+//
+// -layout names a registered preset, NewWorker10 by default.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ifo/sanic"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// commands are the subcommands, by name.
+var commands = map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+	"decode-file": decodeFile,
+	"inspect":     inspect,
+}
+
+// run runs the subcommand named by args[0] and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: sanic <command> [arguments]")
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "sanic: unknown command %q\n", args[0])
+		return 2
+	}
+	if err := cmd(args[1:], stdin, stdout, stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(stderr, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+var policies = map[string]sanic.DecodePolicy{
+	"abort":    sanic.DecodeAbort,
+	"skip":     sanic.DecodeSkip,
+	"annotate": sanic.DecodeAnnotate,
+}
+
+func decodeFile(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("decode-file", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	layout := fs.String("layout", "NewWorker10", "registered preset to decode with")
+	policy := fs.String("policy", "abort", "what to do with malformed lines: abort, skip or annotate")
+	noHeader := fs.Bool("no-header", false, "leave out the header row")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("sanic: decode-file takes at most one file")
+	}
+	p, ok := policies[*policy]
+	if !ok {
+		return fmt.Errorf("sanic: unknown policy %q", *policy)
+	}
+	w, err := presetWorker(*layout)
+	if err != nil {
+		return err
+	}
+
+	in := stdin
+	if name := fs.Arg(0); name != "" && name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	d, err := sanic.DecodeReader(in, w, sanic.DecodeOptions{Policy: p, NoHeader: *noHeader})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(stdout, d)
+	if n := d.Skipped(); n > 0 {
+		fmt.Fprintf(stderr, "sanic: %d malformed lines\n", n)
+	}
+	return err
+}
+
+func inspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	layout := fs.String("layout", "NewWorker10", "registered preset to decode with")
+	format := fs.String("format", "auto", "form of the ids: auto, string, hex or decimal")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("sanic: inspect needs at least one id")
+	}
+	w, err := presetWorker(*layout)
+	if err != nil {
+		return err
+	}
+	for _, s := range fs.Args() {
+		id, err := parseID(w, *format, s)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "id=%d string=%s hex=%s %s\n",
+			id, w.IDString(id), w.IDHex(id), w.Decompose(id))
+	}
+	return nil
+}
+
+// parseID reads s in the named format, validating it against w's layout.
+func parseID(w *sanic.Worker, format, s string) (int64, error) {
+	if format == "auto" {
+		switch {
+		case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+			format = "hex"
+		case isHex(s):
+			format = "hex"
+		case len(s) == w.Layout().StringLength():
+			format = "string"
+		default:
+			format = "decimal"
+		}
+	}
+	switch format {
+	case "string":
+		return w.ParseIDString(s)
+	case "hex":
+		return w.ParseHex(s)
+	case "decimal":
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q is not a decimal ID", sanic.ErrMalformedID, s)
+		}
+		if err := w.Validate(id); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+	return 0, fmt.Errorf("sanic: unknown format %q", format)
+}
+
+// isHex reports whether s looks like IDHex output rather than a decimal ID.
+// IDHex pads with zeros and decimal IDs have no leading zero, so only 16
+// digits with a nonzero lead are taken as decimal.
+func isHex(s string) bool {
+	if len(s) != 16 || strings.Trim(s, "0123456789abcdefABCDEF") != "" {
+		return false
+	}
+	return s[0] == '0' || strings.Trim(s, "0123456789") != ""
+}
+
+// presetWorker returns a worker with the layout of the named preset, for
+// decoding.
+func presetWorker(name string) (*sanic.Worker, error) {
+	var names []string
+	for _, p := range sanic.Presets() {
+		if p.Name == name {
+			return sanic.New(sanic.WorkerConfig{Layout: p.Layout})
+		}
+		names = append(names, p.Name)
+	}
+	return nil, fmt.Errorf("sanic: unknown layout %q, want one of %s",
+		name, strings.Join(names, ", "))
+}
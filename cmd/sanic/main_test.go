@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ifo/sanic"
+)
+
+func runCmd(t *testing.T, stdin string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	status = run(args, strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), status
+}
+
+func TestDecodeFile(t *testing.T) {
+	w := sanic.NewWorker9(2)
+	a, b := w.IDString(w.NextID()), w.IDString(w.NextID())
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(path, []byte(a+"\nbad\n "+b+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, errOut, status := runCmd(t, "", "decode-file", "-layout", "NewWorker9",
+		"-policy", "skip", path)
+	if status != 0 {
+		t.Fatalf("status %d: %s", status, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], a+",") ||
+		!strings.HasPrefix(lines[2], b+",") || !strings.HasSuffix(lines[2], ",2,1") {
+		t.Errorf("output:\n%s", out)
+	}
+	if !strings.Contains(errOut, "1 malformed") {
+		t.Errorf("stderr %q", errOut)
+	}
+
+	// Standard input, aborting on the bad line.
+	out, errOut, status = runCmd(t, a+"\nbad\n", "decode-file", "-layout", "NewWorker9",
+		"-no-header")
+	if status != 1 || !strings.Contains(errOut, "line 2") || !strings.HasPrefix(out, a+",") {
+		t.Errorf("abort: status %d, stdout %q, stderr %q", status, out, errOut)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	w := sanic.NewWorker9(3)
+	id := w.NextID()
+	want := fmt.Sprintf("id=%d string=%s hex=%s %s\n", id, w.IDString(id), w.IDHex(id), w.Decompose(id))
+	for _, in := range []string{
+		w.IDString(id),
+		w.IDHex(id),
+		"0x" + w.IDHex(id),
+		strconv.FormatInt(id, 10),
+	} {
+		out, errOut, status := runCmd(t, "", "inspect", "-layout", "NewWorker9", in)
+		if status != 0 {
+			t.Errorf("%s: status %d: %s", in, status, errOut)
+		} else if out != want {
+			t.Errorf("%s: got %q, want %q", in, out, want)
+		}
+	}
+	if !strings.Contains(want, " worker=3 ") {
+		t.Errorf("output %q is missing the worker ID", want)
+	}
+
+	out, _, status := runCmd(t, "", "inspect", "-layout", "NewWorker9", "-format", "decimal",
+		strconv.FormatInt(id, 10), strconv.FormatInt(id+1, 10))
+	if status != 0 || strings.Count(out, "\n") != 2 {
+		t.Errorf("two ids: status %d, output %q", status, out)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"nope"},
+		{"decode-file", "-layout", "NoSuchLayout"},
+		{"decode-file", "-policy", "ignore"},
+		{"decode-file", "a", "b"},
+		{"inspect"},
+		{"inspect", "-format", "octal", "1"},
+		{"inspect", "not an id"},
+		{"inspect", "-1"},
+		{"inspect", "-format", "hex", "12"},
+	} {
+		if _, _, status := runCmd(t, "", args...); status == 0 {
+			t.Errorf("%q: status 0", args)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package sanic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IDStringCompact is IDString without its leading '-' characters, which
+// encode zero bits, keeping at least one character. Compact strings are
+// shorter for small IDs but, unlike IDString, do not sort in ID order.
+func (w *Worker) IDStringCompact(id int64) string {
+	s := strings.TrimLeft(w.IDString(id), "-")
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// ParseCompact parses either a compact string from IDStringCompact or a
+// fixed-width one from IDString. Strings shorter than StringLength are read
+// as if padded on the left with '-' to that length, and those of exactly
+// StringLength are read as fixed width; since padding with zeros does not
+// change the value, each string has one meaning whichever form it was
+// made in. It returns ErrMalformedID for an empty string, one longer than
+// StringLength, or one with characters outside the encoding alphabet, and
+// ErrInvalidID if the ID does not fit the worker's layout.
+func (w *Worker) ParseCompact(s string) (int64, error) {
+	n := w.StringLength()
+	if len(s) == 0 || len(s) > n {
+		return 0, fmt.Errorf("%w: %q is %d bytes, want 1 to %d",
+			ErrMalformedID, s, len(s), n)
+	}
+	return w.ParseIDString(strings.Repeat("-", n-len(s)) + s)
+}
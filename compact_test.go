@@ -0,0 +1,71 @@
+package sanic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIDStringCompact(t *testing.T) {
+	w := NewWorker10(5)
+	for _, c := range []struct {
+		id   int64
+		want string
+	}{
+		{1, "0"},
+		{63, "z"},
+		{64, "0-"},
+		{w.compose(w.CustomEpoch, 0, 9), "4-8"}, // worker 5, sequence 9
+	} {
+		if got := w.IDStringCompact(c.id); got != c.want {
+			t.Errorf("IDStringCompact(%d) = %q, want %q", c.id, got, c.want)
+		}
+		if back, err := w.ParseCompact(c.want); err != nil || back != c.id {
+			t.Errorf("ParseCompact(%q) = %d, %v; want %d", c.want, back, err, c.id)
+		}
+	}
+	// Zero is not a valid ID, but its compact form keeps one character.
+	if got := w.IDStringCompact(0); got != "-" {
+		t.Errorf("IDStringCompact(0) = %q", got)
+	}
+}
+
+// A string means the same ID whether it was made compact or fixed width, so
+// the two forms are never ambiguous.
+func TestParseCompactBothForms(t *testing.T) {
+	for _, w := range presetWorkers() {
+		ids := make([]int64, 100)
+		w.NextIDs(ids)
+		ids = append(ids, 1, 1<<(w.TotalBits-1)-1)
+		for _, id := range ids {
+			fixed, compact := w.IDString(id), w.IDStringCompact(id)
+			if len(compact) > len(fixed) || !strings.HasSuffix(fixed, compact) {
+				t.Fatalf("%s: compact %q is not a suffix of %q", w, compact, fixed)
+			}
+			forms := []string{fixed, compact}
+			if len(compact) < len(fixed) {
+				forms = append(forms, "-"+compact) // partly padded
+			}
+			for _, s := range forms {
+				if back, err := w.ParseCompact(s); err != nil || back != id {
+					t.Fatalf("%s: ParseCompact(%q) = %d, %v; want %d", w, s, back, err, id)
+				}
+			}
+		}
+	}
+}
+
+func TestParseCompactErrors(t *testing.T) {
+	w := NewWorker10(5)
+	for _, s := range []string{"", "00000000000", "a!", "é"} {
+		if _, err := w.ParseCompact(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("ParseCompact(%q) = %v, want ErrMalformedID", s, err)
+		}
+	}
+	// "-" is zero, and a leading "z" sets the reserved top bit.
+	for _, s := range []string{"-", "z---------"} {
+		if _, err := w.ParseCompact(s); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("ParseCompact(%q) = %v, want ErrInvalidID", s, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package sanic
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WorkerConfig describes a worker for New.
+type WorkerConfig struct {
+	ID     int64
+	Layout Layout
+	// ReservedRanges is copied to the worker's ReservedRanges, sorted and
+	// merged.
+	ReservedRanges [][2]int64
+}
+
+// New is NewTaggedWorker for a WorkerConfig, returning an error instead of
+// exiting when the layout or ID is invalid, or when the reserved ranges
+// cover the layout's last tick.
+func New(cfg WorkerConfig) (*Worker, error) {
+	w, err := fromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w.start()
+	return w, nil
+}
+
+// Config returns the WorkerConfig that New would need to build a worker
+// like w.
+func (w *Worker) Config() WorkerConfig {
+	return WorkerConfig{
+		ID:             w.ID,
+		Layout:         w.Layout(),
+		ReservedRanges: slices.Clone(w.ReservedRanges),
+	}
+}
+
+// fromConfig is New without the call to start.
+func fromConfig(cfg WorkerConfig) (*Worker, error) {
+	l := cfg.Layout
+	if err := l.check(); err != nil {
+		return nil, err
+	}
+	if cfg.ID < 0 || cfg.ID >= 1<<l.IDBits {
+		return nil, fmt.Errorf("%w: %d does not fit in %d bits",
+			ErrInvalidWorkerID, cfg.ID, l.IDBits)
+	}
+	reserved, err := mergeRanges(cfg.ReservedRanges)
+	if err != nil {
+		return nil, err
+	}
+	w := newTaggedWorker(cfg.ID, l.CustomEpoch, l.IDBits, l.TagBits,
+		l.SequenceBits, l.TimeStampBits, l.Frequency)
+	w.VersionBit = l.VersionBit
+	if len(reserved) > 0 {
+		w.ReservedRanges = reserved
+		if err := w.checkReserved(); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// check returns ErrInvalidLayout if l cannot be used to generate IDs.
+func (l Layout) check() error {
+	switch total := l.TotalBits(); {
+	case l.TimeStampBits == 0:
+		return fmt.Errorf("%w: no timestamp bits", ErrInvalidLayout)
+	case total > 64:
+		return fmt.Errorf("%w: %d bits do not fit in an int64",
+			ErrInvalidLayout, total)
+	case total%6 != 0:
+		return fmt.Errorf("%w: totalBits + 1 must be evenly divisible by 6",
+			ErrInvalidLayout)
+	case l.Frequency <= 0:
+		return fmt.Errorf("%w: frequency must be positive", ErrInvalidLayout)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package sanic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewLayoutErrors(t *testing.T) {
+	ok := NewWorker10(0).Layout()
+	for _, tc := range []struct {
+		name   string
+		change func(l *Layout)
+	}{
+		{"no timestamp", func(l *Layout) { l.TimeStampBits = 0 }},
+		{"too wide", func(l *Layout) { l.TimeStampBits += 6 }},
+		{"not a multiple of 6", func(l *Layout) { l.TimeStampBits-- }},
+		{"zero frequency", func(l *Layout) { l.Frequency = 0 }},
+		{"negative frequency", func(l *Layout) { l.Frequency = -time.Millisecond }},
+	} {
+		l := ok
+		tc.change(&l)
+		if w, err := New(WorkerConfig{Layout: l}); !errors.Is(err, ErrInvalidLayout) || w != nil {
+			t.Errorf("%s: New = %v, %v, want ErrInvalidLayout", tc.name, w, err)
+		}
+	}
+	for _, id := range []int64{-1, 64} {
+		if _, err := New(WorkerConfig{ID: id, Layout: ok}); !errors.Is(err, ErrInvalidWorkerID) {
+			t.Errorf("New with ID %d = %v, want ErrInvalidWorkerID", id, err)
+		}
+	}
+}
+
+// Config round-trips through New.
+func TestConfig(t *testing.T) {
+	tagged, err := New(WorkerConfig{ID: 3, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range append(presetWorkers(), tagged) {
+		cfg := w.Config()
+		got, err := New(cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", w, err)
+		}
+		if got.ID != w.ID || got.Layout() != w.Layout() {
+			t.Errorf("New(%s.Config()) = %s", w, got)
+		}
+	}
+}
+
+func TestConfigReservedRanges(t *testing.T) {
+	in := [][2]int64{{20, 30}, {1, 5}, {4, 10}}
+	w, err := New(WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout(), ReservedRanges: in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int64{{1, 10}, {20, 30}}
+	if !slices.Equal(w.ReservedRanges, want) {
+		t.Errorf("ReservedRanges = %v, want %v", w.ReservedRanges, want)
+	}
+	cfg := w.Config()
+	cfg.ReservedRanges[0][1] = 99
+	if w.ReservedRanges[0][1] != 10 {
+		t.Error("Config shares ReservedRanges with the worker")
+	}
+}
@@ -0,0 +1,57 @@
+package sanic
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math"
+)
+
+// cursorVersion is the first character of every cursor.
+const cursorVersion = '1'
+
+// Cursor returns an opaque, URL-safe pagination cursor for id: a version
+// character, the ID string, and a check character derived from a CRC-32 of
+// the rest.
+func (w *Worker) Cursor(id int64) string {
+	body := string(cursorVersion) + w.IDString(id)
+	return body + string(cursorCheck(body))
+}
+
+// ParseCursor returns the ID in a cursor from Cursor. It returns an error
+// wrapping ErrBadCursor for anything that did not come from Cursor with the
+// same layout.
+func (w *Worker) ParseCursor(s string) (int64, error) {
+	if len(s) != w.StringLength()+2 || s[0] != cursorVersion {
+		return 0, fmt.Errorf("%w: %q", ErrBadCursor, s)
+	}
+	body := s[:len(s)-1]
+	if s[len(s)-1] != cursorCheck(body) {
+		return 0, fmt.Errorf("%w: %q fails its check", ErrBadCursor, s)
+	}
+	id, err := w.ParseIDString(body[1:])
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBadCursor, err)
+	}
+	return id, nil
+}
+
+// NextPageBound returns the smallest valid ID strictly greater than lastID,
+// for queries of the form id >= bound, and false if there is none because
+// lastID is at or past the layout's largest ID. Every bit pattern that
+// fits the layout, other than zero, is an ID some worker could generate,
+// so nothing can be skipped: the bound is lastID+1, but at least 1, or with
+// VersionBit at least the smallest version 1 ID.
+func (w *Worker) NextPageBound(lastID int64) (int64, bool) {
+	maxID := int64(1)<<(w.TotalBits-1) - 1
+	if w.VersionBit && lastID < math.MinInt64+maxID {
+		return max(lastID+1, math.MinInt64+1), true
+	}
+	if lastID >= maxID {
+		return 0, false
+	}
+	return max(lastID+1, 1), true
+}
+
+func cursorCheck(body string) byte {
+	return alphabet[crc32.ChecksumIEEE([]byte(body))%64]
+}
@@ -0,0 +1,119 @@
+package sanic
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	w := NewWorker10(1)
+	for range 100 {
+		id := w.NextID()
+		c := w.Cursor(id)
+		if got, err := w.ParseCursor(c); err != nil || got != id {
+			t.Fatalf("ParseCursor(%q) = %d, %v, want %d", c, got, err, id)
+		}
+	}
+}
+
+func TestCursorTampered(t *testing.T) {
+	w := NewWorker10(1)
+	c := w.Cursor(w.NextID())
+	bad := []string{"", c[:len(c)-1], c + "-", "2" + c[1:]}
+	for i := range len(c) {
+		b := []byte(c)
+		b[i] = alphabet[(decodeMap[b[i]]+1)%64]
+		bad = append(bad, string(b))
+	}
+	for _, s := range bad {
+		if _, err := w.ParseCursor(s); !errors.Is(err, ErrBadCursor) {
+			t.Errorf("ParseCursor(%q) = %v, want ErrBadCursor", s, err)
+		}
+	}
+	// A cursor from another layout has the wrong length.
+	if _, err := NewWorker9(1).ParseCursor(c); !errors.Is(err, ErrBadCursor) {
+		t.Errorf("cursor from another layout: %v", err)
+	}
+}
+
+func TestPagingAcrossTicks(t *testing.T) {
+	now := time.Now()
+	w := NewWorker10(1)
+	w.TimeFunc = func() time.Time { return now }
+	w.Warmup()
+	var ids []int64
+	for range 3 * (w.MaxSequence + 1) {
+		if len(ids)%int(w.MaxSequence+1) == 0 {
+			now = now.Add(time.Millisecond)
+		}
+		ids = append(ids, w.NextID())
+	}
+
+	// Page through in pages of 1000, resuming from each page's cursor.
+	var got []int64
+	bound := int64(1)
+	for {
+		page := 0
+		for _, id := range ids {
+			if id >= bound && page < 1000 {
+				got = append(got, id)
+				page++
+			}
+		}
+		if page == 0 {
+			break
+		}
+		last, err := w.ParseCursor(w.Cursor(got[len(got)-1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ok bool
+		if bound, ok = w.NextPageBound(last); !ok {
+			t.Fatal("no bound after a fresh ID")
+		}
+	}
+	if len(got) != len(ids) {
+		t.Fatalf("paged %d IDs, want %d", len(got), len(ids))
+	}
+	for i := range ids {
+		if got[i] != ids[i] {
+			t.Fatalf("page order differs at %d", i)
+		}
+	}
+}
+
+func TestNextPageBound(t *testing.T) {
+	w := NewWorker10(1)
+	maxID := int64(1)<<(w.TotalBits-1) - 1
+	v := NewWorker10(1)
+	v.VersionBit = true
+	for _, tc := range []struct {
+		w      *Worker
+		last   int64
+		want   int64
+		wantOK bool
+	}{
+		{w, 41, 42, true},
+		{w, 0, 1, true},
+		{w, -5, 1, true},
+		{w, math.MinInt64, 1, true},
+		{w, maxID - 1, maxID, true},
+		{w, maxID, 0, false},
+		{w, math.MaxInt64, 0, false},
+		{v, math.MinInt64, math.MinInt64 + 1, true},
+		{v, math.MinInt64 + maxID, 1, true},
+		{v, -1, 1, true},
+		{v, maxID, 0, false},
+	} {
+		got, ok := tc.w.NextPageBound(tc.last)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("NextPageBound(%d) with VersionBit %v = %d, %v, want %d, %v",
+				tc.last, tc.w.VersionBit, got, ok, tc.want, tc.wantOK)
+		}
+		if ok && tc.w.Validate(got) != nil {
+			t.Errorf("NextPageBound(%d) = %d is not a valid ID", tc.last, got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package sanic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// maxDecodeLine is the longest input line DecodeReader reads; longer lines
+// are malformed.
+const maxDecodeLine = 4096
+
+// DecodePolicy says what DecodeReader does with a line that is not an ID
+// string.
+type DecodePolicy int
+
+const (
+	// DecodeAbort makes reading fail with an error naming the line.
+	DecodeAbort DecodePolicy = iota
+	// DecodeSkip leaves the line out and counts it.
+	DecodeSkip
+	// DecodeAnnotate writes the line with its error in an extra error
+	// column, and counts it.
+	DecodeAnnotate
+)
+
+// DecodeOptions configure DecodeReader.
+type DecodeOptions struct {
+	Policy   DecodePolicy
+	NoHeader bool // leave out the header row
+}
+
+// DecodedReader is the CSV produced by DecodeReader.
+type DecodedReader struct {
+	src     *bufio.Reader
+	w       *Worker
+	opts    DecodeOptions
+	out     bytes.Buffer
+	csv     *csv.Writer
+	line    int
+	skipped int
+	err     error // returned once out is drained
+}
+
+// DecodeReader reads newline-delimited ID strings from r and returns a
+// reader of CSV rows of id, timestamp_rfc3339nano, worker_id and sequence,
+// decoded with w's layout, for tools that cannot decode IDs themselves.
+// Input is read only as output is, one line at a time, so memory is bounded
+// however large r is. Surrounding whitespace and blank lines are ignored,
+// and lines over 4096 bytes are malformed.
+//
+// Malformed lines are handled by opts.Policy; under DecodeAnnotate every
+// row has a fifth error column. It returns ErrUninitialized for a Worker
+// not made by a constructor. The sanic command's decode-file runs it on a
+// file or standard input.
+func DecodeReader(r io.Reader, w *Worker, opts DecodeOptions) (*DecodedReader, error) {
+	if err := w.checkInitialized(); err != nil {
+		return nil, err
+	}
+	if opts.Policy < DecodeAbort || opts.Policy > DecodeAnnotate {
+		return nil, fmt.Errorf("sanic: unknown decode policy %d", opts.Policy)
+	}
+	d := &DecodedReader{
+		src:  bufio.NewReaderSize(r, maxDecodeLine+1),
+		w:    w,
+		opts: opts,
+	}
+	d.csv = csv.NewWriter(&d.out)
+	if !opts.NoHeader {
+		header := []string{"id", "timestamp_rfc3339nano", "worker_id", "sequence"}
+		if opts.Policy == DecodeAnnotate {
+			header = append(header, "error")
+		}
+		if err := d.csv.Write(header); err != nil {
+			return nil, err
+		}
+		d.csv.Flush()
+		if err := d.csv.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Read reads CSV output. Under DecodeAbort, the first malformed line makes
+// it return an error naming the line, after the rows before it.
+func (d *DecodedReader) Read(p []byte) (int, error) {
+	for d.out.Len() == 0 && d.err == nil {
+		d.err = d.next()
+	}
+	if d.out.Len() > 0 {
+		return d.out.Read(p)
+	}
+	return 0, d.err
+}
+
+// Skipped returns how many malformed lines have been skipped or annotated.
+func (d *DecodedReader) Skipped() int {
+	return d.skipped
+}
+
+// next decodes one input line into d.out.
+func (d *DecodedReader) next() error {
+	line, long, err := d.readLine()
+	if err != nil {
+		return err
+	}
+	s := string(bytes.TrimSpace(line))
+	if s == "" && !long {
+		return nil
+	}
+
+	var id int64
+	if long {
+		// s is at most the first maxDecodeLine bytes, which keeps
+		// annotated rows bounded.
+		err = fmt.Errorf("%w: line is over %d bytes", ErrMalformedID,
+			maxDecodeLine)
+	} else {
+		id, err = d.w.ParseIDString(s)
+	}
+	var row []string
+	switch {
+	case err == nil:
+		p := d.w.Decompose(id)
+		row = []string{
+			s,
+			p.Time.Format(time.RFC3339Nano),
+			strconv.FormatInt(p.WorkerID, 10),
+			strconv.FormatInt(p.Sequence, 10),
+		}
+		if d.opts.Policy == DecodeAnnotate {
+			row = append(row, "")
+		}
+	case d.opts.Policy == DecodeAbort:
+		return fmt.Errorf("sanic: line %d: %w", d.line, err)
+	case d.opts.Policy == DecodeSkip:
+		d.skipped++
+		return nil
+	default:
+		d.skipped++
+		row = []string{s, "", "", "", err.Error()}
+	}
+	if err := d.csv.Write(row); err != nil {
+		return err
+	}
+	d.csv.Flush()
+	return d.csv.Error()
+}
+
+// readLine returns the next line, or its first maxDecodeLine bytes and
+// long set if it is longer, and io.EOF after the last.
+func (d *DecodedReader) readLine() (line []byte, long bool, err error) {
+	line, err = d.src.ReadSlice('\n')
+	for errors.Is(err, bufio.ErrBufferFull) {
+		if !long {
+			line, long = bytes.Clone(line[:maxDecodeLine]), true
+		}
+		_, err = d.src.ReadSlice('\n')
+	}
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	d.line++
+	return line, long, nil
+}
@@ -0,0 +1,114 @@
+package sanic
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeAll(t *testing.T, in string, opts DecodeOptions) ([][]string, int, error) {
+	t.Helper()
+	w := NewWorker10(3)
+	d, err := DecodeReader(strings.NewReader(in), w, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(d)
+	rows, cerr := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if cerr != nil {
+		t.Fatalf("output is not CSV: %v\n%s", cerr, out)
+	}
+	return rows, d.Skipped(), err
+}
+
+func TestDecodeReaderRows(t *testing.T) {
+	w := NewWorker10(3)
+	id := w.NextID()
+	s := w.IDString(id)
+	p := w.Decompose(id)
+
+	rows, skipped, err := decodeAll(t, s+"\n\n  "+s+"\t\r\n", DecodeOptions{})
+	if err != nil || skipped != 0 {
+		t.Fatalf("err %v, skipped %d", err, skipped)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header and 2: %q", len(rows), rows)
+	}
+	if got := strings.Join(rows[0], ","); got != "id,timestamp_rfc3339nano,worker_id,sequence" {
+		t.Errorf("header %q", got)
+	}
+	for _, row := range rows[1:] {
+		if row[0] != s || row[1] != p.Time.Format(time.RFC3339Nano) ||
+			row[2] != "3" || row[3] != strconv.FormatInt(p.Sequence, 10) {
+			t.Errorf("row %q for %s", row, s)
+		}
+	}
+}
+
+func TestDecodeReaderLongLines(t *testing.T) {
+	s := NewWorker10(3).IDString(NewWorker10(3).NextID())
+	long := strings.Repeat("x", maxDecodeLine+1)
+	// Exactly maxDecodeLine bytes once trimmed, but longer with padding.
+	padded := "  " + strings.Repeat("y", maxDecodeLine-2) + "  "
+	in := long + "\n" + padded + "\n" + s + "\n"
+
+	rows, skipped, err := decodeAll(t, in, DecodeOptions{Policy: DecodeAnnotate, NoHeader: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 || len(rows) != 3 {
+		t.Fatalf("skipped %d, %d rows", skipped, len(rows))
+	}
+	if len(rows[0][0]) != maxDecodeLine || !strings.Contains(rows[0][4], "over") {
+		t.Errorf("long line annotated as %d bytes, %q", len(rows[0][0]), rows[0][4])
+	}
+	if rows[1][0] != strings.TrimSpace(padded) || rows[1][4] == "" {
+		t.Errorf("padded line not annotated: %q", rows[1][4])
+	}
+	if rows[2][0] != s || rows[2][4] != "" {
+		t.Errorf("valid line after long ones: %q", rows[2])
+	}
+}
+
+func TestDecodeReaderPolicies(t *testing.T) {
+	w := NewWorker10(3)
+	var b strings.Builder
+	const n, bad = 20000, 100
+	for i := range n {
+		if i%(n/bad) == 7 {
+			b.WriteString("not an id\n")
+			continue
+		}
+		b.WriteString(w.IDString(w.NextID()) + "\n")
+	}
+	in := b.String()
+
+	rows, skipped, err := decodeAll(t, in, DecodeOptions{Policy: DecodeSkip, NoHeader: true})
+	if err != nil || skipped != bad || len(rows) != n-bad {
+		t.Errorf("skip: err %v, skipped %d, %d rows", err, skipped, len(rows))
+	}
+	rows, skipped, err = decodeAll(t, in, DecodeOptions{Policy: DecodeAnnotate, NoHeader: true})
+	if err != nil || skipped != bad || len(rows) != n {
+		t.Errorf("annotate: err %v, skipped %d, %d rows", err, skipped, len(rows))
+	}
+	rows, _, err = decodeAll(t, in, DecodeOptions{NoHeader: true})
+	if !errors.Is(err, ErrMalformedID) || !strings.Contains(err.Error(), "line 8") {
+		t.Errorf("abort: err %v", err)
+	}
+	if len(rows) != 7 {
+		t.Errorf("abort: %d rows before the bad line, want 7", len(rows))
+	}
+}
+
+func TestDecodeReaderErrors(t *testing.T) {
+	if _, err := DecodeReader(strings.NewReader(""), &Worker{}, DecodeOptions{}); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("zero Worker: %v", err)
+	}
+	if _, err := DecodeReader(strings.NewReader(""), NewWorker10(0), DecodeOptions{Policy: 9}); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
@@ -0,0 +1,46 @@
+package sanic
+
+import (
+	"fmt"
+	"time"
+)
+
+// Parts are the fields packed into an ID.
+type Parts struct {
+	Time     time.Time
+	Tick     int64 // Time in units of Frequency since the unix epoch
+	WorkerID int64
+	Tag      int64 // always 0 for layouts without TagBits
+	Sequence int64
+	Version  int // the sign bit, always 0 for layouts without VersionBit
+}
+
+// String returns the fields of p as space-separated key=value pairs, with
+// Time in RFC 3339 format.
+func (p Parts) String() string {
+	return fmt.Sprintf("time=%s tick=%d worker=%d tag=%d sequence=%d version=%d",
+		p.Time.UTC().Format(time.RFC3339Nano), p.Tick, p.WorkerID, p.Tag, p.Sequence, p.Version)
+}
+
+// MarshalText implements encoding.TextMarshaler using the String form, so
+// decomposed IDs can be logged or written out as text directly.
+func (p Parts) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// Decompose unpacks id using the worker's layout. It does not depend on the
+// worker's own ID, so it decodes IDs from any worker with the same layout.
+func (w *Worker) Decompose(id int64) Parts {
+	return w.Layout().Decompose(id)
+}
+
+// Timestamp returns the time id was generated, to the worker's Frequency.
+func (w *Worker) Timestamp(id int64) time.Time {
+	return w.Layout().Timestamp(id)
+}
+
+// Validate returns ErrInvalidID if id could not have been generated by any
+// worker with this worker's layout.
+func (w *Worker) Validate(id int64) error {
+	return w.Layout().Validate(id)
+}
@@ -0,0 +1,67 @@
+package sanic
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecomposeRoundTrip(t *testing.T) {
+	tagged, err := New(WorkerConfig{ID: 5, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range append(presetWorkers(), tagged) {
+		l := w.Layout()
+		tick := w.CustomEpoch + 123456
+		for _, c := range [][2]int64{{0, 0}, {l.MaxTag(), l.MaxSequence()}, {l.MaxTag() / 2, 77 % (l.MaxSequence() + 1)}} {
+			p := w.Decompose(w.compose(tick, c[0], c[1]))
+			if p.Tick != tick || p.WorkerID != w.ID || p.Tag != c[0] || p.Sequence != c[1] || p.Version != 0 {
+				t.Errorf("%s: tag %d sequence %d decomposed to %+v", w, c[0], c[1], p)
+			}
+			if want := time.Unix(0, tick*int64(w.Frequency)); !p.Time.Equal(want) {
+				t.Errorf("%s: time %s, want %s", w, p.Time, want)
+			}
+		}
+	}
+}
+
+// Decoding depends only on the layout, not the decoding worker's ID.
+func TestDecomposeOtherWorker(t *testing.T) {
+	a, b := NewWorker10(1), NewWorker10(62)
+	id := a.NextID()
+	if pa, pb := a.Decompose(id), b.Decompose(id); pa != pb || pb.WorkerID != 1 {
+		t.Errorf("worker 62 decoded %+v, worker 1 %+v", pb, pa)
+	}
+	if !b.Timestamp(id).Equal(a.Decompose(id).Time) {
+		t.Error("Timestamp disagrees with Decompose")
+	}
+}
+
+func TestDecomposeVersionBit(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	id := NewWorker10(3).compose(l.CustomEpoch+99, 0, 4) | math.MinInt64
+	p := l.Decompose(id)
+	if p.Version != 1 || p.Tick != l.CustomEpoch+99 || p.WorkerID != 3 || p.Sequence != 4 {
+		t.Errorf("version 1 ID decomposed to %+v", p)
+	}
+	if err := l.Validate(id); err != nil {
+		t.Errorf("Validate(version 1 ID) = %v", err)
+	}
+}
+
+func TestDecomposeNonPositive(t *testing.T) {
+	w := NewWorker10(3)
+	for _, id := range []int64{0, -1, math.MinInt64} {
+		if p := w.Decompose(id); p != (Parts{}) || !w.Timestamp(id).IsZero() {
+			t.Errorf("Decompose(%d) = %+v, want the zero Parts", id, p)
+		}
+		if err := w.Validate(id); err == nil {
+			t.Errorf("Validate(%d) accepted", id)
+		}
+	}
+	if err := w.Validate(1 << 59); err == nil {
+		t.Error("Validate accepted an ID wider than the layout")
+	}
+}
@@ -0,0 +1,89 @@
+package sanic
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultWorkerIDEnv names the environment variable holding the worker ID of
+// the default worker. If it is unset the ID is 0.
+const DefaultWorkerIDEnv = "SANIC_WORKER_ID"
+
+// ErrDefaultInUse is returned by SetDefault once the default worker has been
+// used.
+var ErrDefaultInUse = errors.New("sanic: default worker already in use")
+
+var (
+	defaultOnce    sync.Once
+	defaultMutex   sync.Mutex
+	defaultUsed    bool
+	defaultPending *Worker
+	defaultWorker  *Worker
+	defaultErr     error // why the default worker could not be made
+)
+
+// NextID returns an ID from the default worker.
+func NextID() int64 {
+	return Default().NextID()
+}
+
+// NextStringID returns an ID string from the default worker.
+func NextStringID() string {
+	w := Default()
+	return w.IDString(w.NextID())
+}
+
+// Default returns the default worker, creating it on first use. Unless
+// SetDefault was called first, it is NewWorker10 with the worker ID from
+// the SANIC_WORKER_ID environment variable, and Default panics, on every
+// call, if that is not a valid ID.
+func Default() *Worker {
+	defaultOnce.Do(func() {
+		defaultMutex.Lock()
+		defer defaultMutex.Unlock()
+
+		defaultUsed = true
+		defaultWorker = defaultPending
+		if defaultWorker == nil {
+			var id int64
+			id, defaultErr = defaultWorkerID()
+			if defaultErr == nil {
+				defaultWorker = NewWorker10(id)
+			}
+		}
+	})
+	if defaultErr != nil {
+		panic(defaultErr)
+	}
+	return defaultWorker
+}
+
+// SetDefault replaces the default worker. It returns ErrDefaultInUse if the
+// default worker has already been used, since mixing layouts in one process
+// would break ordering and uniqueness.
+func SetDefault(w *Worker) error {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+
+	if defaultUsed {
+		return ErrDefaultInUse
+	}
+	defaultPending = w
+	return nil
+}
+
+func defaultWorkerID() (int64, error) {
+	s := os.Getenv(DefaultWorkerIDEnv)
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 0 || id > worker10MaxID {
+		return 0, fmt.Errorf("%w: %s=%q is not between 0 and %d",
+			ErrInvalidWorkerID, DefaultWorkerIDEnv, s, worker10MaxID)
+	}
+	return id, nil
+}
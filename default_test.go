@@ -0,0 +1,102 @@
+package sanic
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// resetDefault forgets the default worker for the length of the test.
+func resetDefault(t *testing.T) {
+	forget := func() {
+		defaultMutex.Lock()
+		defer defaultMutex.Unlock()
+		defaultOnce = sync.Once{}
+		defaultUsed, defaultPending, defaultWorker, defaultErr = false, nil, nil, nil
+	}
+	forget()
+	t.Cleanup(forget)
+}
+
+func TestDefaultFromEnv(t *testing.T) {
+	resetDefault(t)
+	t.Setenv(DefaultWorkerIDEnv, "7")
+	if w := Default(); w.ID != 7 || w != Default() {
+		t.Errorf("Default() = %v, want one worker with ID 7", w)
+	}
+	if NextID() <= 0 || len(NextStringID()) != Default().StringLength() {
+		t.Error("package-level generation failed")
+	}
+}
+
+func TestDefaultBadEnvPanicsEveryCall(t *testing.T) {
+	resetDefault(t)
+	t.Setenv(DefaultWorkerIDEnv, "64")
+	for i := range 2 {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidWorkerID) {
+					t.Errorf("call %d: recovered %v, want ErrInvalidWorkerID", i, err)
+				}
+			}()
+			Default()
+		}()
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	resetDefault(t)
+	w := NewWorker9(2)
+	if err := SetDefault(w); err != nil {
+		t.Fatal(err)
+	}
+	if Default() != w {
+		t.Error("Default() is not the worker passed to SetDefault")
+	}
+	if err := SetDefault(NewWorker9(3)); !errors.Is(err, ErrDefaultInUse) {
+		t.Errorf("SetDefault after use = %v, want ErrDefaultInUse", err)
+	}
+}
+
+func TestDefaultConcurrentFirstUse(t *testing.T) {
+	resetDefault(t)
+	const goroutines, perGoroutine = 16, 1000
+	workers := make([]*Worker, goroutines)
+	ids := make([][]int64, goroutines)
+	var start, wg sync.WaitGroup
+	start.Add(1)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start.Wait()
+			if g%2 == 0 {
+				workers[g] = Default()
+			}
+			for range perGoroutine {
+				ids[g] = append(ids[g], NextID())
+			}
+			if workers[g] == nil {
+				workers[g] = Default()
+			}
+		}()
+	}
+	start.Done()
+	wg.Wait()
+
+	for g, w := range workers {
+		if w != workers[0] {
+			t.Fatalf("goroutine %d got worker %p, goroutine 0 got %p", g, w, workers[0])
+		}
+	}
+	seen := make(map[int64]bool, goroutines*perGoroutine)
+	for _, gen := range ids {
+		for _, id := range gen {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+		}
+	}
+}
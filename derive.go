@@ -0,0 +1,123 @@
+package sanic
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// WithID returns a new worker built by New from w's Config with worker ID
+// newID, so it has w's layout, including VersionBit, and its reserved
+// ranges, but its own sequence state. It also takes w's tag prefixes, clock
+// and every other setting, sharing w's tick source if w is tick-driven and
+// w's range leases, so AllocateRange on either never leases the same worker
+// ID twice. OnNewInterval and Recent are per worker and are not carried
+// over.
+//
+// w and the workers derived from it, directly or through each other, form
+// a family whose members' worker IDs are distinct, so their IDs never
+// collide. WithID returns ErrInvalidWorkerID if newID does not fit the
+// layout, belongs to an open member of the family or is one of
+// RangeWorkerIDs, and ErrClosed if w is closed. Closing a member frees its
+// worker ID; OnRelease is called when the last one closes.
+func (w *Worker) WithID(newID int64) (*Worker, error) {
+	if err := w.checkInitialized(); err != nil {
+		return nil, err
+	}
+	if slices.Contains(w.RangeWorkerIDs, newID) {
+		return nil, fmt.Errorf("%w: %d is set aside for ranges",
+			ErrInvalidWorkerID, newID)
+	}
+	cfg := w.Config()
+	cfg.ID = newID
+	d, err := fromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.TagPrefixes = w.TagPrefixes
+	d.TimeFunc = w.TimeFunc
+	d.MaxClockJump = w.MaxClockJump
+	d.Borrow = w.Borrow
+	d.Health = w.Health
+	d.PausePolicy = w.PausePolicy
+	d.TTLClasses = w.TTLClasses
+	d.TickPolicy = w.TickPolicy
+	d.RangeWorkerIDs = w.RangeWorkerIDs
+	d.OnAnomaly = w.OnAnomaly
+	d.OnEpochExhausted = w.OnEpochExhausted
+	d.MaxEnsureWait = w.MaxEnsureWait
+	d.OnRelease = w.OnRelease
+	d.ticks = w.ticks
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return nil, ErrClosed
+	}
+	if w.family == nil {
+		w.family = &family{ids: map[int64]bool{w.ID: true}, release: w.OnRelease}
+	}
+	f := w.family
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.ids[newID] {
+		return nil, fmt.Errorf("%w: %d is in use in the family",
+			ErrInvalidWorkerID, newID)
+	}
+	f.ids[newID] = true
+	d.family = f
+	d.start()
+	d.ranges = w.ranges
+	return d, nil
+}
+
+// Close stops w from generating IDs: generation that can fail returns
+// ErrClosed, and NextID and NextIDs panic with it, including calls waiting
+// for Resume. UnsafeNextID and substreams are not checked. Closing a worker
+// from WithID frees its worker ID in its family, and once every member of
+// the family, or w alone if it has none, is closed, OnRelease is called.
+// Close always returns nil, and closing a closed worker does nothing.
+func (w *Worker) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed, w.paused = true, true
+	w.releaseNext()
+	f, release := w.family, w.OnRelease
+	w.mutex.Unlock()
+
+	if f != nil {
+		f.mutex.Lock()
+		delete(f.ids, w.ID)
+		last := len(f.ids) == 0
+		f.mutex.Unlock()
+		release = nil
+		if last {
+			release = f.release
+		}
+	}
+	if release != nil {
+		release()
+	}
+	return nil
+}
+
+// family is what the workers related by WithID share.
+type family struct {
+	mutex   sync.Mutex
+	ids     map[int64]bool // worker IDs of the open members
+	release func()
+}
+
+// has reports whether worker ID id belongs to an open member of f, which
+// may be nil.
+func (f *family) has(id int64) bool {
+	if f == nil {
+		return false
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.ids[id]
+}
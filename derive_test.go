@@ -0,0 +1,212 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithIDUnique(t *testing.T) {
+	parent := NewWorker10(0)
+	workers := []*Worker{parent}
+	for id := int64(1); id < 4; id++ {
+		d, err := parent.WithID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		workers = append(workers, d)
+	}
+	grand, err := workers[1].WithID(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workers = append(workers, grand)
+
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]int64, 20000)
+			for i := range ids {
+				ids[i] = w.NextID()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				if seen[id] {
+					t.Errorf("duplicate %d", id)
+					return
+				}
+				seen[id] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithIDRejects(t *testing.T) {
+	parent := NewWorker10(0)
+	parent.RangeWorkerIDs = []int64{9}
+	child, err := parent.WithID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		from *Worker
+		id   int64
+	}{
+		{parent, 0},  // its own ID
+		{parent, 1},  // a sibling's
+		{child, 0},   // the parent's, from the child
+		{parent, 9},  // set aside for ranges
+		{parent, 64}, // too large
+		{parent, -1},
+	} {
+		if _, err := tc.from.WithID(tc.id); !errors.Is(err, ErrInvalidWorkerID) {
+			t.Errorf("WithID(%d) from worker %d: %v", tc.id, tc.from.ID, err)
+		}
+	}
+	if _, err := (&Worker{}).WithID(1); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("zero Worker: %v", err)
+	}
+}
+
+func TestWithIDCopiesConfig(t *testing.T) {
+	w, err := New(WorkerConfig{
+		ID:             2,
+		Layout:         Layout{IDBits: 5, SequenceBits: 12, TimeStampBits: 42, Frequency: time.Millisecond, VersionBit: true},
+		ReservedRanges: [][2]int64{{1, 100}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.TTLClasses = []time.Duration{time.Hour}
+	w.PausePolicy = PauseError
+	d, err := w.WithID(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.VersionBit || d.NextID() >= 0 {
+		t.Error("VersionBit not carried over")
+	}
+	if len(d.ReservedRanges) != 1 || d.ReservedRanges[0] != [2]int64{1, 100} {
+		t.Errorf("ReservedRanges %v", d.ReservedRanges)
+	}
+	if len(d.TTLClasses) != 1 || d.PausePolicy != PauseError {
+		t.Error("settings not carried over")
+	}
+	if got := d.Decompose(d.NextID()).WorkerID; got != 3 {
+		t.Errorf("worker ID %d, want 3", got)
+	}
+}
+
+func TestWithIDTickDriven(t *testing.T) {
+	ticks := make(chan int64, 4)
+	cfg := WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}
+	w, err := NewTickDrivenWorker(cfg, ticks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := w.WithID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := cfg.Layout.CustomEpoch + 1000
+	ticks <- tick
+	for _, x := range []*Worker{w, d} {
+		if got := x.Decompose(x.NextID()).Tick; got != tick {
+			t.Errorf("worker %d stamped tick %d, want %d", x.ID, got, tick)
+		}
+	}
+}
+
+func TestWithIDSharesRanges(t *testing.T) {
+	w := NewWorker10(0)
+	w.RangeWorkerIDs = []int64{9}
+	d, err := w.WithID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.AllocateRange(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.AllocateRange(time.Hour); !errors.Is(err, ErrNoRange) {
+		t.Errorf("derived worker leased a leased worker ID: %v", err)
+	}
+	if u := d.RangeUsage(); u.Active != 1 {
+		t.Errorf("derived worker sees %d active ranges, want 1", u.Active)
+	}
+}
+
+func TestCloseRefcount(t *testing.T) {
+	released := 0
+	parent := NewWorker10(0)
+	parent.OnRelease = func() { released++ }
+	a, _ := parent.WithID(1)
+	b, _ := a.WithID(2)
+
+	parent.Close()
+	if released != 0 {
+		t.Fatal("released with derived workers open")
+	}
+	if _, err := parent.WithID(3); !errors.Is(err, ErrClosed) {
+		t.Errorf("WithID on a closed worker: %v", err)
+	}
+	// A closed member's worker ID is free again.
+	c, err := a.WithID(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []*Worker{a, b, a} {
+		w.Close()
+	}
+	if released != 0 {
+		t.Fatal("released with one derived worker open")
+	}
+	c.Close()
+	if released != 1 {
+		t.Fatalf("released %d times, want 1", released)
+	}
+
+	lone := NewWorker10(5)
+	lone.OnRelease = func() { released++ }
+	lone.Close()
+	if released != 2 {
+		t.Error("worker without a family not released")
+	}
+}
+
+func TestClosedGeneration(t *testing.T) {
+	w := NewWorker10(0)
+	w.Pause()
+	errs := make(chan error)
+	go func() {
+		_, err := w.NextIDContext(context.Background())
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	w.Close()
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Errorf("waiting NextIDContext: %v", err)
+	}
+	if _, err := w.NextIDTagged(0); !errors.Is(err, ErrClosed) {
+		t.Errorf("NextIDTagged: %v", err)
+	}
+	if err := w.Resume(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Resume: %v", err)
+	}
+	if w.Paused() {
+		t.Error("closed worker reports paused")
+	}
+	defer func() {
+		if r := recover(); r != ErrClosed {
+			t.Errorf("NextID panicked with %v, want ErrClosed", r)
+		}
+	}()
+	w.NextID()
+}
@@ -0,0 +1,124 @@
+package sanic
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DuplicateDetector remembers IDs from the most recent horizon of time and
+// reports repeats, using memory bounded by the horizon and the layout's
+// capacity per tick rather than by the number of IDs seen.
+//
+// IDs are bucketed by tick, and each tick keeps a sparse bitset over the
+// rest of the ID (worker ID, tag and sequence). Once an ID is older than the
+// horizon, measured from the newest ID seen, its tick has been evicted, so
+// a repeat of it is not detected: Seen returns false and counts it in
+// DetectorStats.TooOld. IDs arriving that late are false negatives by
+// design.
+type DuplicateDetector struct {
+	layout  Layout
+	mutex   sync.Mutex
+	ticks   []detectorTick
+	maxTick int64
+	started bool
+	tooOld  int64
+	invalid int64
+}
+
+type detectorTick struct {
+	tick  int64
+	words map[int64]uint64
+	count int64
+}
+
+// DetectorStats describes a DuplicateDetector's window.
+type DetectorStats struct {
+	Ticks  int   // ticks currently holding IDs
+	IDs    int64 // IDs currently remembered
+	TooOld int64 // IDs that arrived after their tick was evicted
+	// Invalid counts IDs that Validate rejects for the layout, such as
+	// zero or negative ones, none of which are recorded.
+	Invalid int64
+}
+
+// NewDuplicateDetector returns a detector for IDs with layout l that
+// remembers horizon worth of ticks, and at least one.
+func NewDuplicateDetector(l Layout, horizon time.Duration) *DuplicateDetector {
+	n := int64(horizon / l.Frequency)
+	if n < 1 {
+		n = 1
+	}
+	return &DuplicateDetector{layout: l, ticks: make([]detectorTick, n)}
+}
+
+// Seen records id and reports whether it was already recorded. IDs that
+// the layout's Validate rejects are not recorded: Seen returns false and
+// counts them in DetectorStats.Invalid.
+func (d *DuplicateDetector) Seen(id int64) bool {
+	valid := d.layout.Validate(id) == nil
+	shift := d.layout.timeStampShift()
+	version := int64(0)
+	if id < 0 { // only valid with VersionBit
+		id, version = id&math.MaxInt64, 1
+	}
+	tick := id >> shift
+	rest := version<<shift | id&(1<<shift-1)
+	n := int64(len(d.ticks))
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !valid {
+		d.invalid++
+		return false
+	}
+	if d.started && tick <= d.maxTick-n {
+		d.tooOld++
+		return false
+	}
+	if !d.started || tick > d.maxTick {
+		d.maxTick, d.started = tick, true
+	}
+
+	t := &d.ticks[tick%n]
+	if t.words == nil || t.tick != tick {
+		*t = detectorTick{tick: tick, words: map[int64]uint64{}}
+	}
+	word, bit := rest/64, uint64(1)<<(rest%64)
+	if t.words[word]&bit != 0 {
+		return true
+	}
+	t.words[word] |= bit
+	t.count++
+	return false
+}
+
+// Forget drops every remembered ID from a tick that ended before before.
+func (d *DuplicateDetector) Forget(before time.Time) {
+	cutoff := d.layout.tick(before) - d.layout.CustomEpoch
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for i := range d.ticks {
+		if d.ticks[i].words != nil && d.ticks[i].tick < cutoff {
+			d.ticks[i] = detectorTick{}
+		}
+	}
+}
+
+// Stats returns the current size of the window.
+func (d *DuplicateDetector) Stats() DetectorStats {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	s := DetectorStats{TooOld: d.tooOld, Invalid: d.invalid}
+	for _, t := range d.ticks {
+		if t.count > 0 {
+			s.Ticks++
+			s.IDs += t.count
+		}
+	}
+	return s
+}
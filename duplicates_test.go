@@ -0,0 +1,83 @@
+package sanic
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// detectorID builds an ID of l for tick (relative to the epoch), worker and
+// sequence.
+func detectorID(l Layout, tick, worker, seq int64) int64 {
+	return tick<<l.timeStampShift() | worker<<(l.SequenceBits+l.TagBits) | seq
+}
+
+func TestDuplicateDetectorMillions(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	d := NewDuplicateDetector(l, 10*time.Millisecond)
+	const ticks, perTick = 1000, 1000
+	dups := 0
+	for tick := int64(1); tick <= ticks; tick++ {
+		for i := int64(0); i < perTick; i++ {
+			id := detectorID(l, tick, i%64, i/64)
+			if d.Seen(id) {
+				t.Fatalf("first sight of %d reported as a duplicate", id)
+			}
+			if i%100 == 0 {
+				// A repeat from a few ticks back, inside the horizon.
+				back := detectorID(l, max(tick-5, 1), i%64, i/64)
+				if !d.Seen(back) {
+					t.Fatalf("repeat of %d not detected", back)
+				}
+				dups++
+			}
+		}
+	}
+	s := d.Stats()
+	if s.Ticks != 10 || s.IDs != 10*perTick || s.TooOld != 0 {
+		t.Errorf("stats %+v after %d duplicates", s, dups)
+	}
+}
+
+func TestDuplicateDetectorTooOld(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	d := NewDuplicateDetector(l, 3*time.Millisecond)
+	old := detectorID(l, 10, 1, 1)
+	d.Seen(old)
+	d.Seen(detectorID(l, 13, 1, 1))
+	// Tick 10 has left the 3-tick window, so the repeat is a documented
+	// false negative.
+	if d.Seen(old) {
+		t.Error("repeat outside the horizon reported")
+	}
+	if s := d.Stats(); s.TooOld != 1 {
+		t.Errorf("TooOld %d, want 1", s.TooOld)
+	}
+}
+
+func TestDuplicateDetectorInvalid(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	d := NewDuplicateDetector(l, time.Millisecond)
+	for _, id := range []int64{-1, math.MinInt64, -detectorID(l, 5, 1, 1), 0} {
+		if d.Seen(id) || d.Seen(id) {
+			t.Errorf("invalid ID %d reported as seen", id)
+		}
+	}
+	if s := d.Stats(); s.Invalid != 8 || s.IDs != 0 {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestDuplicateDetectorVersionBit(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	d := NewDuplicateDetector(l, time.Millisecond)
+	v0 := detectorID(l, 5, 1, 1)
+	v1 := v0 | math.MinInt64
+	if d.Seen(v0) || d.Seen(v1) {
+		t.Error("versions of the same bits reported as duplicates")
+	}
+	if !d.Seen(v1) {
+		t.Error("repeated version 1 ID not detected")
+	}
+}
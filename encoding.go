@@ -2,10 +2,26 @@ package sanic
 
 import (
 	"bytes"
-	"encoding/base64"
 	"encoding/binary"
+	"fmt"
 )
 
+// alphabet holds the 64 characters of the string encoding in ASCII order,
+// so that fixed-width strings sort the same way as the IDs they encode.
+const alphabet = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+// decodeMap maps each byte to its 6-bit value, or 0xFF if it is not in the
+// alphabet.
+var decodeMap = func() (m [256]byte) {
+	for i := range m {
+		m[i] = 0xFF
+	}
+	for i := 0; i < len(alphabet); i++ {
+		m[alphabet[i]] = byte(i)
+	}
+	return m
+}()
+
 func IntToBytes(i int64) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := binary.Write(buf, binary.LittleEndian, i)
@@ -15,25 +31,113 @@ func IntToBytes(i int64) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// IntToString encodes the low totalBits bits of i, most significant first,
+// six bits per character. The result is always StringLength(totalBits)
+// characters long. It returns ErrOutOfRange if totalBits is not between 1
+// and 64 or if i does not fit in totalBits bits.
 func IntToString(i int64, totalBits uint64) (string, error) {
-	bts, err := IntToBytes(i)
-	if err != nil {
-		return "", err
+	if totalBits == 0 || totalBits > 64 {
+		return "", fmt.Errorf("%w: cannot encode %d bits",
+			ErrOutOfRange, totalBits)
+	}
+	u := uint64(i)
+	if totalBits < 64 && u>>totalBits != 0 {
+		return "", fmt.Errorf("%w: %d does not fit in %d bits",
+			ErrOutOfRange, i, totalBits)
+	}
+	buf := make([]byte, StringLength(totalBits))
+	encode(buf, u)
+	return string(buf), nil
+}
+
+// EncodeInt encodes any 64-bit value, not just an ID, in the string
+// encoding: bits bits, most significant first, six per character from the
+// alphabet
+//
+//	-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz
+//
+// which is in ASCII order, so strings of equal length sort as their values
+// do when read as unsigned. The result is always StringLength(bits)
+// characters long, padded with leading '-' (zero).
+//
+// v is encoded as its two's-complement bit pattern, so a negative v needs
+// all 64 bits. EncodeInt returns ErrOutOfRange if bits is not between 1 and
+// 64, or if v needs more than bits bits; it never truncates.
+func EncodeInt(v int64, bits uint64) (string, error) {
+	return IntToString(v, bits)
+}
+
+// DecodeInt is the inverse of EncodeInt. The width is taken from the length
+// of s: 6 bits per character, at most 64, so s may be 1 to 11 characters
+// long and an 11 character string must not use the top 2 of its 66 bits. A
+// 64-bit pattern with the top bit set decodes to a negative value. It
+// returns ErrMalformedID if s is empty, too long, does not fit in 64 bits,
+// or contains a byte outside the alphabet.
+func DecodeInt(s string) (int64, error) {
+	maxLen := StringLength(64)
+	if len(s) == 0 || len(s) > maxLen {
+		return 0, fmt.Errorf("%w: %q is %d bytes, want 1 to %d",
+			ErrMalformedID, s, len(s), maxLen)
 	}
-	bts = RemoveUnusedBytes(bts, totalBits)
-	str := RemoveSixTrailingZeroBits(base64.RawURLEncoding.EncodeToString(bts),
-		totalBits)
-	return str, nil
+	return StringToInt(s, min(6*uint64(len(s)), 64))
+}
+
+// encode writes the low 6*len(dst) bits of u into dst, most significant
+// first.
+func encode(dst []byte, u uint64) {
+	for k := len(dst) - 1; k >= 0; k-- {
+		dst[k] = alphabet[u&63]
+		u >>= 6
+	}
+}
+
+// StringToInt decodes a string produced by IntToString with the same
+// totalBits. It returns ErrOutOfRange if totalBits is not between 1 and 64,
+// and ErrMalformedID if s has the wrong length, contains a byte outside the
+// alphabet, or encodes more than totalBits bits. s is read byte by byte, so
+// any multi-byte UTF-8 character is reported as a byte outside the alphabet.
+func StringToInt(s string, totalBits uint64) (int64, error) {
+	if totalBits == 0 || totalBits > 64 {
+		return 0, fmt.Errorf("%w: cannot decode %d bits",
+			ErrOutOfRange, totalBits)
+	}
+	if n := StringLength(totalBits); len(s) != n {
+		return 0, fmt.Errorf("%w: %q is %d bytes, want %d",
+			ErrMalformedID, s, len(s), n)
+	}
+	var u uint64
+	for k := 0; k < len(s); k++ {
+		v := decodeMap[s[k]]
+		if v == 0xFF {
+			return 0, fmt.Errorf("%w: %q has invalid byte %#x at %d",
+				ErrMalformedID, s, s[k], k)
+		}
+		u = u<<6 | uint64(v)
+	}
+	if extra := uint64(len(s))*6 - totalBits; extra > 0 &&
+		decodeMap[s[0]]>>(6-extra) != 0 {
+		return 0, fmt.Errorf("%w: %q does not fit in %d bits",
+			ErrMalformedID, s, totalBits)
+	}
+	return int64(u), nil
+}
+
+// StringLength returns the number of characters IntToString produces for
+// totalBits bits.
+func StringLength(totalBits uint64) int {
+	return int((totalBits + 5) / 6)
 }
 
+// Deprecated: IntToString no longer uses RemoveUnusedBytes.
 func RemoveUnusedBytes(bts []byte, totalBits uint64) []byte {
 	bytesLen := totalBits / 8
 	if totalBits%8 != 0 {
 		bytesLen++
 	}
-	return bts[:bytesLen]
+	return bts[:min(bytesLen, uint64(len(bts)))]
 }
 
+// Deprecated: IntToString no longer uses RemoveSixTrailingZeroBits.
 func RemoveSixTrailingZeroBits(s string, totalBits uint64) string {
 	strLen := int(totalBits / 6)
 	if len(s) == strLen+1 {
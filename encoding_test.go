@@ -0,0 +1,326 @@
+package sanic
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func presetWorkers() []*Worker {
+	return []*Worker{NewWorker10(7), NewWorker9(2), NewWorker8(), NewWorker7()}
+}
+
+func TestIDStringOrder(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, w := range presetWorkers() {
+		ids := make([]int64, 5000)
+		for i := range ids {
+			ids[i] = r.Int64N(1<<(w.TotalBits-1)-1) + 1
+		}
+		ids = append(ids, 1, 1<<(w.TotalBits-1)-1)
+		strs := make([]string, len(ids))
+		for i, id := range ids {
+			strs[i] = w.IDString(id)
+		}
+		slices.Sort(ids)
+		slices.Sort(strs)
+		for i := range ids {
+			if got := w.IDString(ids[i]); got != strs[i] {
+				t.Fatalf("%v: string order differs from ID order at %d", w, i)
+			}
+		}
+	}
+}
+
+func TestIDStringRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4))
+	for _, w := range presetWorkers() {
+		for range 5000 {
+			id := r.Int64N(1<<(w.TotalBits-1)-1) + 1
+			s := w.IDString(id)
+			if len(s) != w.StringLength() {
+				t.Fatalf("%v: IDString(%d) = %q, want %d characters", w, id, s, w.StringLength())
+			}
+			got, err := w.ParseIDString(s)
+			if err != nil || got != id {
+				t.Fatalf("%v: ParseIDString(%q) = %d, %v, want %d", w, s, got, err, id)
+			}
+		}
+		id := w.NextID()
+		if got, err := w.ParseIDString(w.IDString(id)); err != nil || got != id {
+			t.Errorf("%v: generated ID %d round-trips to %d, %v", w, id, got, err)
+		}
+	}
+}
+
+func TestParseIDStringErrors(t *testing.T) {
+	w := NewWorker10(1)
+	var lerr *LengthError
+	for _, tc := range []struct {
+		in   string
+		want error
+	}{
+		{"", nil},
+		{"abc", nil},
+		{"----------", ErrInvalidID},      // zero
+		{"zzzzzzzzzz", ErrInvalidID},      // the reserved top bit set
+		{"------ü---", ErrMalformedID},    // multi-byte character
+		{"-----.----", ErrMalformedID},    // outside the alphabet
+		{"---------\x00", ErrMalformedID}, // control byte
+	} {
+		_, err := w.ParseIDString(tc.in)
+		switch {
+		case tc.want == nil && !errors.As(err, &lerr):
+			t.Errorf("ParseIDString(%q) = %v, want a LengthError", tc.in, err)
+		case tc.want != nil && !errors.Is(err, tc.want):
+			t.Errorf("ParseIDString(%q) = %v, want %v", tc.in, err, tc.want)
+		}
+	}
+}
+
+func TestEncodeInt(t *testing.T) {
+	for _, c := range []struct {
+		v    int64
+		bits uint64
+		want string
+	}{
+		{0, 6, "-"},
+		{63, 6, "z"},
+		{64, 12, "0-"},
+		{1, 60, "---------0"},
+		{-1, 64, "Ezzzzzzzzzz"},
+		{-1 << 63, 64, "7----------"},
+	} {
+		s, err := EncodeInt(c.v, c.bits)
+		if err != nil || s != c.want {
+			t.Errorf("EncodeInt(%d, %d) = %q, %v; want %q", c.v, c.bits, s, err, c.want)
+		}
+		if len(s) != StringLength(c.bits) {
+			t.Errorf("EncodeInt(%d, %d) has %d characters", c.v, c.bits, len(s))
+		}
+		if v, err := DecodeInt(s); err != nil || v != c.v {
+			t.Errorf("DecodeInt(%q) = %d, %v; want %d", s, v, err, c.v)
+		}
+	}
+	for _, c := range []struct {
+		v    int64
+		bits uint64
+	}{{1, 0}, {1, 65}, {64, 6}, {1 << 59, 59}, {-1, 63}} {
+		if s, err := EncodeInt(c.v, c.bits); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("EncodeInt(%d, %d) = %q, %v; want ErrOutOfRange", c.v, c.bits, s, err)
+		}
+	}
+}
+
+func TestDecodeInt(t *testing.T) {
+	// An 11 character string holds 66 bits, of which the top two must be
+	// clear.
+	for _, s := range []string{"", "---------0--", "F----------", "z----------", "-!"} {
+		if v, err := DecodeInt(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("DecodeInt(%q) = %d, %v; want ErrMalformedID", s, v, err)
+		}
+	}
+	if v, err := DecodeInt("zzzzzzzzzz"); err != nil || v != 1<<60-1 {
+		t.Errorf("DecodeInt of 10 z's = %d, %v", v, err)
+	}
+}
+
+func FuzzEncodeInt(f *testing.F) {
+	f.Add(int64(0), uint64(1))
+	f.Add(int64(-1), uint64(64))
+	f.Add(int64(1<<40), uint64(41))
+	f.Fuzz(func(t *testing.T, v int64, bits uint64) {
+		s, err := EncodeInt(v, bits)
+		fits := bits >= 1 && bits <= 64 && (bits == 64 || uint64(v)>>bits == 0)
+		if !fits {
+			if err == nil {
+				t.Fatalf("EncodeInt(%d, %d) = %q, want an error", v, bits, s)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("EncodeInt(%d, %d): %v", v, bits, err)
+		}
+		if back, err := DecodeInt(s); err != nil || back != v {
+			t.Fatalf("DecodeInt(EncodeInt(%d, %d) = %q) = %d, %v", v, bits, s, back, err)
+		}
+	})
+}
+
+// Every value of every width up to 16 bits round-trips, in order, and the
+// next value up is rejected both ways.
+func TestConformanceExhaustive(t *testing.T) {
+	for bits := uint64(1); bits <= 16; bits++ {
+		n := StringLength(bits)
+		var prev string
+		for v := int64(0); v < 1<<bits; v++ {
+			s, err := IntToString(v, bits)
+			if err != nil || len(s) != n {
+				t.Fatalf("IntToString(%d, %d) = %q, %v", v, bits, s, err)
+			}
+			if back, err := StringToInt(s, bits); err != nil || back != v {
+				t.Fatalf("StringToInt(%q, %d) = %d, %v, want %d", s, bits, back, err, v)
+			}
+			if s <= prev && v > 0 {
+				t.Fatalf("%d bits: %q sorts before %q", bits, s, prev)
+			}
+			prev = s
+		}
+		if _, err := IntToString(1<<bits, bits); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("IntToString(1<<%d, %d) = %v, want ErrOutOfRange", bits, bits, err)
+		}
+		if bits%6 != 0 {
+			over := make([]byte, n)
+			encode(over, 1<<bits)
+			if _, err := StringToInt(string(over), bits); !errors.Is(err, ErrMalformedID) {
+				t.Errorf("StringToInt(%q, %d) = %v, want ErrMalformedID", over, bits, err)
+			}
+		}
+	}
+}
+
+func TestConformanceRandom(t *testing.T) {
+	for _, bits := range []uint64{60, 64} {
+		for range 100_000 {
+			v := int64(rand.Uint64() >> (64 - bits))
+			s, err := IntToString(v, bits)
+			if err != nil {
+				t.Fatalf("IntToString(%d, %d): %v", v, bits, err)
+			}
+			if back, err := StringToInt(s, bits); err != nil || back != v {
+				t.Fatalf("StringToInt(%q, %d) = %d, %v, want %d", s, bits, back, err, v)
+			}
+		}
+	}
+	for _, v := range []int64{-1, math.MinInt64, math.MaxInt64} {
+		s, _ := IntToString(v, 64)
+		if back, err := StringToInt(s, 64); err != nil || back != v {
+			t.Errorf("StringToInt(%q, 64) = %d, %v, want %d", s, back, err, v)
+		}
+	}
+}
+
+// Each byte outside the alphabet is rejected at each position.
+func TestConformanceInvalidBytes(t *testing.T) {
+	valid, _ := IntToString(math.MaxInt64, 64)
+	for b := range 256 {
+		if strings.IndexByte(alphabet, byte(b)) >= 0 {
+			continue
+		}
+		for k := range len(valid) {
+			s := valid[:k] + string([]byte{byte(b)}) + valid[k+1:]
+			if v, err := StringToInt(s, 64); !errors.Is(err, ErrMalformedID) {
+				t.Fatalf("StringToInt(%q, 64) = %d, %v, want ErrMalformedID", s, v, err)
+			}
+		}
+		if v, err := DecodeInt(string([]byte{byte(b)})); !errors.Is(err, ErrMalformedID) {
+			t.Fatalf("DecodeInt(%#x) = %d, %v, want ErrMalformedID", b, v, err)
+		}
+	}
+}
+
+// Multi-byte UTF-8 once indexed past the end of the input. The length
+// checks count bytes, not runes.
+func TestConformanceUTF8(t *testing.T) {
+	w := NewWorker10(7)
+	n := w.StringLength()
+	for _, r := range []string{"é", "€", "😀", "\uFFFD"} {
+		for _, s := range []string{
+			r + strings.Repeat("-", n-len(r)), // n bytes
+			r + strings.Repeat("-", n-1),      // n runes
+			strings.Repeat(r, n),
+		} {
+			if v, err := StringToInt(s, w.TotalBits); !errors.Is(err, ErrMalformedID) {
+				t.Errorf("StringToInt(%q) = %d, %v, want ErrMalformedID", s, v, err)
+			}
+			if v, err := w.ParseIDString(s); !errors.Is(err, ErrMalformedID) {
+				t.Errorf("ParseIDString(%q) = %d, %v, want ErrMalformedID", s, v, err)
+			}
+			if v, err := DecodeInt(s); err == nil {
+				t.Errorf("DecodeInt(%q) = %d, want an error", s, v)
+			}
+		}
+	}
+}
+
+func TestConformanceBits(t *testing.T) {
+	for _, bits := range []uint64{0, 65, 128, math.MaxUint64} {
+		if s, err := IntToString(0, bits); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("IntToString(0, %d) = %q, %v, want ErrOutOfRange", bits, s, err)
+		}
+		if v, err := StringToInt("-", bits); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("StringToInt(\"-\", %d) = %d, %v, want ErrOutOfRange", bits, v, err)
+		}
+	}
+	if v, err := StringToInt("", 6); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("StringToInt(\"\", 6) = %d, %v", v, err)
+	}
+	if got := RemoveUnusedBytes([]byte{1, 2}, 64); len(got) != 2 {
+		t.Errorf("RemoveUnusedBytes of short input = %v", got)
+	}
+}
+
+// FuzzDecode checks that decoding never panics, and that whatever decodes
+// encodes back to the same string.
+func FuzzDecode(f *testing.F) {
+	f.Add("", uint64(0))
+	f.Add("-", uint64(6))
+	f.Add("zzzzzzzzzzz", uint64(64))
+	f.Add("Ezzzzzzzzzz", uint64(64))
+	f.Add("\xc3\xa9---------", uint64(59))
+	f.Fuzz(func(t *testing.T, s string, bits uint64) {
+		if v, err := StringToInt(s, bits); err == nil {
+			if back, err := IntToString(v, bits); err != nil || back != s {
+				t.Fatalf("IntToString(StringToInt(%q, %d) = %d) = %q, %v", s, bits, v, back, err)
+			}
+		}
+		if v, err := DecodeInt(s); err == nil {
+			if back, err := EncodeInt(v, min(6*uint64(len(s)), 64)); err != nil || back != s {
+				t.Fatalf("EncodeInt(DecodeInt(%q) = %d) = %q, %v", s, v, back, err)
+			}
+		}
+	})
+}
+
+// FuzzParseHex checks that ParseHex never panics, and that whatever parses
+// is printed back by IDHex.
+func FuzzParseHex(f *testing.F) {
+	f.Add("")
+	f.Add("0x")
+	f.Add("0x0000000000000001")
+	f.Add("00000fffffffffff")
+	f.Add("ffffffffffffffff")
+	f.Add("+000000000000001")
+	f.Fuzz(func(t *testing.T, s string) {
+		w := NewWorker10(7)
+		id, err := w.ParseHex(s)
+		if err != nil {
+			return
+		}
+		want := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+		if h := w.IDHex(id); h != want {
+			t.Fatalf("IDHex(ParseHex(%q) = %d) = %q", s, id, h)
+		}
+	})
+}
+
+func FuzzIntToString(f *testing.F) {
+	f.Add(int64(0), uint64(1))
+	f.Add(int64(math.MaxInt64), uint64(63))
+	f.Add(int64(math.MinInt64), uint64(64))
+	f.Fuzz(func(t *testing.T, v int64, bits uint64) {
+		s, err := IntToString(v, bits)
+		if err != nil {
+			return
+		}
+		if len(s) != StringLength(bits) {
+			t.Fatalf("IntToString(%d, %d) = %q, wrong length", v, bits, s)
+		}
+		if back, err := StringToInt(s, bits); err != nil || back != v {
+			t.Fatalf("StringToInt(IntToString(%d, %d) = %q) = %d, %v", v, bits, s, back, err)
+		}
+	})
+}
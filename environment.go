@@ -0,0 +1,102 @@
+package sanic
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithEpochOffset returns l with its epoch moved d earlier, rounded down to
+// a whole tick, so that IDs generated at any time are d's worth of ticks
+// higher than l's. A negative d moves the epoch later.
+func (l Layout) WithEpochOffset(d time.Duration) Layout {
+	l.CustomEpoch -= int64(d / l.Frequency)
+	return l
+}
+
+// Environments assigns named environments, such as "prod" and "test",
+// epochs offset from one base layout, so that their IDs fall in disjoint
+// numeric bands for as long as they are in use, and classifies IDs by band.
+type Environments struct {
+	layout      Layout
+	from, until time.Time
+	envs        []environment
+}
+
+type environment struct {
+	name   string
+	layout Layout
+	lo, hi int64 // the band, inclusive
+}
+
+// NewEnvironments returns an empty set of environments based on l, which
+// will generate IDs between from and until.
+func NewEnvironments(l Layout, from, until time.Time) *Environments {
+	return &Environments{layout: l, from: from, until: until}
+}
+
+// Add registers an environment whose layout is the base layout with its
+// epoch moved offset earlier, as by WithEpochOffset. Its band holds every
+// ID its layout can generate between from and until. Add returns
+// ErrInvalidLayout if the layout cannot generate IDs for that whole period,
+// and ErrBandOverlap if the band overlaps another environment's, or the
+// name is taken.
+func (e *Environments) Add(name string, offset time.Duration) error {
+	l := e.layout.WithEpochOffset(offset)
+	if e.from.Before(l.Epoch()) {
+		return fmt.Errorf("%w: environment %q starts at %s, before its epoch %s",
+			ErrInvalidLayout, name, e.from, l.Epoch())
+	}
+	if !e.until.Before(l.Exhausts()) {
+		return fmt.Errorf("%w: environment %q ends at %s, after its layout is exhausted at %s",
+			ErrInvalidLayout, name, e.until, l.Exhausts())
+	}
+	env := environment{
+		name:   name,
+		layout: l,
+		lo:     l.FirstID(e.from),
+		hi:     l.FirstID(e.until) + 1<<l.timeStampShift() - 1,
+	}
+	for _, other := range e.envs {
+		if other.name == name {
+			return fmt.Errorf("%w: environment %q already added", ErrBandOverlap, name)
+		}
+		if env.lo <= other.hi && other.lo <= env.hi {
+			return fmt.Errorf("%w: %q band %d-%d overlaps %q band %d-%d",
+				ErrBandOverlap, name, env.lo, env.hi, other.name, other.lo, other.hi)
+		}
+	}
+	e.envs = append(e.envs, env)
+	return nil
+}
+
+// Layout returns the layout registered for the environment name.
+func (e *Environments) Layout(name string) (Layout, bool) {
+	for _, env := range e.envs {
+		if env.name == name {
+			return env.layout, true
+		}
+	}
+	return Layout{}, false
+}
+
+// Band returns the inclusive range of IDs the environment name can
+// generate between from and until.
+func (e *Environments) Band(name string) (lo, hi int64, ok bool) {
+	for _, env := range e.envs {
+		if env.name == name {
+			return env.lo, env.hi, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Environment returns the name of the environment whose band holds id, or
+// "" if there is none.
+func (e *Environments) Environment(id int64) string {
+	for _, env := range e.envs {
+		if id >= env.lo && id <= env.hi {
+			return env.name
+		}
+	}
+	return ""
+}
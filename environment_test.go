@@ -0,0 +1,85 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const year = 365 * 24 * time.Hour
+
+func testEnvironments(t *testing.T) *Environments {
+	t.Helper()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := NewEnvironments(NewWorker10(0).Layout(), from, from.Add(10*year))
+	if err := e.Add("prod", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Add("test", 20*year); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEnvironmentBands(t *testing.T) {
+	e := testEnvironments(t)
+	plo, phi, _ := e.Band("prod")
+	tlo, thi, ok := e.Band("test")
+	if !ok || phi >= tlo || plo >= phi || tlo >= thi {
+		t.Fatalf("bands prod %d-%d, test %d-%d", plo, phi, tlo, thi)
+	}
+	for id, want := range map[int64]string{
+		plo - 1: "", plo: "prod", phi: "prod", phi + 1: "",
+		tlo - 1: "", tlo: "test", thi: "test", thi + 1: "",
+	} {
+		if got := e.Environment(id); got != want {
+			t.Errorf("Environment(%d) = %q, want %q", id, got, want)
+		}
+	}
+	if _, _, ok := e.Band("dev"); ok {
+		t.Error("Band of an unregistered environment")
+	}
+}
+
+func TestEnvironmentWorkers(t *testing.T) {
+	e := testEnvironments(t)
+	for _, name := range []string{"prod", "test"} {
+		l, ok := e.Layout(name)
+		if !ok {
+			t.Fatalf("no layout for %s", name)
+		}
+		w, err := New(WorkerConfig{ID: 3, Layout: l})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Environment(w.NextID()); got != name {
+			t.Errorf("%s worker's ID classified as %q", name, got)
+		}
+	}
+	prod, _ := e.Layout("prod")
+	test, _ := e.Layout("test")
+	if d := prod.Epoch().Sub(test.Epoch()); d != 20*year {
+		t.Errorf("test epoch %s before prod's, want 20 years", d)
+	}
+}
+
+func TestEnvironmentAddErrors(t *testing.T) {
+	e := testEnvironments(t)
+	for _, c := range []struct {
+		name   string
+		offset time.Duration
+		want   error
+	}{
+		{"staging", 5 * year, ErrBandOverlap},
+		{"test", 40 * year, ErrBandOverlap},
+		{"old", 60 * year, ErrInvalidLayout},    // exhausted before until
+		{"future", -9 * year, ErrInvalidLayout}, // epoch after from
+	} {
+		if err := e.Add(c.name, c.offset); !errors.Is(err, c.want) {
+			t.Errorf("Add(%q, %s) = %v, want %v", c.name, c.offset, err, c.want)
+		}
+	}
+	if _, ok := e.Layout("staging"); ok {
+		t.Error("a rejected environment was registered")
+	}
+}
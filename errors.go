@@ -0,0 +1,158 @@
+package sanic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrMalformedID is returned when an encoded ID is not in the expected
+	// format.
+	ErrMalformedID = errors.New("sanic: malformed id")
+	// ErrInvalidID is returned when an ID could not have been generated by a
+	// worker with the given layout.
+	ErrInvalidID = errors.New("sanic: invalid id for layout")
+)
+
+var (
+	// ErrClockBeforeEpoch is returned when the clock reads earlier than the
+	// layout's custom epoch.
+	ErrClockBeforeEpoch = errors.New("sanic: clock is before epoch")
+	// ErrClockBackwards is returned when the clock reads earlier than the
+	// last generated ID.
+	ErrClockBackwards = errors.New("sanic: clock moved backwards")
+	// ErrClockJumped is returned when the clock is further ahead of the last
+	// generated ID than the worker's MaxClockJump.
+	ErrClockJumped = errors.New("sanic: clock jumped forward")
+	// ErrEpochExhausted is returned when the clock is past the last time the
+	// layout's timestamp bits can represent.
+	ErrEpochExhausted = errors.New("sanic: epoch exhausted")
+)
+
+var (
+	// ErrInvalidTag is returned when a tag does not fit the layout's
+	// TagBits, including when the layout has no tag bits at all.
+	ErrInvalidTag = errors.New("sanic: invalid tag for layout")
+	// ErrUnknownTagPrefix is returned when a string does not start with any
+	// registered tag prefix.
+	ErrUnknownTagPrefix = errors.New("sanic: unknown tag prefix")
+)
+
+// ErrInvalidWorkerID is returned when a worker ID does not fit the layout's
+// IDBits.
+var ErrInvalidWorkerID = errors.New("sanic: invalid worker id for layout")
+
+// ErrWrongPrefix is returned when a prefixed ID string carries a prefix
+// other than the one expected, or one that is not registered.
+var ErrWrongPrefix = errors.New("sanic: wrong id prefix")
+
+// ErrUninitialized is returned when generating with a Worker that was not
+// made by NewWorker or one of the other constructors.
+var ErrUninitialized = errors.New("sanic: worker not made by a constructor")
+
+// ErrUnderProvisioned is returned by HealthCheck when the sequence is
+// exhausted too often for the worker's layout.
+var ErrUnderProvisioned = errors.New("sanic: sequence space under-provisioned")
+
+// LengthError is returned when an ID string has the wrong length for the
+// worker parsing it. It matches ErrMalformedID with errors.Is.
+type LengthError struct {
+	Expected int
+	Actual   int
+	// Matches names the registered presets whose ID strings have the
+	// actual length, as a hint to which layout the string came from.
+	Matches []string
+}
+
+func (e *LengthError) Error() string {
+	msg := fmt.Sprintf("sanic: id string is %d characters, want %d",
+		e.Actual, e.Expected)
+	if len(e.Matches) > 0 {
+		msg += fmt.Sprintf("; it looks like an id from %s",
+			strings.Join(e.Matches, " or "))
+	}
+	return msg
+}
+
+func (e *LengthError) Unwrap() error {
+	return ErrMalformedID
+}
+
+// ErrBadCursor is returned when a pagination cursor is truncated, tampered
+// with, or from an unknown version.
+var ErrBadCursor = errors.New("sanic: bad cursor")
+
+// ErrInvalidLayout is returned when a layout cannot be used to generate
+// IDs.
+var ErrInvalidLayout = errors.New("sanic: invalid layout")
+
+// ErrPaused is returned by generation that can fail while the worker is
+// paused under PauseError.
+var ErrPaused = errors.New("sanic: worker paused")
+
+// ErrOutOfOrder is returned by Migrator.Mint when ObjectIDs are not given
+// in ascending order.
+var ErrOutOfOrder = errors.New("sanic: input out of order")
+
+// ErrOutOfRange is returned when a value does not fit in the number of bits
+// it is to be encoded in.
+var ErrOutOfRange = errors.New("sanic: value out of range")
+
+// ErrIDMismatch is returned by FromProto when a SanicID's numeric and
+// string forms encode different IDs.
+var ErrIDMismatch = errors.New("sanic: id forms disagree")
+
+// ErrUnfillable is returned by Fill for values or fields it cannot fill.
+var ErrUnfillable = errors.New("sanic: cannot fill")
+
+// ErrBandOverlap is returned by Environments.Add when two environments
+// would generate IDs in overlapping ranges.
+var ErrBandOverlap = errors.New("sanic: environment bands overlap")
+
+// ErrNoTick is returned by a tick-driven worker under TickError when
+// generating would mean waiting for a tick to arrive.
+var ErrNoTick = errors.New("sanic: no tick available")
+
+// ErrUnknownLayoutCode is returned by DecodeTagged for a string whose first
+// character is not a registered layout code.
+var ErrUnknownLayoutCode = errors.New("sanic: unknown layout code")
+
+// ErrQuotaExceeded is returned by QuotaManager.NextID under QuotaError when
+// a tag has used up its quota for the current window.
+var ErrQuotaExceeded = errors.New("sanic: tag quota exceeded")
+
+// BindError is returned by BindID and IDVar. Err matches ErrMalformedID
+// when the input is not an ID in any form, which a handler should answer
+// with 400 Bad Request, and ErrInvalidID when it is well formed but no
+// worker with the layout could have generated it, which suits 404 Not
+// Found.
+type BindError struct {
+	Raw string
+	Err error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("sanic: cannot bind %q: %v", e.Raw, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// NotFound reports whether the input was well formed, but not an ID of the
+// layout.
+func (e *BindError) NotFound() bool {
+	return errors.Is(e.Err, ErrInvalidID)
+}
+
+// ErrNoRange is returned by AllocateRange when every worker ID in
+// RangeWorkerIDs is leased to an unexpired Range.
+var ErrNoRange = errors.New("sanic: no range worker id available")
+
+// ErrReservationClosed is returned by Reservation.Commit and Release once
+// the reservation has been committed or released.
+var ErrReservationClosed = errors.New("sanic: reservation already closed")
+
+// ErrClosed is returned by generation from a Worker after Close.
+var ErrClosed = errors.New("sanic: worker closed")
@@ -0,0 +1,83 @@
+package sanic
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// WaitUntilAfter blocks until the worker's clock has passed the tick of id,
+// typically the highest ID seen from another worker before failing over to
+// this one, and then fast-forwards the worker so every later ID it
+// generates is greater than id, whatever id's worker ID and sequence. It
+// sleeps rather than spins, and returns ctx's error if ctx is done first,
+// ErrInvalidID if id does not fit the worker's layout, and ErrUninitialized
+// for a Worker not made by a constructor.
+func (w *Worker) WaitUntilAfter(ctx context.Context, id int64) error {
+	tick, err := w.afterTick(id)
+	if err != nil {
+		return err
+	}
+	if w.ticks != nil {
+		if _, err := w.ticks.afterContext(ctx, tick); err != nil {
+			return err
+		}
+	} else {
+		for wait := w.untilAfter(tick); wait > 0; wait = w.untilAfter(tick) {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+	}
+	w.fastForward(tick, w.MaxSequence)
+	return nil
+}
+
+// EnsureAfter is WaitUntilAfter without the wait. It fast-forwards the
+// worker so that later IDs are greater than id, and returns at once. If the
+// clock has not yet passed id's tick, the next ID waits for it, and
+// EnsureAfter returns ErrClockBackwards instead if that wait could be
+// longer than MaxEnsureWait.
+func (w *Worker) EnsureAfter(id int64) error {
+	tick, err := w.afterTick(id)
+	if err != nil {
+		return err
+	}
+	if wait := w.untilAfter(tick); wait > w.MaxEnsureWait {
+		return fmt.Errorf("%w: clock is up to %s behind id %d, limit is %s",
+			ErrClockBackwards, wait, id, w.MaxEnsureWait)
+	}
+	w.fastForward(tick, w.MaxSequence)
+	return nil
+}
+
+// afterTick returns the tick WaitUntilAfter and EnsureAfter must pass.
+func (w *Worker) afterTick(id int64) (int64, error) {
+	if err := w.checkInitialized(); err != nil {
+		return 0, err
+	}
+	if err := w.Validate(id); err != nil {
+		return 0, err
+	}
+	return w.Decompose(id).Tick, nil
+}
+
+// untilAfter returns how long the clock may yet take to pass tick, to the
+// precision of the worker's Frequency, or zero if it has already. Before a
+// tick-driven worker's first tick it is unbounded.
+func (w *Worker) untilAfter(tick int64) time.Duration {
+	var now int64
+	if w.ticks == nil {
+		now = w.Time()
+	} else if t, ok := w.ticks.peek(); ok {
+		now = t
+	} else {
+		return math.MaxInt64
+	}
+	return max(time.Duration(tick+1-now)*w.Frequency, 0)
+}
@@ -0,0 +1,129 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// The highest ID another worker could have issued in tick.
+func failoverID(tick int64) int64 {
+	l := NewWorker10(0).Layout()
+	other := NewWorker10(l.MaxWorkerID())
+	return other.compose(tick, 0, l.MaxSequence())
+}
+
+func TestWaitUntilAfter(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}, tick)
+	other := failoverID(tick + 2)
+
+	done := make(chan error, 1)
+	go func() { done <- w.WaitUntilAfter(context.Background(), other) }()
+	clock.set(tick + 2)
+	select {
+	case err := <-done:
+		t.Fatalf("returned %v in the ID's own tick", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.set(tick + 3)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if id := w.NextID(); id <= other {
+		t.Errorf("issued %d after waiting for %d", id, other)
+	}
+}
+
+// An ID from the past needs no wait but still fast-forwards the worker
+// past a higher worker ID in the current tick.
+func TestWaitUntilAfterPassed(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}, tick)
+	other := failoverID(tick - 5)
+	if err := w.WaitUntilAfter(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	if id := w.NextID(); id <= other || w.Decompose(id).Tick != tick {
+		t.Errorf("issued %+v after %+v", w.Decompose(id), w.Decompose(other))
+	}
+
+	other = failoverID(tick)
+	clock.set(tick + 1)
+	if err := w.EnsureAfter(other); err != nil {
+		t.Fatal(err)
+	}
+	if id := w.NextID(); id <= other {
+		t.Errorf("issued %d after EnsureAfter(%d)", id, other)
+	}
+}
+
+func TestWaitUntilAfterContext(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, _ := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}, tick)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.WaitUntilAfter(ctx, failoverID(tick+1000)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitUntilAfter = %v, want the context's error", err)
+	}
+}
+
+func TestWaitUntilAfterTickDriven(t *testing.T) {
+	ticks := make(chan int64, 1)
+	w := tickWorker(t, ticks)
+	tick := NewWorker10(0).CustomEpoch + 1_000_000
+	other := failoverID(tick)
+	if wait := w.untilAfter(tick); wait <= 0 {
+		t.Errorf("untilAfter before the first tick = %s", wait)
+	}
+	done := make(chan error, 1)
+	go func() { done <- w.WaitUntilAfter(context.Background(), other) }()
+	ticks <- tick + 1
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if id := w.NextID(); id <= other {
+		t.Errorf("issued %d after waiting for %d", id, other)
+	}
+}
+
+func TestEnsureAfter(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}, tick)
+	other := failoverID(tick + 3)
+	if err := w.EnsureAfter(other); !errors.Is(err, ErrClockBackwards) {
+		t.Errorf("EnsureAfter with no MaxEnsureWait = %v, want ErrClockBackwards", err)
+	}
+
+	w.MaxEnsureWait = 4 * w.Frequency
+	if err := w.EnsureAfter(other); err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan int64, 1)
+	go func() { got <- w.NextID() }()
+	clock.spinning()
+	clock.set(tick + 4)
+	if id := <-got; id <= other {
+		t.Errorf("issued %d after EnsureAfter(%d)", id, other)
+	}
+}
+
+func TestFailoverErrors(t *testing.T) {
+	w := NewWorker10(1)
+	for _, id := range []int64{0, -1, 1 << 62} {
+		if err := w.WaitUntilAfter(context.Background(), id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("WaitUntilAfter(%d) = %v, want ErrInvalidID", id, err)
+		}
+		if err := w.EnsureAfter(id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("EnsureAfter(%d) = %v, want ErrInvalidID", id, err)
+		}
+	}
+	id := w.NextID()
+	if err := (&Worker{}).WaitUntilAfter(context.Background(), id); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("WaitUntilAfter on a zero Worker = %v", err)
+	}
+	if err := (&Worker{}).EnsureAfter(id); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("EnsureAfter on a zero Worker = %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package sanic
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Fill assigns new IDs to the zero-valued fields tagged `sanic:"id"` in the
+// struct v points to: NextID for int64 fields and its IDString for string
+// fields, including fields of any other kind-int64 or kind-string type.
+// Fields already set are left alone. Fill looks through nested structs,
+// pointers to them, and slices and arrays of either, but not through maps
+// or interfaces, and skips unexported fields. v must not hold a cycle of
+// pointers.
+//
+// A type is examined with reflection once, on first use, and the fields to
+// visit are cached, so later calls only walk the values. Fill returns
+// ErrUnfillable if v is not a non-nil pointer to a struct, or if a tagged
+// field is not of int64 or string kind or has a tag other than "id".
+func Fill(w *Worker, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() ||
+		rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a non-nil pointer to a struct",
+			ErrUnfillable, v)
+	}
+	p, err := planFor(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	p.fill(w, rv.Elem())
+	return nil
+}
+
+// fillPlan lists what Fill does for one struct type.
+type fillPlan struct {
+	steps []fillStep
+	done  bool // false while the steps are being built
+}
+
+type fillStep struct {
+	index int
+	kind  reflect.Kind // Int64 or String for tagged fields
+	elem  *fillPlan    // for fields holding structs to fill
+}
+
+var fillPlans struct {
+	sync.RWMutex
+	plans map[reflect.Type]*fillPlan
+	errs  map[reflect.Type]error
+}
+
+func planFor(t reflect.Type) (*fillPlan, error) {
+	fillPlans.RLock()
+	p, ok := fillPlans.plans[t]
+	err := fillPlans.errs[t]
+	fillPlans.RUnlock()
+	if ok {
+		return p, err
+	}
+
+	fillPlans.Lock()
+	defer fillPlans.Unlock()
+	if fillPlans.plans == nil {
+		fillPlans.plans = map[reflect.Type]*fillPlan{}
+		fillPlans.errs = map[reflect.Type]error{}
+	}
+	return buildPlan(t)
+}
+
+// buildPlan returns the plan for struct type t, building and caching it if
+// need be. fillPlans must be locked. A plan is cached before its steps are
+// built, so recursive types refer to themselves.
+func buildPlan(t reflect.Type) (*fillPlan, error) {
+	if p, ok := fillPlans.plans[t]; ok {
+		return p, fillPlans.errs[t]
+	}
+	p := &fillPlan{}
+	fillPlans.plans[t] = p
+	err := p.build(t)
+	p.done = true
+	fillPlans.errs[t] = err
+	return p, err
+}
+
+func (p *fillPlan) build(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("sanic"); ok {
+			if tag != "id" {
+				return fmt.Errorf("%w: %s.%s has tag %q, want \"id\"",
+					ErrUnfillable, t, f.Name, tag)
+			}
+			switch k := f.Type.Kind(); k {
+			case reflect.Int64, reflect.String:
+				p.steps = append(p.steps, fillStep{index: i, kind: k})
+			default:
+				return fmt.Errorf("%w: %s.%s is %s, want int64 or string",
+					ErrUnfillable, t, f.Name, f.Type)
+			}
+			continue
+		}
+
+		st := f.Type
+		for st.Kind() == reflect.Pointer || st.Kind() == reflect.Slice ||
+			st.Kind() == reflect.Array {
+			st = st.Elem()
+		}
+		if st.Kind() != reflect.Struct {
+			continue
+		}
+		elem, err := buildPlan(st)
+		if err != nil {
+			return err
+		}
+		if elem.done && len(elem.steps) == 0 {
+			continue // nothing to fill, such as time.Time
+		}
+		p.steps = append(p.steps, fillStep{index: i, elem: elem})
+	}
+	return nil
+}
+
+func (p *fillPlan) fill(w *Worker, v reflect.Value) {
+	for _, s := range p.steps {
+		f := v.Field(s.index)
+		switch {
+		case s.elem != nil:
+			s.elem.fillValue(w, f)
+		case !f.IsZero():
+		case s.kind == reflect.Int64:
+			f.SetInt(w.NextID())
+		default:
+			f.SetString(w.IDString(w.NextID()))
+		}
+	}
+}
+
+// fillValue fills the structs held in v, through any pointers, slices and
+// arrays.
+func (p *fillPlan) fillValue(w *Worker, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			p.fillValue(w, v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			p.fillValue(w, v.Index(i))
+		}
+	case reflect.Struct:
+		p.fill(w, v)
+	}
+}
@@ -0,0 +1,109 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fillID int64
+
+type fillOrder struct {
+	ID  int64 `sanic:"id"`
+	Ref string
+}
+
+type fillUser struct {
+	ID      int64  `sanic:"id"`
+	Key     string `sanic:"id"`
+	Custom  fillID `sanic:"id"`
+	Set     int64  `sanic:"id"`
+	Name    string
+	Created time.Time
+	Orders  []fillOrder
+	Primary *fillOrder
+	Pair    [2]fillOrder
+	Ptrs    []*fillOrder
+	ByName  map[string]*fillOrder // not looked through
+	hidden  fillOrder
+}
+
+type fillNode struct {
+	ID   int64 `sanic:"id"`
+	Next *fillNode
+	Kids []fillNode
+}
+
+func TestFill(t *testing.T) {
+	w := NewWorker10(4)
+	u := fillUser{
+		Set:     42,
+		Orders:  make([]fillOrder, 3),
+		Primary: &fillOrder{},
+		Ptrs:    []*fillOrder{{}, nil, {ID: 7}},
+		ByName:  map[string]*fillOrder{"a": {}},
+	}
+	if err := Fill(w, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID == 0 || u.Custom == 0 || u.Set != 42 || u.Name != "" {
+		t.Errorf("top-level fields %+v", u)
+	}
+	if _, err := w.ParseIDString(u.Key); err != nil {
+		t.Errorf("string field %q: %v", u.Key, err)
+	}
+	ids := []int64{u.ID, int64(u.Custom), u.Primary.ID, u.Pair[0].ID, u.Pair[1].ID, u.Ptrs[0].ID}
+	for _, o := range u.Orders {
+		ids = append(ids, o.ID)
+	}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			t.Errorf("nested ID %d is zero or repeated", id)
+		}
+		seen[id] = true
+	}
+	if u.Ptrs[2].ID != 7 || u.ByName["a"].ID != 0 || u.hidden.ID != 0 {
+		t.Error("Fill changed a set, map-held or unexported field")
+	}
+
+	// A second Fill leaves everything alone.
+	before := u.ID
+	if err := Fill(w, &u); err != nil || u.ID != before {
+		t.Errorf("refill changed ID %d to %d: %v", before, u.ID, err)
+	}
+}
+
+func TestFillRecursive(t *testing.T) {
+	n := fillNode{Next: &fillNode{Next: &fillNode{}}, Kids: make([]fillNode, 2)}
+	if err := Fill(NewWorker10(4), &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.ID == 0 || n.Next.ID == 0 || n.Next.Next.ID == 0 || n.Kids[1].ID == 0 || n.Next.Next.Next != nil {
+		t.Errorf("recursive fill %+v", n)
+	}
+}
+
+func TestFillErrors(t *testing.T) {
+	w := NewWorker10(4)
+	var nilUser *fillUser
+	var i int64
+	type badTag struct {
+		ID int64 `sanic:"key"`
+	}
+	type badKind struct {
+		ID int32 `sanic:"id"`
+	}
+	type nestedBad struct {
+		Inner []badKind
+	}
+	for _, v := range []any{fillUser{}, nilUser, &i, nil, &badTag{}, &badKind{}, &nestedBad{}} {
+		if err := Fill(w, v); !errors.Is(err, ErrUnfillable) {
+			t.Errorf("Fill(%T) = %v, want ErrUnfillable", v, err)
+		}
+	}
+	// The error is cached with the plan.
+	if err := Fill(w, &badKind{}); !errors.Is(err, ErrUnfillable) {
+		t.Errorf("second Fill(*badKind) = %v", err)
+	}
+}
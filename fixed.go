@@ -0,0 +1,130 @@
+package sanic
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// EncodeFixed writes id's string form into dst without allocating. It
+// returns an error unless len(dst) == w.StringLength() and id fits the
+// worker's layout.
+func (w *Worker) EncodeFixed(dst []byte, id int64) error {
+	if len(dst) != w.StringLength() {
+		return fmt.Errorf("sanic: EncodeFixed needs %d bytes, got %d",
+			w.StringLength(), len(dst))
+	}
+	if err := w.Validate(id); err != nil {
+		return err
+	}
+	encode(dst, uint64(w.Layout().stringBits(id)))
+	return nil
+}
+
+// TenString, NineString, EightString and SevenString hold the string forms
+// of IDs from NewWorker10, NewWorker9, NewWorker8 and NewWorker7 in fixed
+// arrays, for fixed-width storage. Arrays compare and sort in the same
+// order as the IDs they hold.
+type (
+	TenString   [worker10StringLength]byte
+	NineString  [worker9StringLength]byte
+	EightString [worker8StringLength]byte
+	SevenString [worker7StringLength]byte
+)
+
+// fixedFrom validates id against a preset's total bits and encodes it.
+func fixedFrom(dst []byte, id int64, totalBits uint64) error {
+	if id <= 0 || id>>(totalBits-1) != 0 {
+		return ErrInvalidID
+	}
+	encode(dst, uint64(id))
+	return nil
+}
+
+// fixedID decodes a fixed array, which may have been filled from untrusted
+// bytes.
+func fixedID(src []byte, totalBits uint64) (int64, error) {
+	id, err := StringToInt(string(src), totalBits)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 || id>>(totalBits-1) != 0 {
+		return 0, ErrInvalidID
+	}
+	return id, nil
+}
+
+// From sets s to the string form of id.
+func (s *TenString) From(id int64) error {
+	return fixedFrom(s[:], id, worker10TotalBits)
+}
+
+// ID returns the ID s holds.
+func (s TenString) ID() (int64, error) {
+	return fixedID(s[:], worker10TotalBits)
+}
+
+// Compare returns -1, 0 or 1 as s sorts before, with or after other.
+func (s TenString) Compare(other TenString) int {
+	return bytes.Compare(s[:], other[:])
+}
+
+func (s TenString) String() string {
+	return string(s[:])
+}
+
+// From sets s to the string form of id.
+func (s *NineString) From(id int64) error {
+	return fixedFrom(s[:], id, worker9TotalBits)
+}
+
+// ID returns the ID s holds.
+func (s NineString) ID() (int64, error) {
+	return fixedID(s[:], worker9TotalBits)
+}
+
+// Compare returns -1, 0 or 1 as s sorts before, with or after other.
+func (s NineString) Compare(other NineString) int {
+	return bytes.Compare(s[:], other[:])
+}
+
+func (s NineString) String() string {
+	return string(s[:])
+}
+
+// From sets s to the string form of id.
+func (s *EightString) From(id int64) error {
+	return fixedFrom(s[:], id, worker8TotalBits)
+}
+
+// ID returns the ID s holds.
+func (s EightString) ID() (int64, error) {
+	return fixedID(s[:], worker8TotalBits)
+}
+
+// Compare returns -1, 0 or 1 as s sorts before, with or after other.
+func (s EightString) Compare(other EightString) int {
+	return bytes.Compare(s[:], other[:])
+}
+
+func (s EightString) String() string {
+	return string(s[:])
+}
+
+// From sets s to the string form of id.
+func (s *SevenString) From(id int64) error {
+	return fixedFrom(s[:], id, worker7TotalBits)
+}
+
+// ID returns the ID s holds.
+func (s SevenString) ID() (int64, error) {
+	return fixedID(s[:], worker7TotalBits)
+}
+
+// Compare returns -1, 0 or 1 as s sorts before, with or after other.
+func (s SevenString) Compare(other SevenString) int {
+	return bytes.Compare(s[:], other[:])
+}
+
+func (s SevenString) String() string {
+	return string(s[:])
+}
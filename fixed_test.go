@@ -0,0 +1,97 @@
+package sanic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEncodeFixed(t *testing.T) {
+	for _, w := range presetWorkers() {
+		id := w.NextID()
+		for _, n := range []int{0, w.StringLength() - 1, w.StringLength() + 1} {
+			if err := w.EncodeFixed(make([]byte, n), id); err == nil {
+				t.Errorf("%s: EncodeFixed accepted %d bytes", w, n)
+			}
+		}
+		dst := make([]byte, w.StringLength())
+		if err := w.EncodeFixed(dst, -1); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("%s: EncodeFixed(-1) = %v", w, err)
+		}
+		if err := w.EncodeFixed(dst, id); err != nil || string(dst) != w.IDString(id) {
+			t.Errorf("%s: EncodeFixed wrote %q, %v; want %q", w, dst, err, w.IDString(id))
+		}
+		if n := testing.AllocsPerRun(1000, func() { w.EncodeFixed(dst, id) }); n != 0 {
+			t.Errorf("%s: EncodeFixed allocates %v times", w, n)
+		}
+	}
+}
+
+func TestTenString(t *testing.T) {
+	w := NewWorker10(7)
+	ids := make([]int64, 2000)
+	w.NextIDs(ids)
+	fixed := make([]TenString, len(ids))
+	for i, id := range ids {
+		if err := fixed[i].From(id); err != nil {
+			t.Fatal(err)
+		}
+		if fixed[i].String() != w.IDString(id) {
+			t.Fatalf("TenString %q, IDString %q", fixed[i], w.IDString(id))
+		}
+		if back, err := fixed[i].ID(); err != nil || back != id {
+			t.Fatalf("ID() = %d, %v; want %d", back, err, id)
+		}
+	}
+	slices.Reverse(fixed)
+	slices.SortFunc(fixed, TenString.Compare)
+	for i := range fixed {
+		if back, _ := fixed[i].ID(); back != ids[i] {
+			t.Fatalf("sorted array %d holds %d, want %d", i, back, ids[i])
+		}
+	}
+
+	var s TenString
+	if err := s.From(0); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("From(0) = %v", err)
+	}
+	if n := testing.AllocsPerRun(1000, func() { s.From(ids[0]) }); n != 0 {
+		t.Errorf("From allocates %v times", n)
+	}
+	copy(s[:], "!!!!!!!!!!")
+	if _, err := s.ID(); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("ID() of garbage = %v", err)
+	}
+	var zero TenString
+	if _, err := zero.ID(); err == nil {
+		t.Error("ID() of the zero array succeeded")
+	}
+}
+
+func TestFixedStrings(t *testing.T) {
+	w9, w8, w7 := NewWorker9(2), NewWorker8(), NewWorker7()
+	var s9 NineString
+	var s8 EightString
+	var s7 SevenString
+	for _, c := range []struct {
+		w    *Worker
+		from func(int64) error
+		id   func() (int64, error)
+		str  func() string
+	}{
+		{w9, s9.From, func() (int64, error) { return s9.ID() }, func() string { return s9.String() }},
+		{w8, s8.From, func() (int64, error) { return s8.ID() }, func() string { return s8.String() }},
+		{w7, s7.From, func() (int64, error) { return s7.ID() }, func() string { return s7.String() }},
+	} {
+		id := c.w.NextID()
+		if err := c.from(id); err != nil {
+			t.Fatalf("%s: %v", c.w, err)
+		}
+		if back, err := c.id(); err != nil || back != id || c.str() != c.w.IDString(id) {
+			t.Errorf("%s: %q holds %d, %v; want %d", c.w, c.str(), back, err, id)
+		}
+		if err := c.from(1 << (c.w.TotalBits - 1)); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("%s: From accepted an ID too wide for the layout: %v", c.w, err)
+		}
+	}
+}
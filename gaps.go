@@ -0,0 +1,111 @@
+package sanic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"iter"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Report counts the IDs in each tick of a time window, so that ticks in
+// which no ID could have been generated can be proven empty.
+type Report struct {
+	From, To time.Time
+	Interval time.Duration   // the worker's Frequency
+	Counts   []IntervalCount // one per tick overlapping [From, To), in order
+}
+
+// IntervalCount is the number of IDs seen in the tick starting at Start.
+type IntervalCount struct {
+	Start time.Time `json:"start"`
+	Count int64     `json:"count"`
+}
+
+// Span is a run of consecutive ticks covering [From, To).
+type Span struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GapReport counts ids by tick over the window [from, to). IDs outside the
+// window are ignored. The report has an entry for every tick overlapping the
+// window, so its size grows with (to - from) / Frequency.
+func (w *Worker) GapReport(ids []int64, from, to time.Time) Report {
+	return w.GapReportSeq(slices.Values(ids), from, to)
+}
+
+// GapReportSeq is GapReport for a stream of IDs, which need not fit in
+// memory or be in order.
+func (w *Worker) GapReportSeq(ids iter.Seq[int64], from, to time.Time) Report {
+	l := w.Layout()
+	r := Report{From: from, To: to, Interval: l.Frequency}
+	if !to.After(from) {
+		return r
+	}
+	first, last := l.tick(from), l.tick(to.Add(-1))
+	r.Counts = make([]IntervalCount, last-first+1)
+	for i := range r.Counts {
+		r.Counts[i].Start = l.tickTime(first + int64(i))
+	}
+	for id := range ids {
+		if t := l.Decompose(id).Tick; t >= first && t <= last {
+			r.Counts[t-first].Count++
+		}
+	}
+	return r
+}
+
+// Gaps returns the runs of ticks in which no IDs were seen.
+func (r Report) Gaps() []Span {
+	return r.spans(false)
+}
+
+// Present returns the runs of ticks in which at least one ID was seen.
+func (r Report) Present() []Span {
+	return r.spans(true)
+}
+
+func (r Report) spans(present bool) []Span {
+	var spans []Span
+	for i, c := range r.Counts {
+		if (c.Count > 0) != present {
+			continue
+		}
+		end := c.Start.Add(r.Interval)
+		if n := len(spans); n > 0 && i > 0 &&
+			(r.Counts[i-1].Count > 0) == present {
+			spans[n-1].To = end
+			continue
+		}
+		spans = append(spans, Span{c.Start, end})
+	}
+	return spans
+}
+
+// WriteCSV writes one start,count row per tick, with a header row.
+func (r Report) WriteCSV(out io.Writer) error {
+	cw := csv.NewWriter(out)
+	cw.Write([]string{"start", "count"})
+	for _, c := range r.Counts {
+		cw.Write([]string{
+			c.Start.Format(time.RFC3339Nano),
+			strconv.FormatInt(c.Count, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the report, including its gaps, as a JSON object.
+func (r Report) WriteJSON(out io.Writer) error {
+	return json.NewEncoder(out).Encode(struct {
+		From     time.Time       `json:"from"`
+		To       time.Time       `json:"to"`
+		Interval string          `json:"interval"`
+		Gaps     []Span          `json:"gaps"`
+		Counts   []IntervalCount `json:"counts"`
+	}{r.From, r.To, r.Interval.String(), r.Gaps(), r.Counts})
+}
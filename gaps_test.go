@@ -0,0 +1,104 @@
+package sanic
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gapData returns IDs from a 1s layout with counts 5, 1, 0, 0, 0, 3 in the
+// ticks from start, plus one ID before and one after the window.
+func gapData(w *Worker, start time.Time) []int64 {
+	tick := w.Layout().tick(start)
+	var ids []int64
+	for i, n := range []int64{5, 1, 0, 0, 0, 3} {
+		for seq := range n {
+			ids = append(ids, w.compose(tick+int64(i), 0, seq))
+		}
+	}
+	ids = append(ids, w.compose(tick-1, 0, 0), w.compose(tick+10, 0, 0))
+	slices.Reverse(ids) // order does not matter
+	return ids
+}
+
+func TestGapReport(t *testing.T) {
+	w := NewWorker7()
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	r := w.GapReport(gapData(w, start), start, at(10))
+
+	var counts []int64
+	for i, c := range r.Counts {
+		if !c.Start.Equal(at(i)) {
+			t.Errorf("interval %d starts at %s", i, c.Start)
+		}
+		counts = append(counts, c.Count)
+	}
+	if want := []int64{5, 1, 0, 0, 0, 3, 0, 0, 0, 0}; !slices.Equal(counts, want) {
+		t.Errorf("counts %v, want %v", counts, want)
+	}
+	if got, want := r.Gaps(), []Span{{at(2), at(5)}, {at(6), at(10)}}; !slices.Equal(got, want) {
+		t.Errorf("gaps %v, want %v", got, want)
+	}
+	if got, want := r.Present(), []Span{{at(0), at(2)}, {at(5), at(6)}}; !slices.Equal(got, want) {
+		t.Errorf("present %v, want %v", got, want)
+	}
+
+	seq := w.GapReportSeq(slices.Values(gapData(w, start)), start, at(10))
+	if !slices.Equal(seq.Counts, r.Counts) {
+		t.Error("GapReportSeq disagrees with GapReport")
+	}
+}
+
+func TestGapReportWindow(t *testing.T) {
+	w := NewWorker7()
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	ids := gapData(w, start)
+
+	// A window that is not tick-aligned covers every tick it overlaps.
+	r := w.GapReport(ids, start.Add(500*time.Millisecond), start.Add(2500*time.Millisecond))
+	if len(r.Counts) != 3 || r.Counts[0].Count != 5 || r.Counts[2].Count != 0 {
+		t.Errorf("unaligned window counts %+v", r.Counts)
+	}
+	if r := w.GapReport(ids, start, start); len(r.Counts) != 0 || r.Gaps() != nil {
+		t.Errorf("empty window has %d intervals", len(r.Counts))
+	}
+}
+
+func TestGapReportOutput(t *testing.T) {
+	w := NewWorker7()
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	r := w.GapReport(gapData(w, start), start, start.Add(3*time.Second))
+
+	var csv bytes.Buffer
+	if err := r.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	want := "start,count\n" +
+		"2024-03-01T02:00:00Z,5\n" +
+		"2024-03-01T02:00:01Z,1\n" +
+		"2024-03-01T02:00:02Z,0\n"
+	if csv.String() != want {
+		t.Errorf("CSV\n%s\nwant\n%s", csv.String(), want)
+	}
+
+	var out bytes.Buffer
+	if err := r.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Interval string
+		Gaps     []Span
+		Counts   []IntervalCount
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Interval != "1s" || len(got.Counts) != 3 || len(got.Gaps) != 1 ||
+		!got.Gaps[0].From.Equal(start.Add(2*time.Second)) {
+		t.Errorf("JSON %s", strings.TrimSpace(out.String()))
+	}
+}
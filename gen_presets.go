@@ -0,0 +1,170 @@
+//go:build ignore
+
+// gen_presets generates presets.go from the layout table below. Each preset
+// is built once here with NewWorker, and the derived values it computes are
+// written out as constants, so the generated constructors do no layout math
+// or validation at runtime.
+//
+// To add a preset, add a row to presets and run go generate.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"log"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+var epoch = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var presets = []struct {
+	Name                           string
+	IDBits, SequenceBits, TimeBits uint64
+	Frequency                      time.Duration
+}{
+	{"10", 6, 12, 41, time.Millisecond},
+	{"9", 2, 13, 38, 10 * time.Millisecond},
+	{"8", 0, 13, 34, 100 * time.Millisecond},
+	{"7", 0, 10, 31, time.Second},
+}
+
+type preset struct {
+	Name                                string
+	HasID                               bool
+	IDBits, SequenceBits, TimeStampBits uint64
+	IDShift, TagShift, TimeStampShift   uint64
+	TotalBits                           uint64
+	MaxID, MaxSequence, MaxTimeStamp    int64
+	StringLength                        uint64
+	Frequency                           int64
+	FrequencyName                       string
+	CustomEpoch                         int64
+	Exhausts                            string
+	PerSecond                           int64
+	Years                               int64
+}
+
+func main() {
+	var out []preset
+	for _, p := range presets {
+		ticks := epoch.UnixNano() / int64(p.Frequency)
+		w := sanic.NewWorker(0, ticks, p.IDBits, p.SequenceBits, p.TimeBits,
+			p.Frequency)
+		lifetime := w.Exhausts().Sub(w.Epoch())
+		out = append(out, preset{
+			Name:           p.Name,
+			HasID:          p.IDBits > 0,
+			IDBits:         w.IDBits,
+			SequenceBits:   w.SequenceBits,
+			TimeStampBits:  w.TimeStampBits,
+			IDShift:        w.IDShift,
+			TagShift:       w.TagShift,
+			TimeStampShift: w.TimeStampShift,
+			TotalBits:      w.TotalBits,
+			MaxID:          w.MaxWorkerID,
+			MaxSequence:    w.MaxSequence,
+			MaxTimeStamp:   w.MaxTimeStamp,
+			StringLength:   w.TotalBits / 6,
+			Frequency:      int64(w.Frequency),
+			FrequencyName:  w.Frequency.String(),
+			CustomEpoch:    w.CustomEpoch,
+			Exhausts:       w.Exhausts().Format(time.RFC3339Nano),
+			PerSecond:      int64(time.Second/w.Frequency) << w.SequenceBits,
+			Years:          int64(lifetime.Hours() / 24 / 365.25),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, out); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("presets.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var tmpl = template.Must(template.New("presets").Parse(`// Code generated by gen_presets.go; DO NOT EDIT.
+
+package sanic
+
+// Easy Worker generation given an ID and using a default configuration
+// with a custom epoch of "2016-01-01 00:00:00 +0000 UTC"
+{{range .}}
+// Derived constants for NewWorker{{.Name}}.
+const (
+	worker{{.Name}}IDBits         = {{.IDBits}}
+	worker{{.Name}}SequenceBits   = {{.SequenceBits}}
+	worker{{.Name}}TimeStampBits  = {{.TimeStampBits}}
+	worker{{.Name}}IDShift        = {{.IDShift}}
+	worker{{.Name}}TagShift       = {{.TagShift}}
+	worker{{.Name}}TimeStampShift = {{.TimeStampShift}}
+	worker{{.Name}}TotalBits      = {{.TotalBits}}
+	worker{{.Name}}MaxID          = {{.MaxID}}
+	worker{{.Name}}MaxSequence    = {{.MaxSequence}}
+	worker{{.Name}}MaxTimeStamp   = {{.MaxTimeStamp}}
+	worker{{.Name}}StringLength   = {{.StringLength}}
+	worker{{.Name}}Frequency      = {{.Frequency}} // {{.FrequencyName}}
+	worker{{.Name}}CustomEpoch    = {{.CustomEpoch}}
+	worker{{.Name}}Exhausts       = "{{.Exhausts}}"
+)
+{{if .HasID}}
+// NewWorker{{.Name}} will generate up to {{.PerSecond}} unique ids/second for {{.Years}} years
+// NewWorker{{.Name}} will return nil if the ID is greater than {{.MaxID}} or less than 0
+func NewWorker{{.Name}}(id int64) *Worker {
+	if id > worker{{.Name}}MaxID || id < 0 {
+		return nil
+	}
+{{- else}}
+// NewWorker{{.Name}} will generate up to {{.PerSecond}} unique ids/second for {{.Years}} years
+// NewWorker{{.Name}} is the only worker of its size with this configuration
+func NewWorker{{.Name}}() *Worker {
+	const id = 0
+{{- end}}
+	w := &Worker{
+		ID:             id,
+		IDBits:         worker{{.Name}}IDBits,
+		IDShift:        worker{{.Name}}IDShift,
+		TagShift:       worker{{.Name}}TagShift,
+		SequenceBits:   worker{{.Name}}SequenceBits,
+		SequenceMask:   worker{{.Name}}MaxSequence,
+		TimeStampBits:  worker{{.Name}}TimeStampBits,
+		TimeStampShift: worker{{.Name}}TimeStampShift,
+		Frequency:      worker{{.Name}}Frequency,
+		TotalBits:      worker{{.Name}}TotalBits,
+		CustomEpoch:    worker{{.Name}}CustomEpoch,
+		MaxWorkerID:    worker{{.Name}}MaxID,
+		MaxSequence:    worker{{.Name}}MaxSequence,
+		MaxTimeStamp:   worker{{.Name}}MaxTimeStamp,
+	}
+	w.start()
+	return w
+}
+{{end}}
+// Register the generated presets ahead of any registered by users.
+func init() {
+	registry.presets = append(builtinPresets, registry.presets...)
+}
+
+var builtinPresets = []Preset{
+{{- range .}}
+	{
+		Name: "NewWorker{{.Name}}",
+		Layout: Layout{
+			IDBits:        worker{{.Name}}IDBits,
+			SequenceBits:  worker{{.Name}}SequenceBits,
+			TimeStampBits: worker{{.Name}}TimeStampBits,
+			Frequency:     worker{{.Name}}Frequency,
+			CustomEpoch:   worker{{.Name}}CustomEpoch,
+		},
+	},
+{{- end}}
+}
+`))
@@ -0,0 +1,3 @@
+module github.com/ifo/sanic
+
+go 1.23
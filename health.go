@@ -0,0 +1,85 @@
+package sanic
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthPolicy configures HealthCheck. The worker counts, over the last
+// Window, the ticks it generated IDs in and how many of those exhausted the
+// sequence; HealthCheck fails once the exhausted fraction exceeds Threshold.
+type HealthPolicy struct {
+	Window    time.Duration
+	Threshold float64 // between 0 and 1
+}
+
+// healthBuckets is how many buckets the window is split into. Counts are
+// kept per bucket, so the window slides one bucket at a time.
+const healthBuckets = 60
+
+type healthBucket struct {
+	index     int64 // tick / bucket width
+	used      int64
+	exhausted int64
+}
+
+// HealthCheck returns an error wrapping ErrUnderProvisioned when, over the
+// Health window, the fraction of ticks that exhausted the sequence is above
+// the threshold. It returns nil if Health is not set. It takes the worker's
+// lock, so it must not be called concurrently with UnsafeNextID.
+func (w *Worker) HealthCheck() error {
+	if w.Health == nil {
+		return nil
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.health == nil {
+		return nil
+	}
+	width := w.healthWidth()
+	current := w.Time() / width
+	var used, exhausted int64
+	for _, b := range w.health {
+		if b.index > current-healthBuckets && b.index <= current {
+			used += b.used
+			exhausted += b.exhausted
+		}
+	}
+	if used == 0 {
+		return nil
+	}
+	if frac := float64(exhausted) / float64(used); frac > w.Health.Threshold {
+		return fmt.Errorf("%w: sequence exhausted in %.1f%% of ticks over "+
+			"the last %s (threshold %.1f%%); consider a layout with more "+
+			"sequence bits than %d", ErrUnderProvisioned, 100*frac,
+			w.Health.Window, 100*w.Health.Threshold, w.SequenceBits)
+	}
+	return nil
+}
+
+// recordHealth counts tick as used, or as exhausted. w.mutex must be held.
+func (w *Worker) recordHealth(tick int64, exhausted bool) {
+	if w.health == nil {
+		w.health = make([]healthBucket, healthBuckets)
+	}
+	index := tick / w.healthWidth()
+	b := &w.health[index%healthBuckets]
+	if b.index != index {
+		*b = healthBucket{index: index}
+	}
+	if exhausted {
+		b.exhausted++
+	} else {
+		b.used++
+	}
+}
+
+// healthWidth is the width of a health bucket in ticks.
+func (w *Worker) healthWidth() int64 {
+	width := int64(w.Health.Window/w.Frequency) / healthBuckets
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
@@ -0,0 +1,56 @@
+package sanic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	if err := w.HealthCheck(); err != nil {
+		t.Fatalf("without a policy: %v", err)
+	}
+	w.Health = &HealthPolicy{Window: 600 * time.Millisecond, Threshold: 0.2}
+	if err := w.HealthCheck(); err != nil {
+		t.Fatalf("before any ID: %v", err)
+	}
+
+	for i := range int64(20) {
+		c.set(tick + i)
+		w.NextID()
+	}
+	if err := w.HealthCheck(); err != nil {
+		t.Fatalf("no exhaustion: %v", err)
+	}
+
+	// Exhaust the sequence in 10 of the next 20 ticks, making a quarter of
+	// the 40 ticks used exhausted.
+	fill := make([]int64, w.MaxSequence)
+	for now := tick + 20; now < tick+40; now += 2 {
+		c.set(now)
+		w.NextID()
+		w.NextIDs(fill)
+		done := make(chan struct{})
+		go func() { w.NextID(); close(done) }()
+		c.spinning()
+		c.set(now + 1)
+		<-done
+	}
+	err := w.HealthCheck()
+	if !errors.Is(err, ErrUnderProvisioned) || !strings.Contains(err.Error(), "25.0%") {
+		t.Fatalf("after exhaustion: %v", err)
+	}
+
+	// Once the exhaustion is out of the window, the worker is healthy again.
+	for i := range int64(5) {
+		c.set(tick + 1000 + i)
+		w.NextID()
+	}
+	if err := w.HealthCheck(); err != nil {
+		t.Errorf("after the window passed: %v", err)
+	}
+}
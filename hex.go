@@ -0,0 +1,47 @@
+package sanic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hexLen is the fixed width of hexadecimal IDs, enough for any int64.
+const hexLen = 16
+
+// IDHex returns id as 16 lowercase, zero-padded hexadecimal digits, so that
+// lexical order matches numeric order.
+func (w *Worker) IDHex(id int64) string {
+	return fmt.Sprintf("%016x", uint64(id))
+}
+
+// ParseHex parses a hexadecimal ID produced by IDHex. An optional "0x" prefix
+// is accepted, but the remainder must be exactly 16 hex digits and the ID
+// must fit the worker's layout.
+func (w *Worker) ParseHex(s string) (int64, error) {
+	id, err := parseHex(s)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.Validate(id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// parseHex parses the IDHex form, with an optional "0x" prefix, without
+// checking it against a layout.
+func parseHex(s string) (int64, error) {
+	h := s
+	if strings.HasPrefix(h, "0x") || strings.HasPrefix(h, "0X") {
+		h = h[2:]
+	}
+	if len(h) != hexLen {
+		return 0, fmt.Errorf("%w: %q is not %d hex digits", ErrMalformedID, s, hexLen)
+	}
+	u, err := strconv.ParseUint(h, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not %d hex digits", ErrMalformedID, s, hexLen)
+	}
+	return int64(u), nil
+}
@@ -0,0 +1,88 @@
+package sanic
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHexRoundTrip(t *testing.T) {
+	w := NewWorker10(5)
+	for range 1000 {
+		id := w.NextID()
+		h := w.IDHex(id)
+		if len(h) != hexLen {
+			t.Fatalf("IDHex(%d) = %q", id, h)
+		}
+		for _, s := range []string{h, "0x" + h, strings.ToUpper(h)} {
+			got, err := w.ParseHex(s)
+			if err != nil || got != id {
+				t.Fatalf("ParseHex(%q) = %d, %v, want %d", s, got, err, id)
+			}
+		}
+	}
+}
+
+func TestParseHexErrors(t *testing.T) {
+	w := NewWorker8()
+	for _, s := range []string{"", "0x", "1234", "00000000000000000", "000000000000000g"} {
+		if _, err := w.ParseHex(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("ParseHex(%q) = %v, want ErrMalformedID", s, err)
+		}
+	}
+	// Well formed, but wider than the 48-bit layout.
+	if _, err := w.ParseHex("0001000000000000"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("ParseHex of a 49-bit ID = %v, want ErrInvalidID", err)
+	}
+}
+
+func TestKeyText(t *testing.T) {
+	w := NewWorker10(5)
+	k := KeyOf(w.NextID())
+	b, err := json.Marshal(map[Key]Key{k: k})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := w.IDHex(k.Int64())
+	if want := `{"` + h + `":"` + h + `"}`; string(b) != want {
+		t.Errorf("json = %s, want %s", b, want)
+	}
+	var m map[Key]Key
+	if err := json.Unmarshal(b, &m); err != nil || m[k] != k {
+		t.Errorf("unmarshal = %v, %v", m, err)
+	}
+	if err := new(Key).UnmarshalText([]byte("nope")); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("UnmarshalText(nope) = %v", err)
+	}
+}
+
+// Version 1 IDs are negative, and keep their hex form.
+func TestKeyTextNegative(t *testing.T) {
+	for _, id := range []int64{-1, math.MinInt64, math.MinInt64 | 1<<40} {
+		k := KeyOf(id)
+		b, err := k.MarshalText()
+		if err != nil || len(b) != hexLen || strings.HasPrefix(string(b), "-") {
+			t.Errorf("MarshalText(%d) = %q, %v", id, b, err)
+		}
+		var got Key
+		if err := got.UnmarshalText(b); err != nil || got != k {
+			t.Errorf("UnmarshalText(%q) = %d, %v, want %d", b, got.Int64(), err, id)
+		}
+	}
+}
+
+func TestPartsText(t *testing.T) {
+	w := NewWorker10(5)
+	// One second after the epoch, worker 5, sequence 7.
+	p := w.Decompose(1000<<18 | 5<<12 | 7)
+	want := "time=2016-01-01T00:00:01Z tick=1451606401000 worker=5 tag=0 sequence=7 version=0"
+	if p.String() != want {
+		t.Errorf("String() = %q, want %q", p.String(), want)
+	}
+	b, err := p.MarshalText()
+	if err != nil || string(b) != want {
+		t.Errorf("MarshalText = %q, %v", b, err)
+	}
+}
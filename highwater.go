@@ -0,0 +1,173 @@
+package sanic
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HighWaterStore persists the last ID issued by each worker ID, so that a
+// replacement node taking over a worker ID can resume safely.
+type HighWaterStore interface {
+	// Load returns the stored ID for workerID, or 0 if there is none.
+	Load(workerID int64) (int64, error)
+	Store(workerID, id int64) error
+}
+
+// MemoryStore is an in-memory HighWaterStore.
+type MemoryStore struct {
+	mutex sync.Mutex
+	ids   map[int64]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ids: map[int64]int64{}}
+}
+
+func (s *MemoryStore) Load(workerID int64) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ids[workerID], nil
+}
+
+func (s *MemoryStore) Store(workerID, id int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ids[workerID] = id
+	return nil
+}
+
+// HighWaterWorker wraps a Worker and mirrors its last issued ID to a
+// HighWaterStore from a background goroutine, never on the NextID path.
+type HighWaterWorker struct {
+	w        *Worker
+	store    HighWaterStore
+	every    int64
+	interval time.Duration
+
+	last  atomic.Int64
+	count atomic.Int64
+
+	kick    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+
+	errMutex sync.Mutex
+	err      error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var errNoInterval = errors.New("sanic: HighWaterWorker needs a positive interval")
+
+// NewHighWaterWorker loads w's stored high-water mark and fast-forwards w
+// past it, then starts storing the last issued ID every interval, and also
+// after every every IDs if every is positive.
+//
+// Because stores are batched, the previous owner of the worker ID may have
+// issued IDs after its last successful store, for up to about one interval.
+// So w is fast-forwarded past the stored ID's tick plus twice interval,
+// waiting for the clock if needed, and never repeats an ID the previous
+// owner issued as long as its stores were succeeding.
+func NewHighWaterWorker(w *Worker, store HighWaterStore, every int,
+	interval time.Duration) (*HighWaterWorker, error) {
+
+	if interval <= 0 {
+		return nil, errNoInterval
+	}
+	stored, err := store.Load(w.ID)
+	if err != nil {
+		return nil, err
+	}
+	if unsigned(stored) > 0 {
+		tick := w.Decompose(stored).Tick + int64(2*interval/w.Frequency) + 1
+		w.fastForward(tick, w.MaxSequence)
+	}
+
+	h := &HighWaterWorker{
+		w:        w,
+		store:    store,
+		every:    int64(every),
+		interval: interval,
+		kick:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	h.last.Store(stored)
+	go h.flush()
+	return h, nil
+}
+
+// NextID returns the next ID from the wrapped worker.
+func (h *HighWaterWorker) NextID() int64 {
+	id := h.w.NextID()
+	for {
+		last := h.last.Load()
+		if unsigned(id) <= unsigned(last) || h.last.CompareAndSwap(last, id) {
+			break
+		}
+	}
+	if h.every > 0 && h.count.Add(1)%h.every == 0 {
+		select {
+		case h.kick <- struct{}{}:
+		default:
+		}
+	}
+	return id
+}
+
+// unsigned returns id without its sign bit, which is set only for version 1
+// IDs from a layout with VersionBit, so that one worker's IDs compare in the
+// order it issued them whatever its VersionBit.
+func unsigned(id int64) int64 {
+	return id & math.MaxInt64
+}
+
+// Err returns the most recent error from the store, or nil.
+func (h *HighWaterWorker) Err() error {
+	h.errMutex.Lock()
+	defer h.errMutex.Unlock()
+	return h.err
+}
+
+// Close stops the background goroutine and stores the final high-water
+// mark, returning any error from doing so. Later calls return the same
+// error without storing again.
+func (h *HighWaterWorker) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.done)
+		<-h.stopped
+		h.closeErr = h.store.Store(h.w.ID, h.last.Load())
+	})
+	return h.closeErr
+}
+
+func (h *HighWaterWorker) flush() {
+	defer close(h.stopped)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	stored := h.last.Load()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+		case <-h.kick:
+		}
+		last := h.last.Load()
+		if last == stored {
+			continue
+		}
+		err := h.store.Store(h.w.ID, last)
+		if err == nil {
+			stored = last
+		}
+		h.errMutex.Lock()
+		h.err = err
+		h.errMutex.Unlock()
+	}
+}
@@ -0,0 +1,121 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHighWaterNodeReplacement(t *testing.T) {
+	store := NewMemoryStore()
+	a, err := NewHighWaterWorker(NewWorker10(7), store, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for range 5000 {
+		last = a.NextID()
+	}
+	// Node A dies after its final store; node B takes over worker ID 7.
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Load(7); stored != last {
+		t.Fatalf("stored %d, want %d", stored, last)
+	}
+
+	w := NewWorker10(7)
+	b, err := NewHighWaterWorker(w, store, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	for range 1000 {
+		if id := b.NextID(); id <= last {
+			t.Fatalf("node B issued %d, not after node A's %d", id, last)
+		}
+	}
+	// Waiting out the fast-forward is not a clock regression.
+	if s := w.Stats(); s.ClockBackwards != 0 {
+		t.Errorf("ClockBackwards = %d after fast-forward, want 0", s.ClockBackwards)
+	}
+}
+
+// Version 1 IDs are negative, and must still be recorded and honoured.
+func TestHighWaterVersionBit(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	node := func() *Worker {
+		w, err := New(WorkerConfig{ID: 7, Layout: l})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	store := NewMemoryStore()
+	a, err := NewHighWaterWorker(node(), store, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for range 5000 {
+		last = a.NextID()
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.Load(7); stored != last || Version(stored) != 1 {
+		t.Fatalf("stored %d, want %d", stored, last)
+	}
+
+	w := node()
+	b, err := NewHighWaterWorker(w, store, 100, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	end := w.Decompose(last).Tick
+	for range 1000 {
+		id := b.NextID()
+		if p := w.Decompose(id); p.Version != 1 || p.Tick <= end {
+			t.Fatalf("node B issued %+v, not after node A's tick %d", p, end)
+		}
+	}
+}
+
+type failingStore struct{ MemoryStore }
+
+var errStoreDown = errors.New("store down")
+
+func (s *failingStore) Store(workerID, id int64) error { return errStoreDown }
+
+func TestHighWaterCloseTwice(t *testing.T) {
+	h, err := NewHighWaterWorker(NewWorker10(7), NewMemoryStore(), 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.NextID()
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+
+	f := &failingStore{MemoryStore{ids: map[int64]int64{}}}
+	h, err = NewHighWaterWorker(NewWorker10(7), f, 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := h.Close(); !errors.Is(err, errStoreDown) {
+			t.Errorf("Close = %v, want the store's error", err)
+		}
+	}
+}
+
+func TestHighWaterNeedsInterval(t *testing.T) {
+	if _, err := NewHighWaterWorker(NewWorker10(7), NewMemoryStore(), 1, 0); err == nil {
+		t.Error("zero interval accepted")
+	}
+}
@@ -0,0 +1,39 @@
+package sanic
+
+import (
+	"context"
+	"time"
+)
+
+// GenInfo describes how one ID was generated.
+type GenInfo struct {
+	Tick int64 // the ID's tick, as in Parts.Tick
+	// WaitedFor is how long generation waited for a later tick, by the
+	// worker's clock.
+	WaitedFor time.Duration
+	// RolledOver is set when the sequence was exhausted, so the ID is from
+	// a later tick than the one before it, by waiting or borrowing.
+	RolledOver bool
+	// ClockRegressed is set when the clock read behind the last ID, so
+	// generation waited for it to catch up, and ClockDrift is how far
+	// behind it read, to the worker's Frequency.
+	ClockRegressed bool
+	ClockDrift     time.Duration
+}
+
+// NextIDInfo is NextID that also reports how the ID was generated. NextID
+// itself does not time its waits.
+func (w *Worker) NextIDInfo() (id int64, info GenInfo) {
+	w.mutex.Lock()
+	w.waitTurn(context.Background(), false)
+	w.gen, w.timeWaits = GenInfo{}, true
+	id, tick := w.generate(0)
+	info, w.timeWaits = w.gen, false
+	w.mutex.Unlock()
+
+	info.Tick = tick
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
+	}
+	return id, info
+}
@@ -0,0 +1,76 @@
+package sanic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextIDInfo(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, _ := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	var fired []int64
+	w.OnNewInterval = func(tick int64) { fired = append(fired, tick) }
+	for seq := range int64(3) {
+		id, info := w.NextIDInfo()
+		if id != ref.compose(tick, 0, seq) || info != (GenInfo{Tick: tick}) {
+			t.Errorf("NextIDInfo = %+v, %+v", w.Decompose(id), info)
+		}
+	}
+	if len(fired) != 1 || fired[0] != tick {
+		t.Errorf("OnNewInterval fired for %v", fired)
+	}
+}
+
+func TestNextIDInfoRolledOver(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	w.NextIDs(make([]int64, w.MaxSequence+1))
+
+	type result struct {
+		id   int64
+		info GenInfo
+	}
+	got := make(chan result, 1)
+	go func() {
+		id, info := w.NextIDInfo()
+		got <- result{id, info}
+	}()
+	clock.spinning()
+	clock.set(tick + 3)
+	r := <-got
+	want := GenInfo{Tick: tick + 3, WaitedFor: 3 * time.Millisecond, RolledOver: true}
+	if r.id != ref.compose(tick+3, 0, 0) || r.info != want {
+		t.Errorf("NextIDInfo = %+v, %+v, want %+v", w.Decompose(r.id), r.info, want)
+	}
+	// The next call starts afresh.
+	if _, info := w.NextIDInfo(); info != (GenInfo{Tick: tick + 3}) {
+		t.Errorf("next NextIDInfo = %+v", info)
+	}
+}
+
+func TestNextIDInfoClockRegressed(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick+5)
+	w.NextID()
+	clock.set(tick + 2)
+
+	got := make(chan GenInfo, 1)
+	go func() {
+		_, info := w.NextIDInfo()
+		got <- info
+	}()
+	clock.spinning()
+	clock.set(tick + 6)
+	want := GenInfo{
+		Tick:           tick + 6,
+		WaitedFor:      4 * time.Millisecond,
+		ClockRegressed: true,
+		ClockDrift:     3 * time.Millisecond,
+	}
+	if info := <-got; info != want {
+		t.Errorf("NextIDInfo = %+v, want %+v", info, want)
+	}
+}
@@ -0,0 +1,319 @@
+package sanic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IssuanceLog wraps a Worker and records every ID it issues in segment
+// files in a directory, so that whether an ID was issued can be answered
+// from the log alone. It is safe for concurrent use.
+//
+// Each segment holds the IDs whose ticks fall in one span of time, in a file
+// named by the span's index in hex with the extension ".ids". A segment is a
+// sequence of blocks, each written by one flush:
+//
+//	uvarint count | uvarint length | CRC-32C of payload, 4 bytes LE | payload
+//
+// where the payload is count zigzag varints, each ID's difference from the
+// previous ID in the segment (from 0 for the first). Consecutive IDs from
+// one worker usually differ by 1, so most IDs take one byte.
+//
+// A block is only written whole and then synced, and a failed write is cut
+// off again. On opening, a block that is short or fails its checksum is
+// taken to be torn by a crash, and it and anything after it in the segment
+// are truncated away; Dropped reports how much.
+type IssuanceLog struct {
+	w          *Worker
+	dir        string
+	span       int64 // ticks per segment
+	flushEvery int
+
+	mutex    sync.Mutex
+	segments map[int64]*issuanceSegment
+	current  *issuanceSegment
+	file     *os.File
+	pending  []int64
+	last     int64 // the last ID written to current
+	size     int64 // the length of current's file
+	dropped  int64 // bytes truncated by recover
+}
+
+type issuanceSegment struct {
+	index    int64
+	min, max int64
+}
+
+const issuanceExt = ".ids"
+
+var (
+	errNoSpan     = errors.New("sanic: IssuanceLog needs a span of at least one tick")
+	errTornRecord = errors.New("sanic: torn issuance log record")
+	crcTable      = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// OpenIssuanceLog opens or creates the log in dir for IDs from w, with one
+// segment per span of time. IDs are buffered and written in blocks of
+// flushEvery, each synced before the NextID that filled it returns; a crash
+// can lose up to flushEvery-1 buffered records, so flushEvery below 2 makes
+// every ID durable before it is returned.
+func OpenIssuanceLog(w *Worker, dir string, span time.Duration,
+	flushEvery int) (*IssuanceLog, error) {
+
+	ticks := int64(span / w.Frequency)
+	if ticks < 1 {
+		return nil, errNoSpan
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	l := &IssuanceLog{
+		w:          w,
+		dir:        dir,
+		span:       ticks,
+		flushEvery: max(flushEvery, 1),
+		segments:   map[int64]*issuanceSegment{},
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), issuanceExt)
+		if !ok {
+			continue
+		}
+		index, err := strconv.ParseInt(name, 16, 64)
+		if err != nil {
+			continue
+		}
+		ids, err := l.recover(index)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			l.segments[index] = &issuanceSegment{index, ids[0], ids[len(ids)-1]}
+		}
+	}
+	return l, nil
+}
+
+// NextID returns w.NextID, after recording it in the log.
+func (l *IssuanceLog) NextID() (int64, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	id := l.w.NextID()
+	index := l.w.Decompose(id).Tick / l.span
+	if l.current == nil || l.current.index != index {
+		if err := l.rotate(index); err != nil {
+			return 0, err
+		}
+	}
+	l.pending = append(l.pending, id)
+	s := l.current
+	if _, ok := l.segments[index]; !ok {
+		s.min = id
+		l.segments[index] = s
+	}
+	s.max = max(s.max, id)
+	if len(l.pending) >= l.flushEvery {
+		if err := l.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// Dropped returns the number of bytes of torn or corrupt blocks, and of any
+// blocks after them, that opening the log truncated from its segments.
+func (l *IssuanceLog) Dropped() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.dropped
+}
+
+// Flush writes and syncs any buffered records.
+func (l *IssuanceLog) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.flush()
+}
+
+// Close flushes the log and closes its open segment.
+func (l *IssuanceLog) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	err := l.flush()
+	if l.file != nil {
+		err = errors.Join(err, l.file.Close())
+		l.file = nil
+		l.current = nil
+	}
+	return err
+}
+
+// Contains reports whether id is in the log, including records not yet
+// flushed. Only the segment for id's tick is read, and only if id is
+// between its smallest and largest IDs.
+func (l *IssuanceLog) Contains(id int64) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	index := l.w.Decompose(id).Tick / l.span
+	s, ok := l.segments[index]
+	if !ok || id < s.min || id > s.max {
+		return false, nil
+	}
+	if s == l.current && slices.Contains(l.pending, id) {
+		return true, nil
+	}
+	data, err := os.ReadFile(l.path(index))
+	if err != nil {
+		return false, err
+	}
+	ids, _, err := decodeIssuance(data)
+	if err != nil {
+		return false, err
+	}
+	if !slices.IsSorted(ids) {
+		slices.Sort(ids)
+	}
+	_, found := slices.BinarySearch(ids, id)
+	return found, nil
+}
+
+// rotate flushes the current segment and makes the segment with index
+// current, opening its file for appending. l.mutex must be held.
+func (l *IssuanceLog) rotate(index int64) error {
+	if err := l.flush(); err != nil {
+		return err
+	}
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			return err
+		}
+		l.file, l.current = nil, nil
+	}
+
+	f, err := os.OpenFile(l.path(index), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file, l.last, l.size = f, 0, info.Size()
+	l.current = l.segments[index]
+	if l.current == nil {
+		l.current = &issuanceSegment{index: index}
+	} else {
+		l.last = l.current.max
+	}
+	return nil
+}
+
+// flush writes the pending records as one block and syncs the file. If
+// that fails, the file is cut back to its last whole block and the records
+// stay pending, so that flush can be retried. l.mutex must be held.
+func (l *IssuanceLog) flush() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	var payload []byte
+	last := l.last
+	for _, id := range l.pending {
+		payload = binary.AppendVarint(payload, id-last)
+		last = id
+	}
+	block := binary.AppendUvarint(nil, uint64(len(l.pending)))
+	block = binary.AppendUvarint(block, uint64(len(payload)))
+	block = binary.LittleEndian.AppendUint32(block, crc32.Checksum(payload, crcTable))
+	block = append(block, payload...)
+
+	_, err := l.file.Write(block)
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		return errors.Join(err, l.file.Truncate(l.size))
+	}
+	l.last, l.size = last, l.size+int64(len(block))
+	l.pending = l.pending[:0]
+	return nil
+}
+
+// recover reads the segment with index, truncating it from its first torn or
+// corrupt block, and returns its IDs.
+func (l *IssuanceLog) recover(index int64) ([]int64, error) {
+	path := l.path(index)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ids, n, err := decodeIssuance(data)
+	if errors.Is(err, errTornRecord) {
+		l.dropped += int64(len(data) - n)
+		err = os.Truncate(path, int64(n))
+	}
+	return ids, err
+}
+
+func (l *IssuanceLog) path(index int64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%016x%s", index, issuanceExt))
+}
+
+// decodeIssuance returns the IDs in a segment's blocks and the length of
+// the data holding whole, valid blocks. If a block after that is short or
+// fails its checksum it returns errTornRecord as well.
+func decodeIssuance(data []byte) (ids []int64, n int, err error) {
+	var last int64
+	for n < len(data) {
+		b := data[n:]
+		count, k := binary.Uvarint(b)
+		if k <= 0 {
+			return ids, n, errTornRecord
+		}
+		b = b[k:]
+		length, k := binary.Uvarint(b)
+		if k <= 0 || len(b)-k < 4 || uint64(len(b)-k-4) < length {
+			return ids, n, errTornRecord
+		}
+		b = b[k:]
+		sum := binary.LittleEndian.Uint32(b)
+		payload := b[4 : 4+length]
+		if crc32.Checksum(payload, crcTable) != sum {
+			return ids, n, errTornRecord
+		}
+
+		block := ids
+		for i := uint64(0); i < count; i++ {
+			delta, k := binary.Varint(payload)
+			if k <= 0 {
+				return block[:len(ids)], n, errTornRecord
+			}
+			payload = payload[k:]
+			last += delta
+			block = append(block, last)
+		}
+		if len(payload) != 0 {
+			return block[:len(ids)], n, errTornRecord
+		}
+		ids = block
+		n = len(data) - len(b) + 4 + int(length)
+	}
+	return ids, n, nil
+}
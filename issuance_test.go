@@ -0,0 +1,248 @@
+package sanic
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issue generates n IDs through l, in ticks from tick, perTick to a tick.
+func issue(t *testing.T, l *IssuanceLog, c *fakeClock, tick int64, n, perTick int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		c.set(tick + int64(i/perTick))
+		id, err := l.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+issuanceExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestIssuanceLogContains(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time() / 10 * 10
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	dir := t.TempDir()
+	l, err := OpenIssuanceLog(w, dir, 10*time.Millisecond, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 IDs a tick over 50 ticks, so 5 segments.
+	ids := issue(t, l, c, tick, 150, 3)
+	if ok, err := l.Contains(ids[149]); !ok || err != nil {
+		t.Errorf("unflushed ID: %v, %v", ok, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(segmentFiles(t, dir)); n != 5 {
+		t.Errorf("%d segment files, want 5", n)
+	}
+
+	l, err = OpenIssuanceLog(w, dir, 10*time.Millisecond, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, id := range ids {
+		if ok, err := l.Contains(id); !ok || err != nil {
+			t.Fatalf("issued ID %d: %v, %v", id, ok, err)
+		}
+	}
+	for _, id := range []int64{
+		ref.compose(tick+10, 0, 3),            // next sequence in a segment's tick
+		ref.compose(tick-1, 0, 0),             // before the first segment
+		ref.compose(tick+60, 0, 0),            // after the last
+		ids[0] ^ 3<<ref.Layout().SequenceBits, // another worker's ID in a hit tick
+	} {
+		if ok, err := l.Contains(id); ok || err != nil {
+			t.Errorf("unissued ID %d: %v, %v", id, ok, err)
+		}
+	}
+}
+
+func TestIssuanceLogTornRecord(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time() / 10 * 10
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	dir := t.TempDir()
+	l, err := OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := issue(t, l, c, tick, 30, 30) // three blocks in one segment
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := segmentFiles(t, dir)[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cut the last block short, as a crash mid-write would.
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if ok, err := l.Contains(id); ok != (i < 20) || err != nil {
+			t.Errorf("ID %d after recovery: %v, %v", i, ok, err)
+		}
+	}
+	if d := l.Dropped(); d == 0 {
+		t.Error("no bytes reported dropped")
+	}
+	// The log goes on from the whole blocks.
+	more := issue(t, l, c, tick+1, 10, 10)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, id := range append(ids[:20], more...) {
+		if ok, err := l.Contains(id); !ok || err != nil {
+			t.Fatalf("ID %d after appending to a recovered segment: %v, %v", id, ok, err)
+		}
+	}
+}
+
+// A block after a corrupt one is dropped with it, and counted.
+func TestIssuanceLogCorruptBlock(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time() / 10 * 10
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	dir := t.TempDir()
+	l, err := OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := issue(t, l, c, tick, 10, 10)
+	if err := l.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	path := segmentFiles(t, dir)[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := int(info.Size())
+	issue(t, l, c, tick, 20, 20) // two more blocks
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[first+8] ^= 0xff // in the second block's payload, past its header
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if d, want := l.Dropped(), int64(len(data)-first); d != want {
+		t.Errorf("dropped %d bytes, want %d", d, want)
+	}
+	for _, id := range ids {
+		if ok, err := l.Contains(id); !ok || err != nil {
+			t.Errorf("ID %d before the corrupt block: %v, %v", id, ok, err)
+		}
+	}
+}
+
+// A failed flush leaves its records pending, and a retry writes them whole.
+func TestIssuanceLogFlushRetry(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time() / 10 * 10
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	dir := t.TempDir()
+	l, err := OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := issue(t, l, c, tick, 15, 15)
+	path := segmentFiles(t, dir)[0]
+	l.file.Close() // the next write fails
+	if err := l.Flush(); err == nil {
+		t.Fatal("flush to a closed file succeeded")
+	}
+	if l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = OpenIssuanceLog(w, dir, 10*time.Millisecond, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if d := l.Dropped(); d != 0 {
+		t.Errorf("dropped %d bytes", d)
+	}
+	for _, id := range ids {
+		if ok, err := l.Contains(id); !ok || err != nil {
+			t.Fatalf("ID %d after a retried flush: %v, %v", id, ok, err)
+		}
+	}
+}
+
+// Realistic traffic, a few hundred IDs a tick, takes about a byte per ID.
+func TestIssuanceLogSize(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	dir := t.TempDir()
+	l, err := OpenIssuanceLog(w, dir, time.Hour, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 100000
+	issue(t, l, c, tick, n, 300)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, f := range segmentFiles(t, dir) {
+		info, err := os.Stat(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += info.Size()
+	}
+	if perID := float64(size) / n; perID > 1.1 {
+		t.Errorf("%.2f bytes per ID", perID)
+	}
+}
+
+func TestIssuanceLogSpan(t *testing.T) {
+	if _, err := OpenIssuanceLog(NewWorker8(), t.TempDir(), 10*time.Millisecond, 1); !errors.Is(err, errNoSpan) {
+		t.Errorf("span shorter than a tick: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package sanic
+
+import (
+	"context"
+	"iter"
+)
+
+// iterBlock is the most IDs IterN reserves at once.
+const iterBlock = 64
+
+// Iter returns an iterator over new IDs, one NextIDContext per ID, that
+// ends when ctx is done or generation fails, such as with ErrPaused. It
+// starts no goroutines and only generates an ID when the loop asks for the
+// next one, so breaking out of the loop wastes no sequence space.
+func (w *Worker) Iter(ctx context.Context) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for ctx.Err() == nil {
+			id, err := w.NextIDContext(ctx)
+			if err != nil || !yield(id) {
+				return
+			}
+		}
+	}
+}
+
+// IterN returns an iterator over n new IDs. They are reserved with NextIDs
+// in blocks of up to 64, so breaking out of the loop early discards the
+// rest of the current block: at most 63 IDs are generated and never
+// yielded.
+func (w *Worker) IterN(n int) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		var buf [iterBlock]int64
+		for n > 0 {
+			block := buf[:min(n, iterBlock)]
+			w.NextIDs(block)
+			for _, id := range block {
+				if !yield(id) {
+					return
+				}
+			}
+			n -= len(block)
+		}
+	}
+}
+
+// IterStrings is Iter yielding IDString forms.
+func (w *Worker) IterStrings(ctx context.Context) iter.Seq[string] {
+	return w.stringSeq(w.Iter(ctx))
+}
+
+// IterNStrings is IterN yielding IDString forms.
+func (w *Worker) IterNStrings(n int) iter.Seq[string] {
+	return w.stringSeq(w.IterN(n))
+}
+
+func (w *Worker) stringSeq(ids iter.Seq[int64]) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for id := range ids {
+			if !yield(w.IDString(id)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package sanic
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestIterBreak(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, _ := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	n := 0
+	for id := range w.Iter(context.Background()) {
+		if id != ref.compose(tick, 0, int64(n)) {
+			t.Fatalf("ID %d has sequence %d", n, w.Decompose(id).Sequence)
+		}
+		if n++; n == 10 {
+			break
+		}
+	}
+	// Breaking out burned nothing.
+	if p := w.Decompose(w.NextID()); p.Tick != tick || p.Sequence != 10 {
+		t.Errorf("NextID after Iter at sequence %d", p.Sequence)
+	}
+}
+
+func TestIterNBreak(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, _ := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	n := 0
+	for range w.IterN(100) {
+		if n++; n == 70 {
+			break
+		}
+	}
+	// The rest of the second block, the last 36 of the 100, is discarded.
+	if p := w.Decompose(w.NextID()); p.Sequence != 100 {
+		t.Errorf("NextID after IterN at sequence %d, want 100", p.Sequence)
+	}
+
+	var last int64
+	n = 0
+	for id := range w.IterN(200) {
+		if id <= last {
+			t.Fatalf("ID %d not after %d", id, last)
+		}
+		last, n = id, n+1
+	}
+	if n != 200 {
+		t.Errorf("IterN(200) yielded %d IDs", n)
+	}
+	for range w.IterN(0) {
+		t.Fatal("IterN(0) yielded an ID")
+	}
+}
+
+func TestIterCancel(t *testing.T) {
+	w := NewWorker10(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	for range w.Iter(ctx) {
+		if n++; n == 5 {
+			cancel()
+		}
+		if n > 5 {
+			t.Fatal("Iter yielded after cancellation")
+		}
+	}
+	for range w.Iter(ctx) {
+		t.Fatal("Iter yielded with a done context")
+	}
+
+	// Generation failing ends the loop too.
+	w.PausePolicy = PauseError
+	w.Pause()
+	for range w.Iter(context.Background()) {
+		t.Fatal("Iter yielded while paused")
+	}
+}
+
+func TestIterConcurrent(t *testing.T) {
+	w := NewWorker10(5)
+	const goroutines, each = 4, 3000
+	got := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for g := range got {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if g%2 == 0 {
+				for id := range w.IterN(each) {
+					got[g] = append(got[g], id)
+				}
+				return
+			}
+			for id := range w.Iter(context.Background()) {
+				if got[g] = append(got[g], id); len(got[g]) == each {
+					break
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	seen := map[int64]bool{}
+	for _, ids := range got {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != goroutines*each {
+		t.Errorf("%d IDs, want %d", len(seen), goroutines*each)
+	}
+}
+
+func TestIterStrings(t *testing.T) {
+	w := NewWorker10(5)
+	var strs []string
+	for s := range w.IterNStrings(3) {
+		strs = append(strs, s)
+	}
+	for s := range w.IterStrings(context.Background()) {
+		strs = append(strs, s)
+		break
+	}
+	if len(strs) != 4 {
+		t.Fatalf("%d strings", len(strs))
+	}
+	for i, s := range strs {
+		if _, err := w.ParseIDString(s); err != nil {
+			t.Errorf("string %q: %v", s, err)
+		}
+		if i > 0 && s <= strs[i-1] {
+			t.Errorf("%q not after %q", s, strs[i-1])
+		}
+	}
+}
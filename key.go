@@ -0,0 +1,92 @@
+package sanic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// Key is an ID stored big-endian, for use as a map key. Because IDs are
+// non-negative, comparing Keys byte by byte orders them the same as the IDs
+// they hold, which for IDs from one worker is creation order. Version 1 IDs
+// from a layout with VersionBit are negative but their Keys sort after
+// every version 0 Key, as their strings do.
+type Key [8]byte
+
+// KeyOf returns the Key for id.
+func KeyOf(id int64) Key {
+	var k Key
+	binary.BigEndian.PutUint64(k[:], uint64(id))
+	return k
+}
+
+// ParseKey parses an ID string from w into a Key.
+func ParseKey(w *Worker, s string) (Key, error) {
+	id, err := w.ParseIDString(s)
+	if err != nil {
+		return Key{}, err
+	}
+	return KeyOf(id), nil
+}
+
+// Int64 returns the ID held in k.
+func (k Key) Int64() int64 {
+	return int64(binary.BigEndian.Uint64(k[:]))
+}
+
+// Time returns the creation time of k under w's layout.
+func (k Key) Time(w *Worker) time.Time {
+	return w.Timestamp(k.Int64())
+}
+
+// WorkerID returns the worker ID of k under w's layout.
+func (k Key) WorkerID(w *Worker) int64 {
+	return w.Decompose(k.Int64()).WorkerID
+}
+
+// Sequence returns the sequence of k under w's layout.
+func (k Key) Sequence(w *Worker) int64 {
+	return w.Decompose(k.Int64()).Sequence
+}
+
+// Before reports whether k sorts before other.
+func (k Key) Before(other Key) bool {
+	return CompareKeys(k, other) < 0
+}
+
+// CompareKeys orders Keys by the IDs they hold, for use with
+// slices.SortFunc.
+func CompareKeys(a, b Key) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// IDString returns k in w's string encoding.
+func (k Key) IDString(w *Worker) string {
+	return w.IDString(k.Int64())
+}
+
+// String returns k in the string encoding for a full 64 bits, which sorts
+// like the Keys themselves regardless of layout.
+func (k Key) String() string {
+	s, _ := IntToString(k.Int64(), 64)
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler, writing k in the IDHex
+// form. Unlike IDString, the hex form needs no layout to read back.
+func (k Key) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%016x", uint64(k.Int64())), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, reading the IDHex form
+// with or without a "0x" prefix. It cannot check the ID against a layout;
+// use ParseHex for that.
+func (k *Key) UnmarshalText(text []byte) error {
+	id, err := parseHex(string(text))
+	if err != nil {
+		return err
+	}
+	*k = KeyOf(id)
+	return nil
+}
@@ -0,0 +1,64 @@
+package sanic
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestKeyOrder(t *testing.T) {
+	w := NewWorker10(7)
+	ids := make([]int64, 5000)
+	w.NextIDs(ids)
+	keys := make([]Key, len(ids))
+	counts := map[Key]int{}
+	for i, id := range ids {
+		keys[i] = KeyOf(id)
+		counts[keys[i]]++
+	}
+	if len(counts) != len(ids) {
+		t.Errorf("%d IDs made %d distinct map keys", len(ids), len(counts))
+	}
+
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	slices.SortFunc(keys, CompareKeys)
+	for i, k := range keys {
+		if k.Int64() != ids[i] {
+			t.Fatalf("sorted key %d holds %d, want %d", i, k.Int64(), ids[i])
+		}
+		if i > 0 && (!keys[i-1].Before(k) || k.Before(keys[i-1]) ||
+			keys[i-1].String() >= k.String()) {
+			t.Fatalf("keys %d and %d out of order", i-1, i)
+		}
+	}
+}
+
+func TestKeyParts(t *testing.T) {
+	w := NewWorker10(7)
+	id := w.NextID()
+	k := KeyOf(id)
+	p := w.Decompose(id)
+	if !k.Time(w).Equal(p.Time) || k.WorkerID(w) != 7 || k.Sequence(w) != p.Sequence {
+		t.Errorf("key parts %s/%d/%d, want %+v", k.Time(w), k.WorkerID(w), k.Sequence(w), p)
+	}
+	s := k.IDString(w)
+	if s != w.IDString(id) {
+		t.Errorf("IDString %q, want %q", s, w.IDString(id))
+	}
+	if back, err := ParseKey(w, s); err != nil || back != k {
+		t.Errorf("ParseKey(%q) = %v, %v", s, back, err)
+	}
+	if _, err := ParseKey(w, "short"); err == nil {
+		t.Error("ParseKey accepted a malformed string")
+	}
+}
+
+func TestKeyVersionBit(t *testing.T) {
+	w := NewWorker10(7)
+	v0 := KeyOf(math.MaxInt64 >> 1)
+	v1 := KeyOf(w.compose(w.CustomEpoch+1, 0, 0) | math.MinInt64)
+	if !v0.Before(v1) || v0.String() >= v1.String() {
+		t.Errorf("version 1 key %s does not sort after version 0 key %s", v1, v0)
+	}
+}
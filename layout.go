@@ -0,0 +1,181 @@
+package sanic
+
+import (
+	"math"
+	"time"
+)
+
+// Layout describes how IDs pack a timestamp, worker ID and sequence. It holds
+// everything needed to decode and validate an ID, and nothing about which
+// worker generated it, so one Layout decodes IDs from every worker sharing
+// it.
+type Layout struct {
+	IDBits        uint64
+	TagBits       uint64
+	SequenceBits  uint64
+	TimeStampBits uint64
+	Frequency     time.Duration
+	CustomEpoch   int64
+	// VersionBit makes the sign bit, otherwise always clear, a version flag
+	// for migrating to a new layout in place: IDs with it set are version 1
+	// and negative. See Version and VersionedDecoder.
+	VersionBit bool
+}
+
+// Layout returns the worker's layout. Decoding through it, or through the
+// worker's own decode methods, never depends on w.ID. Its StringLength
+// reports the fixed width of IDString, which is also the longest
+// IDStringCompact can be.
+func (w *Worker) Layout() Layout {
+	return Layout{
+		IDBits:        w.IDBits,
+		TagBits:       w.TagBits,
+		SequenceBits:  w.SequenceBits,
+		TimeStampBits: w.TimeStampBits,
+		Frequency:     w.Frequency,
+		CustomEpoch:   w.CustomEpoch,
+		VersionBit:    w.VersionBit,
+	}
+}
+
+// TotalBits is the number of bits in an ID, including the sign bit, which is
+// unused unless VersionBit is set.
+func (l Layout) TotalBits() uint64 {
+	return l.IDBits + l.TagBits + l.SequenceBits + l.TimeStampBits + 1
+}
+
+// MaxWorkerID is the largest worker ID the layout holds.
+func (l Layout) MaxWorkerID() int64 {
+	return 1<<l.IDBits - 1
+}
+
+// MaxTag is the largest tag the layout holds, 0 without TagBits.
+func (l Layout) MaxTag() int64 {
+	return 1<<l.TagBits - 1
+}
+
+// MaxSequence is the largest sequence the layout holds.
+func (l Layout) MaxSequence() int64 {
+	return 1<<l.SequenceBits - 1
+}
+
+// MaxTimeStamp is the largest timestamp the layout holds, in ticks since
+// CustomEpoch.
+func (l Layout) MaxTimeStamp() int64 {
+	return 1<<l.TimeStampBits - 1
+}
+
+// Decompose unpacks id. With VersionBit, the sign bit is reported as
+// Parts.Version rather than read as part of the timestamp. IDs that Validate
+// rejects for not being positive give the zero Parts, whose Time IsZero,
+// rather than a time at the epoch.
+func (l Layout) Decompose(id int64) Parts {
+	if !l.positive(id) {
+		return Parts{}
+	}
+	version := 0
+	if l.VersionBit && id < 0 {
+		id, version = id&math.MaxInt64, 1
+	}
+	tick := id>>l.timeStampShift() + l.CustomEpoch
+	return Parts{
+		Time:     l.tickTime(tick),
+		Tick:     tick,
+		WorkerID: id >> (l.SequenceBits + l.TagBits) & (1<<l.IDBits - 1),
+		Tag:      id >> l.SequenceBits & (1<<l.TagBits - 1),
+		Sequence: id & (1<<l.SequenceBits - 1),
+		Version:  version,
+	}
+}
+
+// StringLength is the length of the layout's IDString form. Compact forms
+// from IDStringCompact are between 1 and StringLength characters long.
+func (l Layout) StringLength() int {
+	return StringLength(l.TotalBits())
+}
+
+// Timestamp returns the time id was generated, to the layout's Frequency.
+func (l Layout) Timestamp(id int64) time.Time {
+	return l.Decompose(id).Time
+}
+
+// Validate returns ErrInvalidID if id could not have been generated with
+// this layout. The top bit of TotalBits is reserved to keep IDs positive, so
+// valid IDs are positive and use at most TotalBits-1 bits, apart from the
+// sign bit when VersionBit is set. Zero is never valid: it would decode to
+// the epoch, and a worker only generates it in the epoch's first tick.
+func (l Layout) Validate(id int64) error {
+	if !l.positive(id) {
+		return ErrInvalidID
+	}
+	if l.VersionBit {
+		id &= math.MaxInt64
+	}
+	if id>>(l.TotalBits()-1) != 0 {
+		return ErrInvalidID
+	}
+	return nil
+}
+
+// positive reports whether id is above zero, not counting the sign bit when
+// VersionBit is set.
+func (l Layout) positive(id int64) bool {
+	if l.VersionBit {
+		id &= math.MaxInt64
+	}
+	return id > 0
+}
+
+// Epoch returns the layout's custom epoch as a time.
+func (l Layout) Epoch() time.Time {
+	return l.tickTime(l.CustomEpoch)
+}
+
+// Exhausts returns the first time that no longer fits in the timestamp bits.
+func (l Layout) Exhausts() time.Time {
+	return l.tickTime(l.CustomEpoch + 1<<l.TimeStampBits)
+}
+
+// FirstID returns the smallest ID the layout can hold for the tick
+// containing t, that is, with worker ID, tag and sequence all zero.
+func (l Layout) FirstID(t time.Time) int64 {
+	return (l.tick(t) - l.CustomEpoch) << l.timeStampShift()
+}
+
+func (l Layout) timeStampShift() uint64 {
+	return l.SequenceBits + l.TagBits + l.IDBits
+}
+
+func (l Layout) tick(t time.Time) int64 {
+	return t.UnixNano() / int64(l.Frequency)
+}
+
+func (l Layout) tickTime(tick int64) time.Time {
+	return time.Unix(0, tick*int64(l.Frequency)).UTC()
+}
+
+// DecodeAny validates and decomposes an ID from any worker using layout l.
+func DecodeAny(l Layout, id int64) (Parts, error) {
+	if err := l.Validate(id); err != nil {
+		return Parts{}, err
+	}
+	return l.Decompose(id), nil
+}
+
+// stringBits returns the bits of id that IDString encodes. The string form
+// has no sign bit, so with VersionBit the version moves to bit TotalBits-1,
+// which is otherwise always clear.
+func (l Layout) stringBits(id int64) int64 {
+	if l.VersionBit && id < 0 {
+		return id&math.MaxInt64 | 1<<(l.TotalBits()-1)
+	}
+	return id
+}
+
+// fromStringBits is the inverse of stringBits.
+func (l Layout) fromStringBits(v int64) int64 {
+	if top := int64(1) << (l.TotalBits() - 1); l.VersionBit && v&top != 0 {
+		return v&^top | math.MinInt64
+	}
+	return v
+}
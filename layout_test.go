@@ -0,0 +1,208 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ifo/sanic/sanicpb"
+)
+
+func TestLayoutAccessors(t *testing.T) {
+	for _, w := range presetWorkers() {
+		l := w.Layout()
+		if l.MaxWorkerID() != w.MaxWorkerID || l.MaxTag() != w.MaxTag ||
+			l.MaxSequence() != w.MaxSequence || l.MaxSequence() != w.SequenceMask ||
+			l.MaxTimeStamp() != w.MaxTimeStamp || l.TotalBits() != w.TotalBits {
+			t.Errorf("%s: layout accessors disagree with the worker's fields", w)
+		}
+		if l.SequenceBits+l.TagBits != w.IDShift || l.SequenceBits != w.TagShift ||
+			l.TotalBits()-1-l.TimeStampBits != w.TimeStampShift {
+			t.Errorf("%s: shifts disagree with the documented replacements", w)
+		}
+	}
+}
+
+func TestLastIssued(t *testing.T) {
+	w := NewWorker10(4)
+	if tick, _ := w.LastIssued(); tick >= w.Time() {
+		t.Errorf("LastIssued before the first ID is tick %d, not below the clock", tick)
+	}
+	id := w.NextID()
+	w.NextID()
+	p := w.Decompose(id)
+	if tick, seq := w.LastIssued(); tick < p.Tick || tick == p.Tick && seq != p.Sequence+1 {
+		t.Errorf("LastIssued = %d, %d after %+v and one more", tick, seq, p)
+	}
+}
+
+// One decoding worker handles IDs from every worker ID of its layout.
+func TestDecodeAllWorkers(t *testing.T) {
+	dec := NewWorker10(0)
+	l := dec.Layout()
+	for id := int64(0); id <= l.MaxWorkerID(); id++ {
+		w := NewWorker10(id)
+		before := l.FirstID(time.Now())
+		v := w.NextID()
+		if v < before {
+			t.Errorf("worker %d: ID %d below FirstID %d", id, v, before)
+		}
+		p, err := DecodeAny(l, v)
+		if err != nil {
+			t.Fatalf("worker %d: %v", id, err)
+		}
+		if p.WorkerID != id || p != dec.Decompose(v) || p != w.Decompose(v) {
+			t.Errorf("worker %d: decoded %+v", id, p)
+		}
+		if err := dec.Validate(v); err != nil {
+			t.Errorf("worker %d: Validate: %v", id, err)
+		}
+		if !dec.Timestamp(v).Equal(p.Time) || !l.Timestamp(v).Equal(p.Time) {
+			t.Errorf("worker %d: Timestamp disagrees with Decompose", id)
+		}
+	}
+}
+
+func TestDecodeAnyInvalid(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	for _, id := range []int64{0, -1, 1 << (l.TotalBits() - 1)} {
+		if _, err := DecodeAny(l, id); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("DecodeAny(%d) = %v, want ErrInvalidID", id, err)
+		}
+	}
+}
+
+// No entry point accepts 0, -1 or MinInt64, with or without VersionBit:
+// each returns ErrInvalidID. String entry points are given the ID's string
+// form, where it has one.
+func TestNonPositiveRejected(t *testing.T) {
+	layoutCodes(t)
+	versioned := NewWorker10(0).Layout()
+	versioned.VersionBit = true
+	vw, err := New(WorkerConfig{ID: 7, Layout: versioned})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	numeric := map[string]func(w *Worker, id int64) error{
+		"Validate": func(w *Worker, id int64) error { return w.Validate(id) },
+		"DecodeAny": func(w *Worker, id int64) error {
+			_, err := DecodeAny(w.Layout(), id)
+			return err
+		},
+		"EncodeFixed": func(w *Worker, id int64) error {
+			return w.EncodeFixed(make([]byte, w.StringLength()), id)
+		},
+		"Remap": func(w *Worker, id int64) error {
+			_, err := Remap(w, NewWorker10(7), id)
+			return err
+		},
+		"WaitUntilAfter": func(w *Worker, id int64) error {
+			return w.WaitUntilAfter(context.Background(), id)
+		},
+		"EnsureAfter": func(w *Worker, id int64) error { return w.EnsureAfter(id) },
+		"TenString.From": func(_ *Worker, id int64) error {
+			var s TenString
+			return s.From(id)
+		},
+		"SevenString.From": func(_ *Worker, id int64) error {
+			var s SevenString
+			return s.From(id)
+		},
+		"FromProto": func(_ *Worker, id int64) error {
+			p := &sanicpb.SanicID{Id: id}
+			if id == 0 {
+				p = &sanicpb.SanicID{Str: "-"}
+			}
+			_, err := FromProto(p)
+			return err
+		},
+		"ParseProto": func(w *Worker, id int64) error {
+			p := &sanicpb.SanicID{Id: id}
+			if id == 0 {
+				p = &sanicpb.SanicID{Str: strings.Repeat("-", w.StringLength())}
+			}
+			_, err := ParseProto(w, p)
+			return err
+		},
+	}
+	str := map[string]func(w *Worker, s string) error{
+		"ParseIDString": func(w *Worker, s string) error {
+			_, err := w.ParseIDString(s)
+			return err
+		},
+		"ParseCompact": func(w *Worker, s string) error {
+			c := strings.TrimLeft(s, "-")
+			if c == "" {
+				c = "-"
+			}
+			_, err := w.ParseCompact(c)
+			return err
+		},
+		"ParsePrefixed": func(w *Worker, s string) error {
+			_, err := w.ParsePrefixed("evt", "evt-"+s)
+			return err
+		},
+		"Prefixes.Parse": func(w *Worker, s string) error {
+			p := Prefixes{}
+			p.Register("evt", w)
+			_, _, err := p.Parse("evt-" + s)
+			return err
+		},
+		"ParseKey": func(w *Worker, s string) error {
+			_, err := ParseKey(w, s)
+			return err
+		},
+		"BindID": func(w *Worker, s string) error {
+			_, err := BindID(w, s)
+			return err
+		},
+		"DecodeTagged": func(_ *Worker, s string) error {
+			_, _, err := DecodeTagged("A" + s)
+			return err
+		},
+		"DecodeReader": func(w *Worker, s string) error {
+			d, err := DecodeReader(strings.NewReader(s+"\n"), w, DecodeOptions{})
+			if err != nil {
+				return err
+			}
+			_, err = io.ReadAll(d)
+			return err
+		},
+		"TenString.ID": func(_ *Worker, s string) error {
+			var f TenString
+			copy(f[:], s)
+			_, err := f.ID()
+			return err
+		},
+	}
+
+	for _, w := range []*Worker{NewWorker10(7), vw} {
+		l := w.Layout()
+		for _, id := range []int64{0, -1, math.MinInt64} {
+			if p := w.Decompose(id); !l.positive(id) && (p != (Parts{}) || !w.Timestamp(id).IsZero()) {
+				t.Errorf("VersionBit %v: Decompose(%d) = %+v", w.VersionBit, id, p)
+			}
+			for name, f := range numeric {
+				if err := f(w, id); !errors.Is(err, ErrInvalidID) {
+					t.Errorf("VersionBit %v: %s(%d) = %v, want ErrInvalidID", w.VersionBit, name, id, err)
+				}
+			}
+
+			form, err := IntToString(l.stringBits(id), l.TotalBits())
+			if err != nil {
+				continue
+			}
+			for name, f := range str {
+				if err := f(w, form); !errors.Is(err, ErrInvalidID) {
+					t.Errorf("VersionBit %v: %s(%q) = %v, want ErrInvalidID",
+						w.VersionBit, name, form, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sanic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RegisterLayoutCode gives the registered preset name a one-character code
+// for EncodeTagged and DecodeTagged. The code must be a character of the
+// string encoding's alphabet, and each preset and each code may be
+// registered only once.
+func RegisterLayoutCode(name string, code byte) error {
+	if strings.IndexByte(alphabet, code) < 0 {
+		return fmt.Errorf("sanic: layout code %q is not in the alphabet", code)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+
+	i := -1
+	for j, p := range registry.presets {
+		switch {
+		case p.Code == code:
+			return fmt.Errorf("sanic: layout code %q already registered for %q",
+				code, p.Name)
+		case p.Name == name:
+			i = j
+		}
+	}
+	switch {
+	case i < 0:
+		return fmt.Errorf("sanic: no preset %q registered", name)
+	case registry.presets[i].Code != 0:
+		return fmt.Errorf("sanic: preset %q already has layout code %q",
+			name, registry.presets[i].Code)
+	}
+	registry.presets[i].Code = code
+	return nil
+}
+
+// EncodeTagged returns IDString(id) prefixed with the layout code of the
+// preset with the worker's layout, so that DecodeTagged can tell which
+// layout it belongs to. Tagged strings of one layout sort in ID order, as
+// IDString does. It returns ErrUnknownLayoutCode if no preset with the
+// worker's layout has a code.
+func (w *Worker) EncodeTagged(id int64) (string, error) {
+	l := w.Layout()
+	for _, p := range Presets() {
+		if p.Code != 0 && p.Layout == l {
+			return string(p.Code) + w.IDString(id), nil
+		}
+	}
+	return "", fmt.Errorf("%w: none registered for layout %s",
+		ErrUnknownLayoutCode, w.layoutString())
+}
+
+// DecodeTagged parses a string from EncodeTagged with the layout registered
+// for its first character, and returns the name of that layout's preset. It
+// returns ErrUnknownLayoutCode if the code is not registered, a
+// *LengthError if the rest is not that layout's StringLength, and the other
+// errors of ParseIDString.
+func DecodeTagged(s string) (layoutName string, id int64, err error) {
+	if s == "" {
+		return "", 0, fmt.Errorf("%w: empty string", ErrMalformedID)
+	}
+	for _, p := range Presets() {
+		if p.Code == 0 || p.Code != s[0] {
+			continue
+		}
+		l, body := p.Layout, s[1:]
+		if n := l.StringLength(); len(body) != n {
+			return "", 0, &LengthError{
+				Expected: n,
+				Actual:   len(body),
+				Matches:  presetsWithLength(len(body)),
+			}
+		}
+		v, err := StringToInt(body, l.TotalBits())
+		if err != nil {
+			return "", 0, err
+		}
+		id := l.fromStringBits(v)
+		if err := l.Validate(id); err != nil {
+			return "", 0, err
+		}
+		return p.Name, id, nil
+	}
+	return "", 0, fmt.Errorf("%w: %q", ErrUnknownLayoutCode, s[0])
+}
@@ -0,0 +1,116 @@
+package sanic
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var registerCodes sync.Once
+
+// layoutCodes gives NewWorker10 and NewWorker9 their codes, once per test
+// binary as the registry is global.
+func layoutCodes(t *testing.T) {
+	t.Helper()
+	registerCodes.Do(func() {
+		for name, code := range map[string]byte{"NewWorker10": 'A', "NewWorker9": '9'} {
+			if err := RegisterLayoutCode(name, code); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestEncodeTagged(t *testing.T) {
+	layoutCodes(t)
+	for _, tc := range []struct {
+		w    *Worker
+		name string
+		code byte
+	}{
+		{NewWorker10(7), "NewWorker10", 'A'},
+		{NewWorker9(2), "NewWorker9", '9'},
+	} {
+		ids := make([]int64, 100)
+		tc.w.NextIDs(ids)
+		var strs []string
+		for _, id := range ids {
+			s, err := tc.w.EncodeTagged(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s[0] != tc.code || s[1:] != tc.w.IDString(id) {
+				t.Errorf("EncodeTagged(%d) = %q", id, s)
+			}
+			name, got, err := DecodeTagged(s)
+			if name != tc.name || got != id || err != nil {
+				t.Errorf("DecodeTagged(%q) = %q, %d, %v", s, name, got, err)
+			}
+			strs = append(strs, s)
+		}
+		if !slices.IsSorted(strs) {
+			t.Errorf("%s: tagged strings out of ID order", tc.name)
+		}
+	}
+	if _, err := NewWorker8().EncodeTagged(1); !errors.Is(err, ErrUnknownLayoutCode) {
+		t.Errorf("EncodeTagged without a code = %v", err)
+	}
+}
+
+func TestDecodeTaggedErrors(t *testing.T) {
+	layoutCodes(t)
+	w := NewWorker10(7)
+	s, _ := w.EncodeTagged(w.NextID())
+
+	var le *LengthError
+	if _, _, err := DecodeTagged(s[:len(s)-1]); !errors.As(err, &le) ||
+		le.Expected != w.StringLength() || le.Actual != w.StringLength()-1 {
+		t.Errorf("short string: %v", err)
+	}
+	// A NewWorker9 body behind NewWorker10's code hints at NewWorker9.
+	body := NewWorker9(1).IDString(NewWorker9(1).NextID())
+	if _, _, err := DecodeTagged("A" + body); !errors.As(err, &le) ||
+		!slices.Contains(le.Matches, "NewWorker9") {
+		t.Errorf("other layout's body: %v", err)
+	}
+	for _, tc := range []struct {
+		s    string
+		want error
+	}{
+		{"", ErrMalformedID},
+		{"!" + s[1:], ErrUnknownLayoutCode},
+		{"z" + s[1:], ErrUnknownLayoutCode},
+		{"A" + strings.Repeat("-", w.StringLength()), ErrInvalidID},
+		{"A" + strings.Repeat("*", w.StringLength()), ErrMalformedID},
+	} {
+		if _, _, err := DecodeTagged(tc.s); !errors.Is(err, tc.want) {
+			t.Errorf("DecodeTagged(%q) = %v, want %v", tc.s, err, tc.want)
+		}
+	}
+}
+
+func TestRegisterLayoutCode(t *testing.T) {
+	layoutCodes(t)
+	for _, tc := range []struct {
+		name string
+		code byte
+		want string
+	}{
+		{"NewWorker8", '!', "not in the alphabet"},
+		{"NewWorker8", 'A', `already registered for "NewWorker10"`},
+		{"NewWorker10", 'y', "already has layout code"},
+		{"NoSuchPreset", 'y', "no preset"},
+	} {
+		err := RegisterLayoutCode(tc.name, tc.code)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("RegisterLayoutCode(%q, %q) = %v, want %q", tc.name, tc.code, err, tc.want)
+		}
+	}
+	for _, p := range Presets() {
+		if p.Name == "NewWorker8" && p.Code != 0 {
+			t.Errorf("a rejected code was registered: %q", p.Code)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package sanic
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// ParseLegacyIDString parses s in the string format IDString produced
+// before the sortable alphabet: the ID's bytes, little-endian and cut to
+// the bytes TotalBits needs, in unpadded URL-safe base64, cut to
+// StringLength characters. Strings in that format are the same length as,
+// and use the same characters as, the current one, so ParseIDString accepts
+// them but decodes them to different IDs; stored strings must be parsed
+// with this function and re-encoded with IDString to migrate them.
+//
+// The final cut dropped the top few timestamp bits for most layouts, up to
+// three for NewWorker10. They are restored as the latest time not after the
+// worker's clock, so strings older than the span of the dropped bits, about
+// 8.7 years for NewWorker10 and 2.7 for NewWorker9, come back too late by a
+// multiple of it. It
+// returns a *LengthError and ErrMalformedID as ParseIDString does, and
+// ErrInvalidID if no restored ID fits the layout at or before the clock.
+func (w *Worker) ParseLegacyIDString(s string) (int64, error) {
+	if n := w.StringLength(); len(s) != n {
+		return 0, &LengthError{
+			Expected: n,
+			Actual:   len(s),
+			Matches:  presetsWithLength(len(s)),
+		}
+	}
+	nBytes := int(w.TotalBits+7) / 8
+	padded := s
+	if base64.RawURLEncoding.EncodedLen(nBytes) > len(s) {
+		padded += "A" // the dropped character, as zero bits
+	}
+	var buf [8]byte
+	n, err := base64.RawURLEncoding.Decode(buf[:], []byte(padded))
+	if err != nil || n != nBytes {
+		return 0, fmt.Errorf("%w: %q is not a legacy ID string", ErrMalformedID, s)
+	}
+	v := int64(binary.LittleEndian.Uint64(buf[:]))
+
+	lost := legacyLostBits(w.TotalBits, len(s))
+	now := w.Time()
+	found, best := false, int64(0)
+	// Try every value of the lost bits, keeping the latest valid one.
+	for sub := lost; ; sub = (sub - 1) & lost {
+		c := v&^lost | sub
+		if w.Validate(c) == nil && w.Decompose(c).Tick <= now && (!found || c > best) {
+			found, best = true, c
+		}
+		if sub == 0 {
+			break
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("%w: legacy string %q", ErrInvalidID, s)
+	}
+	return best, nil
+}
+
+// legacyLostBits returns the bits of an ID that the legacy string format,
+// kept to chars characters, did not hold, among the TotalBits-1 that can be
+// set.
+func legacyLostBits(totalBits uint64, chars int) int64 {
+	nBytes := int(totalBits+7) / 8
+	var lost uint64
+	// Bit p of the base64 input is bit 7-p%8 of byte p/8, and byte k holds
+	// bits 8k to 8k+7 of the little-endian ID.
+	for p := 6 * chars; p < 8*nBytes; p++ {
+		lost |= 1 << (8*(p/8) + 7 - p%8)
+	}
+	return int64(lost & (1<<(totalBits-1) - 1))
+}
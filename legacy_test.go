@@ -0,0 +1,60 @@
+package sanic
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// legacyIDString is IDString as it was before the sortable alphabet.
+func legacyIDString(id int64, totalBits uint64) string {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(id))
+	s := base64.RawURLEncoding.EncodeToString(buf[:(totalBits+7)/8])
+	return s[:min(len(s), int(totalBits/6))]
+}
+
+func TestParseLegacyReadme(t *testing.T) {
+	// The example from the README before the format changed.
+	w := NewWorker7()
+	id, err := w.ParseLegacyIDString("AUBwOwE")
+	if err != nil || id != 5292179457 {
+		t.Fatalf("ParseLegacyIDString = %d, %v, want 5292179457", id, err)
+	}
+	if s := w.IDString(id); s != "-3vR3-0" {
+		t.Errorf("IDString = %q, want the README's -3vR3-0", s)
+	}
+}
+
+func TestParseLegacyRoundTrip(t *testing.T) {
+	for _, w := range []*Worker{NewWorker10(63), NewWorker9(3), NewWorker8(), NewWorker7()} {
+		for _, age := range []time.Duration{0, time.Hour, 400 * 24 * time.Hour} {
+			l := w.Layout()
+			tick := l.tick(time.Now().Add(-age))
+			id := (tick-l.CustomEpoch)<<l.timeStampShift() | (1<<w.IDBits-1)<<w.SequenceBits | 5
+			got, err := w.ParseLegacyIDString(legacyIDString(id, w.TotalBits))
+			if err != nil || got != id {
+				t.Errorf("%v, %v old: got %d, %v, want %d", w, age, got, err, id)
+			}
+		}
+	}
+}
+
+func TestParseLegacyErrors(t *testing.T) {
+	w := NewWorker10(1)
+	var lerr *LengthError
+	if _, err := w.ParseLegacyIDString("short"); !errors.As(err, &lerr) {
+		t.Errorf("short string: %v", err)
+	}
+	if _, err := w.ParseLegacyIDString("!!!!!!!!!!"); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("bad characters: %v", err)
+	}
+	// A time after the worker's clock, here its epoch, cannot be restored.
+	w.TimeFunc = func() time.Time { return w.Epoch() }
+	id := int64(1) << w.TimeStampShift
+	if _, err := w.ParseLegacyIDString(legacyIDString(id, w.TotalBits)); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("future ID: %v", err)
+	}
+}
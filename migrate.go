@@ -0,0 +1,46 @@
+package sanic
+
+import "time"
+
+// MigrationComparator returns a comparison function, for use with
+// slices.SortFunc, over a mix of IDs from two layouts. classify reports
+// whether an ID belongs to next; otherwise it is decoded with old.
+//
+// IDs are ordered by their decoded creation time, which is only as precise
+// as each layout's Frequency. Ties are broken by putting old IDs first, then
+// by numeric value.
+func MigrationComparator(old, next *Worker, classify func(int64) bool) func(a, b int64) int {
+	oldLayout, nextLayout := old.Layout(), next.Layout()
+	return func(a, b int64) int {
+		aNext, bNext := classify(a), classify(b)
+		at, bt := oldLayout.Timestamp(a), oldLayout.Timestamp(b)
+		if aNext {
+			at = nextLayout.Timestamp(a)
+		}
+		if bNext {
+			bt = nextLayout.Timestamp(b)
+		}
+		switch {
+		case at.Before(bt):
+			return -1
+		case at.After(bt):
+			return 1
+		case !aNext && bNext:
+			return -1
+		case aNext && !bNext:
+			return 1
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	}
+}
+
+// CutoverIDs returns the smallest ID each layout can hold in the tick
+// containing the migration instant at. IDs from ticks before it are below
+// these values, and IDs from that tick on are at or above them.
+func CutoverIDs(old, next *Worker, at time.Time) (oldID, nextID int64) {
+	return old.Layout().FirstID(at), next.Layout().FirstID(at)
+}
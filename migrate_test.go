@@ -0,0 +1,73 @@
+package sanic
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMigrationComparator(t *testing.T) {
+	old, next := NewWorker8(), NewWorker10(1)
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	isNext := map[int64]bool{}
+	oldID := func(d time.Duration) int64 {
+		return old.compose(old.Layout().tick(at.Add(d)), 0, 0)
+	}
+	nextID := func(d time.Duration, seq int64) int64 {
+		id := next.compose(next.Layout().tick(at.Add(d)), 0, seq)
+		isNext[id] = true
+		return id
+	}
+
+	// Old IDs stop after the cutover and new ones start a little before it,
+	// so the two kinds overlap. Each old ID's time is a whole 100ms tick.
+	want := []int64{
+		oldID(-400 * time.Millisecond),
+		nextID(-350*time.Millisecond, 0),
+		oldID(-300 * time.Millisecond),
+		nextID(-300*time.Millisecond, 0), // tie: old first
+		nextID(-300*time.Millisecond, 1), // tie: by value
+		nextID(-1*time.Millisecond, 0),
+		oldID(0),
+		nextID(0, 0),
+		nextID(50*time.Millisecond, 0),
+		oldID(100 * time.Millisecond),
+		nextID(101*time.Millisecond, 0),
+	}
+	cmp := MigrationComparator(old, next, func(id int64) bool { return isNext[id] })
+
+	for range 20 {
+		got := slices.Clone(want)
+		rand.Shuffle(len(got), func(i, j int) { got[i], got[j] = got[j], got[i] })
+		slices.SortFunc(got, cmp)
+		if !slices.Equal(got, want) {
+			t.Fatalf("merged order\n%v\nwant\n%v", got, want)
+		}
+	}
+	if cmp(want[3], want[3]) != 0 {
+		t.Error("an ID does not compare equal to itself")
+	}
+}
+
+func TestCutoverIDs(t *testing.T) {
+	old, next := NewWorker8(), NewWorker10(1)
+	at := time.Date(2024, 6, 1, 12, 0, 0, 50e6, time.UTC)
+	oldCut, nextCut := CutoverIDs(old, next, at)
+	for _, c := range []struct {
+		w   *Worker
+		cut int64
+	}{{old, oldCut}, {next, nextCut}} {
+		l := c.w.Layout()
+		tick := l.tick(at)
+		if last := c.w.compose(tick-1, 0, l.MaxSequence()); last >= c.cut {
+			t.Errorf("%s: last ID before the cutover tick %d >= %d", c.w, last, c.cut)
+		}
+		if first := c.w.compose(tick, 0, 0); first < c.cut {
+			t.Errorf("%s: first ID of the cutover tick %d < %d", c.w, first, c.cut)
+		}
+		if !l.Timestamp(c.cut).Equal(l.tickTime(tick)) {
+			t.Errorf("%s: cutover decodes to %s", c.w, l.Timestamp(c.cut))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sanic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// objectIDLen is the length of a MongoDB ObjectID in bytes.
+const objectIDLen = 12
+
+// ToObjectIDHex returns id in the shape of a MongoDB ObjectID, as 24
+// lowercase hex digits. The first 4 bytes hold the Unix seconds of id's
+// timestamp, as an ObjectID's do, and the last 8 hold id itself, so the
+// mapping is reversible and ObjectIDs made from one worker's IDs sort in
+// the same order as the IDs.
+func (w *Worker) ToObjectIDHex(id int64) string {
+	var b [objectIDLen]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(w.Timestamp(id).Unix()))
+	binary.BigEndian.PutUint64(b[4:], uint64(id))
+	return hex.EncodeToString(b[:])
+}
+
+// FromObjectIDHex returns the creation time stored in the first 4 bytes of
+// a hex ObjectID, to the second. It returns ErrMalformedID unless s is 24
+// hex digits.
+func FromObjectIDHex(s string) (time.Time, error) {
+	b, err := parseObjectID(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return objectIDTime(b), nil
+}
+
+func parseObjectID(s string) ([objectIDLen]byte, error) {
+	var b [objectIDLen]byte
+	if len(s) != 2*objectIDLen {
+		return b, fmt.Errorf("%w: %q is not a %d digit ObjectID",
+			ErrMalformedID, s, 2*objectIDLen)
+	}
+	if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+		return b, fmt.Errorf("%w: %q is not a %d digit ObjectID",
+			ErrMalformedID, s, 2*objectIDLen)
+	}
+	return b, nil
+}
+
+func objectIDTime(b [objectIDLen]byte) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(b[:4])), 0).UTC()
+}
+
+// Migrator mints IDs for existing ObjectID-keyed records, stamped with each
+// ObjectID's creation time, such that the IDs sort in the same order as the
+// ObjectIDs. The ObjectIDs must be given in ascending order, as a scan of a
+// collection by _id returns them. A Migrator is not safe for concurrent use.
+//
+// Records created in the same second share that second's first tick, with
+// increasing sequence numbers; if a second holds more records than the
+// sequence allows, later ones spill into the following ticks. A Migrator
+// keeps its own state rather than sharing the worker's, so it should be
+// given a worker ID that no live worker uses for the migrated time range.
+type Migrator struct {
+	w        *Worker
+	last     [objectIDLen]byte
+	started  bool
+	tick     int64
+	sequence int64
+}
+
+// NewMigrator returns a Migrator minting IDs with w's layout and worker ID.
+func NewMigrator(w *Worker) *Migrator {
+	return &Migrator{w: w}
+}
+
+// Mint returns the ID for the record with the given hex ObjectID. It
+// returns ErrMalformedID for malformed hex, ErrOutOfOrder if objectID is
+// not greater than the previous one, and ErrClockBeforeEpoch or
+// ErrEpochExhausted if its time does not fit the layout.
+func (m *Migrator) Mint(objectID string) (int64, error) {
+	b, err := parseObjectID(objectID)
+	if err != nil {
+		return 0, err
+	}
+	if m.started && bytes.Compare(b[:], m.last[:]) <= 0 {
+		return 0, fmt.Errorf("%w: ObjectID %s does not follow %x",
+			ErrOutOfOrder, objectID, m.last)
+	}
+
+	w := m.w
+	l := w.Layout()
+	t := objectIDTime(b)
+	tick := l.tick(t)
+	next, sequence := tick, int64(0)
+	switch {
+	case !m.started || tick > m.tick:
+	case m.sequence < w.MaxSequence:
+		next, sequence = m.tick, m.sequence+1
+	default:
+		next = m.tick + 1
+	}
+	if next < w.CustomEpoch {
+		return 0, fmt.Errorf("%w: ObjectID %s is from %s, epoch is %s",
+			ErrClockBeforeEpoch, objectID, t, l.Epoch())
+	}
+	if next-w.CustomEpoch > w.MaxTimeStamp {
+		return 0, fmt.Errorf("%w: ObjectID %s is from %s, layout exhausted at %s",
+			ErrEpochExhausted, objectID, t, l.Exhausts())
+	}
+	m.tick, m.sequence = next, sequence
+	m.last, m.started = b, true
+	return w.compose(m.tick, 0, m.sequence), nil
+}
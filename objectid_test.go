@@ -0,0 +1,113 @@
+package sanic
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// objectIDs returns n ascending ObjectIDs shaped like MongoDB's: a Unix
+// seconds timestamp, 5 random bytes per process and a 3-byte counter,
+// spread over a few seconds from start.
+func objectIDs(start time.Time, n int) []string {
+	process := rand.Uint64()
+	counter := rand.Uint32() & 0xffffff
+	ids := make([]string, n)
+	for i := range ids {
+		var b [objectIDLen]byte
+		binary.BigEndian.PutUint32(b[:4], uint32(start.Unix())+uint32(i*3/n))
+		binary.BigEndian.PutUint64(b[4:], process<<24|uint64(counter))
+		counter = (counter + 1) & 0xffffff
+		ids[i] = hex.EncodeToString(b[:])
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestMigratorOrder(t *testing.T) {
+	start := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	oids := objectIDs(start, 3000)
+	m := NewMigrator(NewWorker10(9))
+	var last int64
+	for _, oid := range oids {
+		id, err := m.Mint(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("ObjectID %s minted %d, not after %d", oid, id, last)
+		}
+		last = id
+		want, _ := FromObjectIDHex(oid)
+		if got := NewWorker10(0).Timestamp(id); got.Before(want) || got.Sub(want) >= time.Second {
+			t.Fatalf("ObjectID from %s minted an ID from %s", want, got)
+		}
+	}
+	if _, err := m.Mint(oids[0]); !errors.Is(err, ErrOutOfOrder) {
+		t.Errorf("repeated ObjectID: %v", err)
+	}
+}
+
+// A second with more records than the sequence holds spills into the
+// following ticks.
+func TestMigratorSpill(t *testing.T) {
+	w := NewWorker7()
+	start := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	m := NewMigrator(w)
+	var b [objectIDLen]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(start.Unix()))
+	var last int64
+	for i := range w.MaxSequence + 3 {
+		binary.BigEndian.PutUint64(b[4:], uint64(i))
+		id, err := m.Mint(hex.EncodeToString(b[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("record %d minted %d, not after %d", i, id, last)
+		}
+		last = id
+	}
+	if p := w.Decompose(last); !p.Time.Equal(start.Add(time.Second)) || p.Sequence != 1 {
+		t.Errorf("last record at %s sequence %d", p.Time, p.Sequence)
+	}
+}
+
+func TestObjectIDHex(t *testing.T) {
+	w := NewWorker10(9)
+	ids := make([]int64, 1000)
+	w.NextIDs(ids)
+	for i, id := range ids {
+		s := w.ToObjectIDHex(id)
+		if len(s) != 24 {
+			t.Fatalf("ToObjectIDHex(%d) = %q", id, s)
+		}
+		got, err := FromObjectIDHex(s)
+		if err != nil || !got.Equal(w.Timestamp(id).Truncate(time.Second)) {
+			t.Errorf("FromObjectIDHex(%q) = %s, %v", s, got, err)
+		}
+		if i > 0 && s <= w.ToObjectIDHex(ids[i-1]) {
+			t.Fatalf("ObjectIDs of %d and %d out of order", ids[i-1], id)
+		}
+	}
+}
+
+func TestObjectIDMalformed(t *testing.T) {
+	m := NewMigrator(NewWorker10(9))
+	for _, s := range []string{"", "64564ad9", "64564ad9000000000000000g", "64564ad90000000000000000aa"} {
+		if _, err := FromObjectIDHex(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("FromObjectIDHex(%q) = %v", s, err)
+		}
+		if _, err := m.Mint(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("Mint(%q) = %v", s, err)
+		}
+	}
+	// 2009 is before NewWorker10's epoch.
+	if _, err := m.Mint("4a0000000000000000000000"); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Errorf("ObjectID before the epoch: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package sanic
+
+import "context"
+
+// PausePolicy says what generation does while a worker is paused.
+type PausePolicy int
+
+const (
+	// PauseBlock makes generation wait for Resume.
+	PauseBlock PausePolicy = iota
+	// PauseError makes generation that can fail return ErrPaused at once.
+	// NextID and NextIDs cannot report errors, so they still wait.
+	PauseError
+)
+
+// Pause stops the worker from issuing IDs until Resume. Once Pause returns,
+// no ID is generated until Resume has returned nil, so no ID is stamped with
+// a tick inside the paused window. Pause has no effect on UnsafeNextID.
+func (w *Worker) Pause() {
+	w.mutex.Lock()
+	w.paused = true
+	w.mutex.Unlock()
+}
+
+// Resume lets a paused worker issue IDs again. It first re-runs the clock
+// checks Warmup does, other than MaxClockJump, since the clock is expected
+// to have moved on; if they fail the worker stays paused and the error is
+// returned. Callers that blocked while paused are released in the order
+// they arrived. A closed worker cannot be resumed: Resume returns
+// ErrClosed.
+func (w *Worker) Resume() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return ErrClosed
+	}
+	if !w.paused {
+		return nil
+	}
+	if err := w.checkClock(w.Time(), 0); err != nil {
+		return err
+	}
+	w.paused = false
+	w.releaseNext()
+	return nil
+}
+
+// Paused reports whether Pause has been called without a successful Resume,
+// on a worker that is not closed.
+func (w *Worker) Paused() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.paused && !w.closed
+}
+
+// NextIDContext is NextID for callers that can handle failure. It returns
+// ErrUninitialized for a Worker not made by a constructor, ErrPaused while
+// paused under PauseError, ErrNoTick when a tick-driven worker under
+// TickError would wait for a tick, ErrClosed after Close, ctx's error if
+// ctx is done while it waits for Resume, and ErrEpochExhausted once every
+// tick of the layout has been used. Generation that cannot fail blocks
+// forever in the last case, calling OnEpochExhausted first.
+func (w *Worker) NextIDContext(ctx context.Context) (int64, error) {
+	if err := w.checkInitialized(); err != nil {
+		return 0, err
+	}
+
+	w.mutex.Lock()
+	if err := w.waitTurn(ctx, true); err != nil {
+		w.mutex.Unlock()
+		return 0, err
+	}
+	if err := w.checkTick(); err != nil {
+		w.mutex.Unlock()
+		return 0, err
+	}
+	hook := w.OnAnomaly
+	if hook != nil {
+		w.gen, w.timeWaits = GenInfo{}, true
+	}
+	id, tick, err := w.tryGenerate(ctx, 0)
+	info := w.gen
+	w.timeWaits = false
+	w.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
+	}
+	if hook != nil && (info.RolledOver || info.ClockRegressed) {
+		info.Tick = tick
+		hook(ctx, info)
+	}
+	return id, nil
+}
+
+// waitTurn returns once the worker is not paused and every caller that
+// started waiting earlier has generated its ID. If canFail is set it returns
+// ErrPaused instead of waiting under PauseError. w.mutex must be held, and
+// is held again when waitTurn returns, though it is released while waiting.
+//
+// Waiters queue in pauseQueue in arrival order. Only the front of the queue
+// is ever signalled, by closing its channel, and it signals the next one
+// before generating, so IDs are issued in queue order.
+func (w *Worker) waitTurn(ctx context.Context, canFail bool) error {
+	if !w.paused && len(w.pauseQueue) == 0 {
+		return nil
+	}
+	return w.queueTurn(ctx, canFail)
+}
+
+// queueTurn is the slow path of waitTurn, kept apart so that waitTurn is
+// inlined into every generating call.
+func (w *Worker) queueTurn(ctx context.Context, canFail bool) error {
+	if w.closed {
+		return w.closedTurn(canFail)
+	}
+	if canFail && w.paused && w.PausePolicy == PauseError {
+		return ErrPaused
+	}
+
+	ch := make(chan struct{})
+	w.pauseQueue = append(w.pauseQueue, ch)
+	for {
+		w.mutex.Unlock()
+		var err error
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		w.mutex.Lock()
+
+		signalled := false
+		select {
+		case <-ch:
+			signalled = true
+		default:
+		}
+		if err != nil {
+			w.dequeue(ch)
+			if signalled {
+				w.releaseNext()
+			}
+			return err
+		}
+		if w.closed {
+			w.dequeue(ch)
+			w.releaseNext()
+			return w.closedTurn(canFail)
+		}
+		if w.paused {
+			// Paused again between the signal and taking the mutex; keep
+			// our place at the front and wait for the next Resume.
+			ch = make(chan struct{})
+			w.pauseQueue[0] = ch
+			continue
+		}
+		w.dequeue(ch)
+		w.releaseNext()
+		return nil
+	}
+}
+
+// closedTurn returns ErrClosed, or panics with it after unlocking w.mutex if
+// canFail is not set.
+func (w *Worker) closedTurn(canFail bool) error {
+	if !canFail {
+		w.mutex.Unlock()
+		panic(ErrClosed)
+	}
+	return ErrClosed
+}
+
+// dequeue removes ch from pauseQueue. w.mutex must be held.
+func (w *Worker) dequeue(ch chan struct{}) {
+	for i, c := range w.pauseQueue {
+		if c == ch {
+			w.pauseQueue = append(w.pauseQueue[:i], w.pauseQueue[i+1:]...)
+			return
+		}
+	}
+}
+
+// releaseNext signals the front of pauseQueue, unless the worker is paused
+// and not closed. w.mutex must be held.
+func (w *Worker) releaseNext() {
+	if w.paused && !w.closed || len(w.pauseQueue) == 0 {
+		return
+	}
+	select {
+	case <-w.pauseQueue[0]:
+		// Already signalled by an earlier Resume.
+	default:
+		close(w.pauseQueue[0])
+	}
+}
@@ -0,0 +1,138 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// queued waits until n callers are waiting for w's Resume.
+func queued(w *Worker, n int) {
+	for {
+		w.mutex.Lock()
+		l := len(w.pauseQueue)
+		w.mutex.Unlock()
+		if l >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPauseReleasesInOrder(t *testing.T) {
+	w := NewWorker10(5)
+	w.Pause()
+	if !w.Paused() {
+		t.Fatal("not paused after Pause")
+	}
+	const n = 8
+	results := make([]chan int64, n)
+	for i := range results {
+		results[i] = make(chan int64, 1)
+		go func() {
+			if i%2 == 0 {
+				results[i] <- w.NextID()
+				return
+			}
+			id, err := w.NextIDContext(context.Background())
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] <- id
+		}()
+		queued(w, i+1)
+	}
+	if !blocked(results[0]) {
+		t.Fatal("NextID returned while paused")
+	}
+	if err := w.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Paused() {
+		t.Error("paused after Resume")
+	}
+	var last int64
+	for i, ch := range results {
+		id := <-ch
+		if id <= last {
+			t.Errorf("caller %d got %d, not after %d", i, id, last)
+		}
+		last = id
+	}
+}
+
+func TestPauseError(t *testing.T) {
+	w := NewWorker10(5)
+	w.PausePolicy = PauseError
+	w.Pause()
+	if _, err := w.NextIDContext(context.Background()); !errors.Is(err, ErrPaused) {
+		t.Errorf("NextIDContext = %v, want ErrPaused", err)
+	}
+	if _, err := w.NextIDTagged(0); !errors.Is(err, ErrPaused) {
+		t.Errorf("NextIDTagged = %v, want ErrPaused", err)
+	}
+	// NextID cannot fail, so it still waits.
+	ch := make(chan int64, 1)
+	go func() { ch <- w.NextID() }()
+	if !blocked(ch) {
+		t.Fatal("NextID returned while paused")
+	}
+	w.Resume()
+	<-ch
+}
+
+func TestPauseContext(t *testing.T) {
+	w := NewWorker10(5)
+	w.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := w.NextIDContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("NextIDContext = %v, want the context's error", err)
+	}
+	// The abandoned caller leaves the queue, so Resume releases the next.
+	if err := w.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	w.NextID()
+}
+
+func TestPauseWindow(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	w.NextID()
+	w.Pause()
+	ch := make(chan int64, 1)
+	go func() { ch <- w.NextID() }()
+	queued(w, 1)
+	c.set(tick + 10)
+	if !blocked(ch) {
+		t.Fatal("NextID returned while paused")
+	}
+
+	// The clock checks run again, and a failing one keeps the worker paused.
+	c.set(tick - 1)
+	if err := w.Resume(); !errors.Is(err, ErrClockBackwards) || !w.Paused() {
+		t.Fatalf("Resume with the clock behind = %v, paused %v", err, w.Paused())
+	}
+	c.set(tick + 20)
+	if err := w.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if p := w.Decompose(<-ch); p.Tick != tick+20 {
+		t.Errorf("first ID after Resume at tick +%d, want +20", p.Tick-tick)
+	}
+}
+
+func TestResumeClosed(t *testing.T) {
+	w := NewWorker10(5)
+	w.Pause()
+	w.Close()
+	if err := w.Resume(); !errors.Is(err, ErrClosed) || w.Paused() {
+		t.Errorf("Resume after Close = %v, paused %v", err, w.Paused())
+	}
+	if err := NewWorker10(5).Resume(); err != nil {
+		t.Errorf("Resume without Pause = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package sanic
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PrefixSeparator separates the prefix from the ID in PrefixedString.
+const PrefixSeparator = '-'
+
+// PrefixedString returns id as prefix, PrefixSeparator, then IDString(id),
+// as in "evt-4Kp9QzAb12".
+func (w *Worker) PrefixedString(prefix string, id int64) string {
+	return prefix + string(PrefixSeparator) + w.IDString(id)
+}
+
+// ParsePrefixed parses a string from PrefixedString. Surrounding whitespace
+// is ignored, and the prefix and separator may be left off entirely.
+//
+// The ID itself is always the last StringLength characters, so it may
+// contain PrefixSeparator. ParsePrefixed returns ErrWrongPrefix if anything
+// other than prefix and the separator comes before it, and the errors of
+// ParseIDString if the ID is malformed.
+func (w *Worker) ParsePrefixed(prefix, s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	n := w.StringLength()
+	if len(s) < n {
+		return w.ParseIDString(s)
+	}
+	head, body := s[:len(s)-n], s[len(s)-n:]
+	if head != "" && head != prefix+string(PrefixSeparator) {
+		return 0, fmt.Errorf("%w: %q, want %q", ErrWrongPrefix, head,
+			prefix+string(PrefixSeparator))
+	}
+	return w.ParseIDString(body)
+}
+
+// Prefixes routes prefixed ID strings to the worker registered for their
+// prefix, so one entry point can parse IDs of several layouts.
+type Prefixes map[string]*Worker
+
+// Register adds w for prefix. The prefix must be non-empty and must not
+// contain PrefixSeparator or whitespace, and may be registered only once.
+func (p Prefixes) Register(prefix string, w *Worker) error {
+	if prefix == "" || strings.ContainsRune(prefix, PrefixSeparator) ||
+		strings.ContainsFunc(prefix, unicode.IsSpace) {
+		return fmt.Errorf("sanic: invalid prefix %q", prefix)
+	}
+	if _, ok := p[prefix]; ok {
+		return fmt.Errorf("sanic: prefix %q already registered", prefix)
+	}
+	p[prefix] = w
+	return nil
+}
+
+// Parse parses s with the worker registered for its prefix. It returns
+// ErrWrongPrefix if s has no prefix or an unregistered one.
+func (p Prefixes) Parse(s string) (prefix string, id int64, err error) {
+	s = strings.TrimSpace(s)
+	prefix, _, ok := strings.Cut(s, string(PrefixSeparator))
+	w := p[prefix]
+	if !ok || w == nil {
+		return "", 0, fmt.Errorf("%w: %q", ErrWrongPrefix, s)
+	}
+	id, err = w.ParsePrefixed(prefix, s)
+	if err != nil {
+		return "", 0, err
+	}
+	return prefix, id, nil
+}
@@ -0,0 +1,56 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrefixedString(t *testing.T) {
+	w := NewWorker10(1)
+	id := w.NextID()
+	s := w.PrefixedString("evt", id)
+	if want := "evt-" + w.IDString(id); s != want {
+		t.Fatalf("PrefixedString = %q, want %q", s, want)
+	}
+	for _, in := range []string{s, "  " + s + "\n", w.IDString(id), " " + w.IDString(id)} {
+		if got, err := w.ParsePrefixed("evt", in); err != nil || got != id {
+			t.Errorf("ParsePrefixed(%q) = %d, %v, want %d", in, got, err, id)
+		}
+	}
+	if _, err := w.ParsePrefixed("evt", "usr-"+w.IDString(id)); !errors.Is(err, ErrWrongPrefix) {
+		t.Errorf("wrong prefix: %v", err)
+	}
+	if _, err := w.ParsePrefixed("evt", "evt"+w.IDString(id)); !errors.Is(err, ErrWrongPrefix) {
+		t.Errorf("missing separator: %v", err)
+	}
+	if _, err := w.ParsePrefixed("evt", "evt-"+w.IDString(id)[1:]+"."); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("malformed body: %v", err)
+	}
+}
+
+func TestPrefixesRoute(t *testing.T) {
+	events, users := NewWorker10(1), NewWorker9(2)
+	p := Prefixes{}
+	if err := p.Register("evt", events); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Register("usr", users); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"", "a-b", "a b", "evt"} {
+		if err := p.Register(bad, events); err == nil {
+			t.Errorf("Register(%q) accepted", bad)
+		}
+	}
+
+	e, u := events.NextID(), users.NextID()
+	if prefix, id, err := p.Parse(events.PrefixedString("evt", e)); err != nil || prefix != "evt" || id != e {
+		t.Errorf("evt: %q, %d, %v", prefix, id, err)
+	}
+	if prefix, id, err := p.Parse(" " + users.PrefixedString("usr", u)); err != nil || prefix != "usr" || id != u {
+		t.Errorf("usr: %q, %d, %v", prefix, id, err)
+	}
+	if _, _, err := p.Parse("ord-" + events.IDString(e)); !errors.Is(err, ErrWrongPrefix) {
+		t.Errorf("unregistered prefix: %v", err)
+	}
+}
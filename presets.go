@@ -0,0 +1,226 @@
+// Code generated by gen_presets.go; DO NOT EDIT.
+
+package sanic
+
+// Easy Worker generation given an ID and using a default configuration
+// with a custom epoch of "2016-01-01 00:00:00 +0000 UTC"
+
+// Derived constants for NewWorker10.
+const (
+	worker10IDBits         = 6
+	worker10SequenceBits   = 12
+	worker10TimeStampBits  = 41
+	worker10IDShift        = 12
+	worker10TagShift       = 12
+	worker10TimeStampShift = 18
+	worker10TotalBits      = 60
+	worker10MaxID          = 63
+	worker10MaxSequence    = 4095
+	worker10MaxTimeStamp   = 2199023255551
+	worker10StringLength   = 10
+	worker10Frequency      = 1000000 // 1ms
+	worker10CustomEpoch    = 1451606400000
+	worker10Exhausts       = "2085-09-06T15:47:35.552Z"
+)
+
+// NewWorker10 will generate up to 4096000 unique ids/second for 69 years
+// NewWorker10 will return nil if the ID is greater than 63 or less than 0
+func NewWorker10(id int64) *Worker {
+	if id > worker10MaxID || id < 0 {
+		return nil
+	}
+	w := &Worker{
+		ID:             id,
+		IDBits:         worker10IDBits,
+		IDShift:        worker10IDShift,
+		TagShift:       worker10TagShift,
+		SequenceBits:   worker10SequenceBits,
+		SequenceMask:   worker10MaxSequence,
+		TimeStampBits:  worker10TimeStampBits,
+		TimeStampShift: worker10TimeStampShift,
+		Frequency:      worker10Frequency,
+		TotalBits:      worker10TotalBits,
+		CustomEpoch:    worker10CustomEpoch,
+		MaxWorkerID:    worker10MaxID,
+		MaxSequence:    worker10MaxSequence,
+		MaxTimeStamp:   worker10MaxTimeStamp,
+	}
+	w.start()
+	return w
+}
+
+// Derived constants for NewWorker9.
+const (
+	worker9IDBits         = 2
+	worker9SequenceBits   = 13
+	worker9TimeStampBits  = 38
+	worker9IDShift        = 13
+	worker9TagShift       = 13
+	worker9TimeStampShift = 15
+	worker9TotalBits      = 54
+	worker9MaxID          = 3
+	worker9MaxSequence    = 8191
+	worker9MaxTimeStamp   = 274877906943
+	worker9StringLength   = 9
+	worker9Frequency      = 10000000 // 10ms
+	worker9CustomEpoch    = 145160640000
+	worker9Exhausts       = "2103-02-08T13:44:29.44Z"
+)
+
+// NewWorker9 will generate up to 819200 unique ids/second for 87 years
+// NewWorker9 will return nil if the ID is greater than 3 or less than 0
+func NewWorker9(id int64) *Worker {
+	if id > worker9MaxID || id < 0 {
+		return nil
+	}
+	w := &Worker{
+		ID:             id,
+		IDBits:         worker9IDBits,
+		IDShift:        worker9IDShift,
+		TagShift:       worker9TagShift,
+		SequenceBits:   worker9SequenceBits,
+		SequenceMask:   worker9MaxSequence,
+		TimeStampBits:  worker9TimeStampBits,
+		TimeStampShift: worker9TimeStampShift,
+		Frequency:      worker9Frequency,
+		TotalBits:      worker9TotalBits,
+		CustomEpoch:    worker9CustomEpoch,
+		MaxWorkerID:    worker9MaxID,
+		MaxSequence:    worker9MaxSequence,
+		MaxTimeStamp:   worker9MaxTimeStamp,
+	}
+	w.start()
+	return w
+}
+
+// Derived constants for NewWorker8.
+const (
+	worker8IDBits         = 0
+	worker8SequenceBits   = 13
+	worker8TimeStampBits  = 34
+	worker8IDShift        = 13
+	worker8TagShift       = 13
+	worker8TimeStampShift = 13
+	worker8TotalBits      = 48
+	worker8MaxID          = 0
+	worker8MaxSequence    = 8191
+	worker8MaxTimeStamp   = 17179869183
+	worker8StringLength   = 8
+	worker8Frequency      = 100000000 // 100ms
+	worker8CustomEpoch    = 14516064000
+	worker8Exhausts       = "2070-06-10T02:35:18.4Z"
+)
+
+// NewWorker8 will generate up to 81920 unique ids/second for 54 years
+// NewWorker8 is the only worker of its size with this configuration
+func NewWorker8() *Worker {
+	const id = 0
+	w := &Worker{
+		ID:             id,
+		IDBits:         worker8IDBits,
+		IDShift:        worker8IDShift,
+		TagShift:       worker8TagShift,
+		SequenceBits:   worker8SequenceBits,
+		SequenceMask:   worker8MaxSequence,
+		TimeStampBits:  worker8TimeStampBits,
+		TimeStampShift: worker8TimeStampShift,
+		Frequency:      worker8Frequency,
+		TotalBits:      worker8TotalBits,
+		CustomEpoch:    worker8CustomEpoch,
+		MaxWorkerID:    worker8MaxID,
+		MaxSequence:    worker8MaxSequence,
+		MaxTimeStamp:   worker8MaxTimeStamp,
+	}
+	w.start()
+	return w
+}
+
+// Derived constants for NewWorker7.
+const (
+	worker7IDBits         = 0
+	worker7SequenceBits   = 10
+	worker7TimeStampBits  = 31
+	worker7IDShift        = 10
+	worker7TagShift       = 10
+	worker7TimeStampShift = 10
+	worker7TotalBits      = 42
+	worker7MaxID          = 0
+	worker7MaxSequence    = 1023
+	worker7MaxTimeStamp   = 2147483647
+	worker7StringLength   = 7
+	worker7Frequency      = 1000000000 // 1s
+	worker7CustomEpoch    = 1451606400
+	worker7Exhausts       = "2084-01-19T03:14:08Z"
+)
+
+// NewWorker7 will generate up to 1024 unique ids/second for 68 years
+// NewWorker7 is the only worker of its size with this configuration
+func NewWorker7() *Worker {
+	const id = 0
+	w := &Worker{
+		ID:             id,
+		IDBits:         worker7IDBits,
+		IDShift:        worker7IDShift,
+		TagShift:       worker7TagShift,
+		SequenceBits:   worker7SequenceBits,
+		SequenceMask:   worker7MaxSequence,
+		TimeStampBits:  worker7TimeStampBits,
+		TimeStampShift: worker7TimeStampShift,
+		Frequency:      worker7Frequency,
+		TotalBits:      worker7TotalBits,
+		CustomEpoch:    worker7CustomEpoch,
+		MaxWorkerID:    worker7MaxID,
+		MaxSequence:    worker7MaxSequence,
+		MaxTimeStamp:   worker7MaxTimeStamp,
+	}
+	w.start()
+	return w
+}
+
+// Register the generated presets ahead of any registered by users.
+func init() {
+	registry.presets = append(builtinPresets, registry.presets...)
+}
+
+var builtinPresets = []Preset{
+	{
+		Name: "NewWorker10",
+		Layout: Layout{
+			IDBits:        worker10IDBits,
+			SequenceBits:  worker10SequenceBits,
+			TimeStampBits: worker10TimeStampBits,
+			Frequency:     worker10Frequency,
+			CustomEpoch:   worker10CustomEpoch,
+		},
+	},
+	{
+		Name: "NewWorker9",
+		Layout: Layout{
+			IDBits:        worker9IDBits,
+			SequenceBits:  worker9SequenceBits,
+			TimeStampBits: worker9TimeStampBits,
+			Frequency:     worker9Frequency,
+			CustomEpoch:   worker9CustomEpoch,
+		},
+	},
+	{
+		Name: "NewWorker8",
+		Layout: Layout{
+			IDBits:        worker8IDBits,
+			SequenceBits:  worker8SequenceBits,
+			TimeStampBits: worker8TimeStampBits,
+			Frequency:     worker8Frequency,
+			CustomEpoch:   worker8CustomEpoch,
+		},
+	},
+	{
+		Name: "NewWorker7",
+		Layout: Layout{
+			IDBits:        worker7IDBits,
+			SequenceBits:  worker7SequenceBits,
+			TimeStampBits: worker7TimeStampBits,
+			Frequency:     worker7Frequency,
+			CustomEpoch:   worker7CustomEpoch,
+		},
+	},
+}
@@ -0,0 +1,74 @@
+package sanic
+
+import (
+	"testing"
+	"time"
+)
+
+// The generated constructors must build the same workers as NewWorker does
+// from the table in gen_presets.go, which this repeats.
+func TestPresetsMatchNewWorker(t *testing.T) {
+	epoch := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		w                              *Worker
+		idBits, sequenceBits, timeBits uint64
+		frequency                      time.Duration
+		stringLength                   int
+		exhausts                       string
+	}{
+		{NewWorker10(63), 6, 12, 41, time.Millisecond, worker10StringLength, worker10Exhausts},
+		{NewWorker9(3), 2, 13, 38, 10 * time.Millisecond, worker9StringLength, worker9Exhausts},
+		{NewWorker8(), 0, 13, 34, 100 * time.Millisecond, worker8StringLength, worker8Exhausts},
+		{NewWorker7(), 0, 10, 31, time.Second, worker7StringLength, worker7Exhausts},
+	} {
+		want := NewWorker(c.w.ID, epoch.UnixNano()/int64(c.frequency),
+			c.idBits, c.sequenceBits, c.timeBits, c.frequency)
+		got := c.w
+		if got.IDBits != want.IDBits || got.IDShift != want.IDShift ||
+			got.TagBits != want.TagBits || got.TagShift != want.TagShift ||
+			got.SequenceBits != want.SequenceBits || got.SequenceMask != want.SequenceMask ||
+			got.TimeStampBits != want.TimeStampBits || got.TimeStampShift != want.TimeStampShift ||
+			got.Frequency != want.Frequency || got.TotalBits != want.TotalBits ||
+			got.CustomEpoch != want.CustomEpoch || got.MaxWorkerID != want.MaxWorkerID ||
+			got.MaxTag != want.MaxTag || got.MaxSequence != want.MaxSequence ||
+			got.MaxTimeStamp != want.MaxTimeStamp {
+			t.Errorf("%s differs from NewWorker's %s", got, want)
+		}
+		if got.StringLength() != c.stringLength {
+			t.Errorf("%s: StringLength %d, constant %d", got, got.StringLength(), c.stringLength)
+		}
+		if s := got.Exhausts().Format(time.RFC3339Nano); s != c.exhausts {
+			t.Errorf("%s: exhausts %s, constant %s", got, s, c.exhausts)
+		}
+		if !got.Epoch().Equal(epoch) {
+			t.Errorf("%s: epoch %s", got, got.Epoch())
+		}
+	}
+}
+
+func TestPresetIDRange(t *testing.T) {
+	if NewWorker10(-1) != nil || NewWorker10(64) != nil || NewWorker10(63) == nil {
+		t.Error("NewWorker10 accepts IDs outside 0 to 63")
+	}
+	if NewWorker9(-1) != nil || NewWorker9(4) != nil || NewWorker9(3) == nil {
+		t.Error("NewWorker9 accepts IDs outside 0 to 3")
+	}
+}
+
+func TestBuiltinPresetsRegistered(t *testing.T) {
+	ctors := map[string]*Worker{
+		"NewWorker10": NewWorker10(0),
+		"NewWorker9":  NewWorker9(0),
+		"NewWorker8":  NewWorker8(),
+		"NewWorker7":  NewWorker7(),
+	}
+	ps := Presets()
+	if len(ps) < len(ctors) {
+		t.Fatalf("%d presets registered", len(ps))
+	}
+	for i, name := range []string{"NewWorker10", "NewWorker9", "NewWorker8", "NewWorker7"} {
+		if ps[i].Name != name || ps[i].Layout != ctors[name].Layout() {
+			t.Errorf("preset %d is %+v, want %s's layout", i, ps[i], name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package sanic
+
+import (
+	"fmt"
+
+	"github.com/ifo/sanic/sanicpb"
+)
+
+// MaxSafeInteger is the largest integer a JavaScript number holds exactly,
+// 2^53-1.
+const MaxSafeInteger = 1<<53 - 1
+
+// ToProto returns id as a SanicID with both the numeric and string forms
+// set, following the convention in sanicpb/sanic.proto.
+func ToProto(w *Worker, id int64) *sanicpb.SanicID {
+	return &sanicpb.SanicID{Id: id, Str: w.IDString(id)}
+}
+
+// ToJSProto is ToProto for messages read by JavaScript clients: it leaves
+// the numeric form unset when id is above MaxSafeInteger, so that such
+// clients only see the string. Version 1 IDs, being large negative
+// numbers, are left string-only too.
+func ToJSProto(w *Worker, id int64) *sanicpb.SanicID {
+	p := ToProto(w, id)
+	if id > MaxSafeInteger || id < -MaxSafeInteger {
+		p.Id = 0
+	}
+	return p
+}
+
+// FromProto returns the ID carried by p, from either field. The string
+// form is decoded as with DecodeInt, so FromProto does not need the layout;
+// use Worker.Validate to check the ID against one. It cannot decode version
+// 1 IDs from a layout with VersionBit: their numeric form is rejected as
+// negative, and their string form, which only the layout places the version
+// bit in, decodes to a different ID. Use ParseProto for those layouts.
+//
+// It returns ErrMalformedID if p is nil, has neither field set, or has a
+// malformed string, ErrInvalidID if the ID is zero or negative, and
+// ErrIDMismatch if both fields are set and do not encode the same value.
+// An unset numeric field reads as zero, so it is ignored when str is set.
+func FromProto(p *sanicpb.SanicID) (int64, error) {
+	id, s := p.GetId(), p.GetStr()
+	if id == 0 && s == "" {
+		return 0, fmt.Errorf("%w: SanicID has no value", ErrMalformedID)
+	}
+	if id < 0 {
+		return 0, fmt.Errorf("%w: SanicID.id is negative: %d", ErrInvalidID, id)
+	}
+	if s == "" {
+		return id, nil
+	}
+
+	fromStr, err := DecodeInt(s)
+	if err != nil {
+		return 0, err
+	}
+	if fromStr <= 0 {
+		return 0, fmt.Errorf("%w: SanicID.str %q is not positive", ErrInvalidID, s)
+	}
+	if id != 0 && id != fromStr {
+		return 0, fmt.Errorf("%w: SanicID.id is %d but str %q is %d",
+			ErrIDMismatch, id, s, fromStr)
+	}
+	return fromStr, nil
+}
+
+// ParseProto is FromProto for IDs from w's layout. The string form is
+// decoded with ParseIDString and both fields are checked with Validate, so
+// version 1 IDs from a layout with VersionBit round-trip through ToProto.
+// It returns the same errors as FromProto, and the errors of ParseIDString
+// for a bad string.
+func ParseProto(w *Worker, p *sanicpb.SanicID) (int64, error) {
+	id, s := p.GetId(), p.GetStr()
+	if id == 0 && s == "" {
+		return 0, fmt.Errorf("%w: SanicID has no value", ErrMalformedID)
+	}
+	if id != 0 {
+		if err := w.Validate(id); err != nil {
+			return 0, fmt.Errorf("%w: SanicID.id %d is not from the layout", err, id)
+		}
+	}
+	if s == "" {
+		return id, nil
+	}
+
+	fromStr, err := w.ParseIDString(s)
+	if err != nil {
+		return 0, err
+	}
+	if id != 0 && id != fromStr {
+		return 0, fmt.Errorf("%w: SanicID.id is %d but str %q is %d",
+			ErrIDMismatch, id, s, fromStr)
+	}
+	return fromStr, nil
+}
@@ -0,0 +1,96 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ifo/sanic/sanicpb"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	w := NewWorker10(3)
+	for range 100 {
+		id := w.NextID()
+		for _, p := range []*sanicpb.SanicID{
+			ToProto(w, id),
+			{Id: id},
+			{Str: w.IDString(id)},
+		} {
+			if got, err := FromProto(p); err != nil || got != id {
+				t.Fatalf("FromProto(%+v) = %d, %v, want %d", p, got, err, id)
+			}
+			if got, err := ParseProto(w, p); err != nil || got != id {
+				t.Fatalf("ParseProto(%+v) = %d, %v, want %d", p, got, err, id)
+			}
+		}
+	}
+}
+
+func TestProtoVersionBit(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	w, err := New(WorkerConfig{ID: 3, Layout: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := w.NextID()
+	if id >= 0 {
+		t.Fatalf("version 1 ID %d is not negative", id)
+	}
+	for _, p := range []*sanicpb.SanicID{ToProto(w, id), {Str: w.IDString(id)}} {
+		if got, err := ParseProto(w, p); err != nil || got != id {
+			t.Errorf("ParseProto(%+v) = %d, %v, want %d", p, got, err, id)
+		}
+		if got, err := FromProto(p); err == nil && got == id {
+			t.Errorf("FromProto(%+v) decoded a version 1 ID without the layout", p)
+		}
+	}
+	if p := ToJSProto(w, id); p.Id != 0 || p.Str == "" {
+		t.Errorf("ToJSProto = %+v, want string only", p)
+	}
+}
+
+func TestProtoErrors(t *testing.T) {
+	w := NewWorker10(3)
+	a, b := w.NextID(), w.NextID()
+	for _, c := range []struct {
+		p    *sanicpb.SanicID
+		want error
+	}{
+		{nil, ErrMalformedID},
+		{&sanicpb.SanicID{}, ErrMalformedID},
+		{&sanicpb.SanicID{Str: "!"}, ErrMalformedID},
+		{&sanicpb.SanicID{Id: -1}, ErrInvalidID},
+		{&sanicpb.SanicID{Id: a, Str: w.IDString(b)}, ErrIDMismatch},
+	} {
+		if _, err := FromProto(c.p); !errors.Is(err, c.want) {
+			t.Errorf("FromProto(%+v) = %v, want %v", c.p, err, c.want)
+		}
+		if c.p.GetStr() == "!" {
+			continue // ParseProto reports the length first
+		}
+		if _, err := ParseProto(w, c.p); !errors.Is(err, c.want) {
+			t.Errorf("ParseProto(%+v) = %v, want %v", c.p, err, c.want)
+		}
+	}
+	var le *LengthError
+	if _, err := ParseProto(w, &sanicpb.SanicID{Str: "!"}); !errors.As(err, &le) {
+		t.Errorf("ParseProto of a short string = %v, want *LengthError", err)
+	}
+}
+
+func TestToJSProto(t *testing.T) {
+	w := NewWorker10(3)
+	id := w.NextID()
+	if id <= MaxSafeInteger {
+		t.Fatalf("NewWorker10 ID %d fits a JavaScript number", id)
+	}
+	if p := ToJSProto(w, id); p.Id != 0 || p.Str != w.IDString(id) {
+		t.Errorf("ToJSProto = %+v", p)
+	}
+	w8 := NewWorker8()
+	small := w8.NextID()
+	if p := ToJSProto(w8, small); p.Id != small {
+		t.Errorf("ToJSProto dropped safe ID %d: %+v", small, p)
+	}
+}
@@ -0,0 +1,207 @@
+package sanic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QuotaPolicy says what QuotaManager.NextID does for a tag that has used up
+// its quota.
+type QuotaPolicy int
+
+const (
+	// QuotaBlock makes NextID wait until the tag is under its quota again.
+	QuotaBlock QuotaPolicy = iota
+	// QuotaError makes NextID return ErrQuotaExceeded at once.
+	QuotaError
+)
+
+// Quota allows at most Limit IDs in any Window of time.
+type Quota struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// QuotaUsage is a snapshot of one tag's quota.
+type QuotaUsage struct {
+	Tag int64
+	Quota
+	Used int64 // IDs issued in the last Window
+}
+
+// QuotaManager issues tagged IDs from a worker, allowing each tag with a
+// Quota at most its Limit of IDs in any sliding Window, as read by the
+// worker's clock: its TimeFunc, or for a tick-driven worker its ticks. Tags
+// without a Quota are not limited. It is safe for concurrent use.
+//
+// The manager keeps its own lock, taken before generation and never during
+// it, and remembers the time of each ID inside a tag's window, at O(1)
+// amortized cost per ID and at most Limit times per tag.
+type QuotaManager struct {
+	// Policy is what NextID does for a tag over its quota.
+	Policy QuotaPolicy
+
+	w     *Worker
+	mutex sync.Mutex
+	tags  map[int64]*tagQuota
+}
+
+type tagQuota struct {
+	Quota
+	times []int64 // UnixNano times of the IDs in the window, from head
+	head  int
+}
+
+// NewQuotaManager returns a QuotaManager for w with the given quotas by tag.
+// It returns ErrInvalidTag for a tag that does not fit w's TagBits, and an
+// error for a Quota whose Limit or Window is not positive.
+func NewQuotaManager(w *Worker, quotas map[int64]Quota) (*QuotaManager, error) {
+	q := &QuotaManager{w: w, tags: make(map[int64]*tagQuota, len(quotas))}
+	for tag, quota := range quotas {
+		if tag < 0 || tag > w.MaxTag {
+			return nil, fmt.Errorf("%w: %d does not fit in %d bits",
+				ErrInvalidTag, tag, w.TagBits)
+		}
+		if quota.Limit <= 0 || quota.Window <= 0 {
+			return nil, fmt.Errorf("sanic: quota for tag %d must have a "+
+				"positive limit and window", tag)
+		}
+		q.tags[tag] = &tagQuota{Quota: quota}
+	}
+	return q, nil
+}
+
+// NextID is Worker.NextIDTagged, counted against tag's quota. Over quota it
+// waits under QuotaBlock, returning ctx's error if ctx is done first, or
+// returns ErrQuotaExceeded under QuotaError. Waiting callers are not served
+// in any particular order. An ID that fails to generate is not counted.
+func (q *QuotaManager) NextID(ctx context.Context, tag int64) (int64, error) {
+	at, err := q.admit(ctx, tag)
+	if err != nil {
+		return 0, err
+	}
+	id, err := q.w.NextIDTagged(tag)
+	if err != nil {
+		q.refund(tag, at)
+		return 0, err
+	}
+	return id, nil
+}
+
+// Usage returns a snapshot of every tag with a Quota, in tag order.
+func (q *QuotaManager) Usage() []QuotaUsage {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := q.now()
+	out := make([]QuotaUsage, 0, len(q.tags))
+	for tag, t := range q.tags {
+		t.expire(now)
+		out = append(out, QuotaUsage{
+			Tag:   tag,
+			Quota: t.Quota,
+			Used:  int64(len(t.times) - t.head),
+		})
+	}
+	slices.SortFunc(out, func(a, b QuotaUsage) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	return out
+}
+
+// admit counts an ID against tag's quota, waiting for room under
+// QuotaBlock, and returns the time it was counted at.
+func (q *QuotaManager) admit(ctx context.Context, tag int64) (int64, error) {
+	for {
+		q.mutex.Lock()
+		t, ok := q.tags[tag]
+		if !ok {
+			q.mutex.Unlock()
+			return 0, nil
+		}
+		now := q.now()
+		wait := t.admit(now)
+		q.mutex.Unlock()
+
+		switch {
+		case wait <= 0:
+			return now, nil
+		case q.Policy == QuotaError:
+			return 0, fmt.Errorf("%w: tag %d has used %d IDs in %s",
+				ErrQuotaExceeded, tag, t.Limit, t.Window)
+		}
+		if err := q.wait(ctx, now+int64(wait)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// wait blocks until the worker's clock may have reached at, in UnixNano, or
+// ctx is done. A tick-driven worker waits for the tick holding at; any other
+// waits on a timer and leaves the caller to read the clock again, since
+// TimeFunc need not keep pace with the wall clock.
+func (q *QuotaManager) wait(ctx context.Context, at int64) error {
+	if s := q.w.ticks; s != nil {
+		f := int64(q.w.Frequency)
+		_, err := s.afterContext(ctx, (at+f-1)/f-1)
+		return err
+	}
+	timer := time.NewTimer(time.Duration(at - q.now()))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refund removes an ID counted at time at from tag's quota.
+func (q *QuotaManager) refund(tag, at int64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	t, ok := q.tags[tag]
+	if !ok {
+		return
+	}
+	for i := len(t.times) - 1; i >= t.head; i-- {
+		if t.times[i] == at {
+			t.times = slices.Delete(t.times, i, i+1)
+			return
+		}
+	}
+}
+
+// now reads the worker's clock: its ticks if it is tick-driven, otherwise
+// TimeFunc or the wall clock.
+func (q *QuotaManager) now() int64 {
+	return q.w.clockNanos()
+}
+
+// admit counts an ID at now if the quota has room, returning 0, or returns
+// how long until it will.
+func (t *tagQuota) admit(now int64) time.Duration {
+	t.expire(now)
+	if int64(len(t.times)-t.head) < t.Limit {
+		t.times = append(t.times, now)
+		return 0
+	}
+	return time.Duration(t.times[t.head] + int64(t.Window) - now)
+}
+
+// expire forgets the times that have left the window ending at now.
+func (t *tagQuota) expire(now int64) {
+	for t.head < len(t.times) && t.times[t.head] <= now-int64(t.Window) {
+		t.head++
+	}
+	// Reuse the front of the slice once half of it is spent.
+	if t.head > 0 && t.head*2 >= len(t.times) {
+		n := copy(t.times, t.times[t.head:])
+		t.times, t.head = t.times[:n], 0
+	}
+}
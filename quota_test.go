@@ -0,0 +1,114 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// taggedLayout is NewWorker10's layout with two tag bits taken from the
+// worker ID.
+func taggedLayout() Layout {
+	l := NewWorker10(0).Layout()
+	l.IDBits, l.TagBits = 4, 2
+	return l
+}
+
+func TestQuotaFakeClock(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: taggedLayout()}, tick)
+	q, err := NewQuotaManager(w, map[int64]Quota{2: {Limit: 3, Window: time.Second}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Policy = QuotaError
+	ctx := context.Background()
+	for range 3 {
+		if _, err := q.NextID(ctx, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := q.NextID(ctx, 2); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("fourth ID: %v, want ErrQuotaExceeded", err)
+	}
+	if _, err := q.NextID(ctx, 1); err != nil {
+		t.Errorf("unlimited tag: %v", err)
+	}
+	if u := q.Usage(); len(u) != 1 || u[0].Used != 3 {
+		t.Errorf("Usage() = %+v", u)
+	}
+
+	// The window slides with the worker's clock, not the wall clock.
+	clock.set(tick + 999)
+	if _, err := q.NextID(ctx, 2); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("before the window passed: %v, want ErrQuotaExceeded", err)
+	}
+	clock.set(tick + 1000)
+	if _, err := q.NextID(ctx, 2); err != nil {
+		t.Errorf("after the window passed: %v", err)
+	}
+}
+
+func TestQuotaTickDriven(t *testing.T) {
+	ticks := make(chan int64, 1)
+	w, err := NewTickDrivenWorker(WorkerConfig{ID: 1, Layout: taggedLayout()}, ticks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tick := NewWorker10(0).Time()
+	ticks <- tick
+	q, err := NewQuotaManager(w, map[int64]Quota{3: {Limit: 2, Window: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for range 2 {
+		if _, err := q.NextID(ctx, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(chan int64)
+	go func() {
+		id, err := q.NextID(ctx, 3)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- id
+	}()
+	ticks <- tick + 9
+	select {
+	case id := <-got:
+		t.Fatalf("got %d before the window passed in ticks", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	ticks <- tick + 10
+	if id := <-got; w.Decompose(id).Tick != tick+10 {
+		t.Errorf("third ID at tick %d, want %d", w.Decompose(id).Tick, tick+10)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	q.NextID(ctx, 3)
+	cancel()
+	if _, err := q.NextID(ctx, 3); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled wait: %v", err)
+	}
+}
+
+func TestQuotaConfigErrors(t *testing.T) {
+	w, err := New(WorkerConfig{Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, quotas := range []map[int64]Quota{
+		{4: {Limit: 1, Window: time.Second}},
+		{-1: {Limit: 1, Window: time.Second}},
+		{1: {Limit: 0, Window: time.Second}},
+		{1: {Limit: 1}},
+	} {
+		if _, err := NewQuotaManager(w, quotas); err == nil {
+			t.Errorf("NewQuotaManager(%v) accepted", quotas)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package sanic
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Range is a block of IDs leased by AllocateRange, from which a client can
+// mint IDs with Next while offline, without a clock. It holds all it needs
+// and can be sent to the client as JSON. Its IDs carry WorkerID, which only
+// the range may use until it expires or is returned, and the ticks from
+// FromTick up to but not including ToTick, absolute as in Parts.Tick.
+type Range struct {
+	Layout           Layout
+	WorkerID         int64
+	FromTick, ToTick int64
+	// NextTick and NextSequence are the ID Next returns next.
+	NextTick, NextSequence int64
+}
+
+// RangeUsage accounts for the IDs a worker has leased in Ranges.
+type RangeUsage struct {
+	Active   int   // ranges neither expired nor returned
+	Reserved int64 // IDs in active ranges
+	// Consumed and Unconsumed count the IDs minted and left unminted in
+	// returned ranges.
+	Consumed, Unconsumed int64
+	// Expired counts the IDs in ranges that expired without being
+	// returned, whose use is unknown.
+	Expired int64
+}
+
+type rangeLease struct {
+	fromTick, toTick int64
+	// next is the first tick the worker ID can be leased for again.
+	next int64
+	done bool // expired or returned, and counted in the pool's usage
+}
+
+// AllocateRange leases a Range covering duration from now, rounded down to
+// whole ticks but at least one, under one of RangeWorkerIDs that has no
+// unexpired lease. Leases of the same worker ID never overlap, and w itself
+// never uses RangeWorkerIDs, so w's IDs and those of every Range are
+// distinct. It returns ErrNoRange if every worker ID is leased,
+// ErrInvalidWorkerID if RangeWorkerIDs holds w.ID or an ID that does not fit
+// the layout, and ErrEpochExhausted if the range would end past the layout's
+// last tick.
+func (w *Worker) AllocateRange(duration time.Duration) (Range, error) {
+	if err := w.checkInitialized(); err != nil {
+		return Range{}, err
+	}
+	now := w.Time()
+
+	p := w.ranges
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.settle(w, now)
+
+	for _, id := range w.RangeWorkerIDs {
+		if id == w.ID || id < 0 || id > w.MaxWorkerID || w.family.has(id) {
+			return Range{}, fmt.Errorf("%w: range worker id %d", ErrInvalidWorkerID, id)
+		}
+		l := p.leases[id]
+		if l != nil && !l.done {
+			continue
+		}
+		from := now
+		if l != nil {
+			from = max(from, l.next)
+		}
+		to := from + max(int64(duration/w.Frequency), 1)
+		if to-1-w.CustomEpoch > w.MaxTimeStamp {
+			return Range{}, fmt.Errorf("%w: layout exhausted at %s",
+				ErrEpochExhausted, w.Layout().Exhausts())
+		}
+		if p.leases == nil {
+			p.leases = make(map[int64]*rangeLease)
+		}
+		p.leases[id] = &rangeLease{fromTick: from, toTick: to, next: to}
+		return Range{
+			Layout:   w.Layout(),
+			WorkerID: id,
+			FromTick: from,
+			ToTick:   to,
+			NextTick: from,
+		}, nil
+	}
+	return Range{}, ErrNoRange
+}
+
+// ReturnRange ends the lease of r, a Range from AllocateRange as its client
+// left it, and counts its minted and unminted IDs. Its worker ID can then be
+// leased again, for ticks after the last ID r minted, so r must not be used
+// afterwards. A range that has expired or was already returned is ignored.
+func (w *Worker) ReturnRange(r Range) {
+	now := w.Time()
+	p := w.ranges
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.settle(w, now)
+
+	l := p.leases[r.WorkerID]
+	if l == nil || l.done || l.fromTick != r.FromTick {
+		return
+	}
+	l.done = true
+	consumed := min(r.minted(), l.size(w))
+	p.usage.Consumed += consumed
+	p.usage.Unconsumed += l.size(w) - consumed
+	l.next = r.NextTick
+	if r.NextSequence > 0 {
+		l.next++
+	}
+}
+
+// RangeUsage returns the accounting of the Ranges leased by w and the
+// workers it shares RangeWorkerIDs with through WithID.
+func (w *Worker) RangeUsage() RangeUsage {
+	now := w.Time()
+	p := w.ranges
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.settle(w, now)
+
+	u := p.usage
+	for _, l := range p.leases {
+		if !l.done {
+			u.Active++
+			u.Reserved += l.size(w)
+		}
+	}
+	return u
+}
+
+// rangePool holds the leases of RangeWorkerIDs. A worker shares it with the
+// workers derived from it by WithID.
+type rangePool struct {
+	mutex  sync.Mutex
+	leases map[int64]*rangeLease // by worker ID
+	usage  RangeUsage            // of settled leases
+}
+
+// settle counts leases that have expired by tick now. p.mutex must be held.
+func (p *rangePool) settle(w *Worker, now int64) {
+	for _, l := range p.leases {
+		if !l.done && l.toTick <= now {
+			l.done = true
+			p.usage.Expired += l.size(w)
+		}
+	}
+}
+
+func (l *rangeLease) size(w *Worker) int64 {
+	return (l.toTick - l.fromTick) * (w.MaxSequence + 1)
+}
+
+// Next returns the range's next ID, in increasing order, or false once the
+// range is used up.
+func (r *Range) Next() (int64, bool) {
+	if r.NextTick >= r.ToTick {
+		return 0, false
+	}
+	l := r.Layout
+	id := (r.NextTick-l.CustomEpoch)<<l.timeStampShift() |
+		r.WorkerID<<(l.SequenceBits+l.TagBits) |
+		r.NextSequence
+	if l.VersionBit {
+		id |= math.MinInt64
+	}
+	if r.NextSequence++; r.NextSequence>>l.SequenceBits != 0 {
+		r.NextTick, r.NextSequence = r.NextTick+1, 0
+	}
+	return id, true
+}
+
+// Remaining returns how many IDs Next has left.
+func (r *Range) Remaining() int64 {
+	return (r.ToTick-r.FromTick)<<r.Layout.SequenceBits - r.minted()
+}
+
+// Expires returns the end of the lease's last tick, ToTick-1, which is also
+// the cutoff for the range's IDs: Next only mints IDs stamped before it,
+// however late it is called. After Expires the worker ID can be leased
+// again for later ticks, so IDs minted from the range afterwards are still
+// unique but older than the new lease's, and RangeUsage counts the range as
+// expired.
+func (r *Range) Expires() time.Time {
+	return r.Layout.tickTime(r.ToTick)
+}
+
+func (r *Range) minted() int64 {
+	return (r.NextTick-r.FromTick)<<r.Layout.SequenceBits + r.NextSequence
+}
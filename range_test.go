@@ -0,0 +1,88 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRangeLease(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 1, Layout: NewWorker10(0).Layout()}, tick)
+	w.RangeWorkerIDs = []int64{60}
+
+	r, err := w.AllocateRange(3 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.WorkerID != 60 || r.FromTick != tick || r.ToTick != tick+3 {
+		t.Fatalf("range %+v", r)
+	}
+	if want := w.Layout().tickTime(r.ToTick - 1).Add(w.Frequency); !r.Expires().Equal(want) {
+		t.Errorf("Expires() = %v, want the end of tick %d, %v", r.Expires(), r.ToTick-1, want)
+	}
+	if _, err := w.AllocateRange(time.Millisecond); !errors.Is(err, ErrNoRange) {
+		t.Errorf("second lease of the only worker ID: %v, want ErrNoRange", err)
+	}
+
+	// Let the lease expire before minting from it at all.
+	clock.set(tick + 10)
+	next, err := w.AllocateRange(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.FromTick < r.ToTick {
+		t.Fatalf("new lease starts at tick %d, inside the old one", next.FromTick)
+	}
+	seen := map[int64]bool{}
+	var last int64
+	for n := int64(0); ; n++ {
+		id, ok := r.Next()
+		if !ok {
+			if n != 3*(w.MaxSequence+1) {
+				t.Errorf("range minted %d IDs", n)
+			}
+			break
+		}
+		if id <= last || !w.Timestamp(id).Before(r.Expires()) {
+			t.Fatalf("ID %d at %v is out of order or not before Expires", id, w.Timestamp(id))
+		}
+		last, seen[id] = id, true
+	}
+	for id, ok := next.Next(); ok; id, ok = next.Next() {
+		if seen[id] || id <= last {
+			t.Fatalf("new lease's ID %d is not after the expired range's", id)
+		}
+	}
+	if u := w.RangeUsage(); u.Expired != 3*(w.MaxSequence+1) || u.Active != 1 {
+		t.Errorf("usage %+v", u)
+	}
+}
+
+func TestRangeReturn(t *testing.T) {
+	w := NewWorker10(1)
+	w.RangeWorkerIDs = []int64{60}
+	r, err := w.AllocateRange(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		r.Next()
+	}
+	w.ReturnRange(r)
+	if u := w.RangeUsage(); u.Consumed != 10 || u.Active != 0 || u.Unconsumed != r.Remaining() {
+		t.Errorf("usage %+v, remaining %d", u, r.Remaining())
+	}
+	again, err := w.AllocateRange(time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.FromTick <= r.FromTick {
+		t.Errorf("re-lease starts at tick %d, not after the returned range's IDs", again.FromTick)
+	}
+
+	w.RangeWorkerIDs = []int64{1}
+	if _, err := w.AllocateRange(time.Millisecond); !errors.Is(err, ErrInvalidWorkerID) {
+		t.Errorf("leasing the worker's own ID: %v", err)
+	}
+}
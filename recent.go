@@ -0,0 +1,170 @@
+package sanic
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentQueueSize is the capacity of a RecentIndex's queue, a power of two.
+const recentQueueSize = 4096
+
+// RecentIndex remembers the IDs a worker issued in the last window of time,
+// one bitset of tags and sequences per tick, for questions such as "was an
+// ID issued around this time?". It is safe for concurrent use.
+//
+// Generation only writes each ID to a lock-free single-producer queue; the
+// index drains the queue when queried, or generation does if the queue
+// fills up before then. The window is counted back from the latest tick an
+// ID was recorded for, not from the clock. Memory is fixed when the index
+// is made, at one bit per possible tag and sequence for every tick in the
+// window.
+type RecentIndex struct {
+	l      Layout
+	worker int64
+
+	// The queue. Only the generating goroutine, which holds the worker's
+	// mutex, writes tail and buf; only holders of mutex read them or move
+	// head.
+	buf        [recentQueueSize]int64
+	head, tail atomic.Uint64
+
+	mutex   sync.Mutex
+	slots   []recentSlot
+	started bool  // an ID has been recorded
+	maxTick int64 // the latest tick recorded
+}
+
+type recentSlot struct {
+	tick  int64
+	count int64
+	bits  []uint64
+}
+
+// NewRecentIndex returns a RecentIndex holding window worth of ticks, at
+// least one, and attaches it to w as w.Recent. It must be attached before w
+// generates IDs that should be recorded.
+func NewRecentIndex(w *Worker, window time.Duration) *RecentIndex {
+	n := max(int64(window/w.Frequency), 1)
+	x := &RecentIndex{
+		l:      w.Layout(),
+		worker: w.ID,
+		slots:  make([]recentSlot, n),
+	}
+	perTick := (1<<(w.TagBits+w.SequenceBits) + 63) / 64
+	for i := range x.slots {
+		x.slots[i] = recentSlot{tick: -1, bits: make([]uint64, perTick)}
+	}
+
+	w.mutex.Lock()
+	w.Recent = x
+	w.mutex.Unlock()
+	return x
+}
+
+// IssuedBetween returns the recorded IDs from every tick overlapping
+// [from, to], in increasing order. Ticks that have left the window are not
+// included.
+func (x *RecentIndex) IssuedBetween(from, to time.Time) []int64 {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.drain()
+
+	var ids []int64
+	lo, hi := x.window(x.l.tick(from), x.l.tick(to))
+	for tick := lo; tick <= hi; tick++ {
+		s := x.slot(tick)
+		if s.tick != tick {
+			continue
+		}
+		base := (tick-x.l.CustomEpoch)<<x.l.timeStampShift() |
+			x.worker<<(x.l.SequenceBits+x.l.TagBits)
+		if x.l.VersionBit {
+			base |= math.MinInt64
+		}
+		for i, word := range s.bits {
+			for word != 0 {
+				b := bits.TrailingZeros64(word)
+				word &= word - 1
+				ids = append(ids, base|int64(i*64+b))
+			}
+		}
+	}
+	return ids
+}
+
+// CountSince returns how many recorded IDs are from the tick containing t
+// or later, within the window.
+func (x *RecentIndex) CountSince(t time.Time) int64 {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.drain()
+
+	var n int64
+	lo, hi := x.window(x.l.tick(t), x.maxTick)
+	for tick := lo; tick <= hi; tick++ {
+		if s := x.slot(tick); s.tick == tick {
+			n += s.count
+		}
+	}
+	return n
+}
+
+// window clips the ticks [lo, hi] to those still held. x.mutex must be held.
+func (x *RecentIndex) window(lo, hi int64) (int64, int64) {
+	if !x.started {
+		return 0, -1
+	}
+	return max(lo, x.maxTick-int64(len(x.slots))+1), min(hi, x.maxTick)
+}
+
+func (x *RecentIndex) slot(tick int64) *recentSlot {
+	i := tick % int64(len(x.slots))
+	if i < 0 {
+		i += int64(len(x.slots))
+	}
+	return &x.slots[i]
+}
+
+// push queues id for the index. It is called with the worker's mutex held.
+func (x *RecentIndex) push(id int64) {
+	t := x.tail.Load()
+	if t-x.head.Load() == recentQueueSize {
+		x.mutex.Lock()
+		x.drain()
+		x.mutex.Unlock()
+	}
+	x.buf[t%recentQueueSize] = id
+	x.tail.Store(t + 1)
+}
+
+// drain moves queued IDs into the slots. x.mutex must be held.
+func (x *RecentIndex) drain() {
+	h, t := x.head.Load(), x.tail.Load()
+	for ; h != t; h++ {
+		x.insert(x.buf[h%recentQueueSize])
+	}
+	x.head.Store(h)
+}
+
+func (x *RecentIndex) insert(id int64) {
+	p := x.l.Decompose(id)
+	if x.started && p.Tick <= x.maxTick-int64(len(x.slots)) {
+		return // already out of the window
+	}
+	s := x.slot(p.Tick)
+	if s.tick != p.Tick {
+		clear(s.bits)
+		s.tick, s.count = p.Tick, 0
+	}
+	i := p.Tag<<x.l.SequenceBits | p.Sequence
+	if s.bits[i/64]&(1<<(i%64)) == 0 {
+		s.bits[i/64] |= 1 << (i % 64)
+		s.count++
+	}
+	if !x.started || p.Tick > x.maxTick {
+		x.maxTick, x.started = p.Tick, true
+	}
+}
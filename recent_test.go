@@ -0,0 +1,133 @@
+package sanic
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recentWorker returns a fake-clock worker with a RecentIndex of 10 ticks,
+// having issued perTick IDs in each of ticks ticks from tick.
+func recentWorker(t *testing.T, cfg WorkerConfig, tick int64, ticks, perTick int) (*Worker, *RecentIndex, []int64) {
+	t.Helper()
+	w, c := fakeClockWorker(t, cfg, tick)
+	x := NewRecentIndex(w, 10*w.Frequency)
+	var ids []int64
+	for i := range ticks {
+		c.set(tick + int64(i))
+		for range perTick {
+			ids = append(ids, w.NextID())
+		}
+	}
+	return w, x, ids
+}
+
+func TestRecentIndexWindow(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, x, ids := recentWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick, 15, 5)
+	l := w.Layout()
+	at := func(k int64) time.Time { return l.tickTime(tick + k) }
+
+	// Only the last 10 ticks, +5 to +14, are held.
+	if got := x.IssuedBetween(at(0), at(14)); !slices.Equal(got, ids[25:]) {
+		t.Errorf("IssuedBetween the whole run = %d IDs, want the last 50", len(got))
+	}
+	if got := x.CountSince(at(0)); got != 50 {
+		t.Errorf("CountSince the start = %d, want 50", got)
+	}
+
+	// Both ends are inclusive, and cover the whole tick they fall in.
+	if got := x.IssuedBetween(at(7), at(7)); !slices.Equal(got, ids[35:40]) {
+		t.Errorf("IssuedBetween one tick = %v, want %v", got, ids[35:40])
+	}
+	if got := x.IssuedBetween(at(7).Add(time.Microsecond), at(8).Add(-time.Microsecond)); !slices.Equal(got, ids[35:40]) {
+		t.Errorf("IssuedBetween within one tick = %d IDs", len(got))
+	}
+	if got := x.CountSince(at(12).Add(time.Microsecond)); got != 15 {
+		t.Errorf("CountSince +12 = %d, want 15", got)
+	}
+	if got := x.CountSince(at(15)); got != 0 {
+		t.Errorf("CountSince after the last ID = %d", got)
+	}
+	if got := x.IssuedBetween(at(9), at(8)); got != nil {
+		t.Errorf("IssuedBetween an empty range = %v", got)
+	}
+}
+
+func TestRecentIndexEmpty(t *testing.T) {
+	w := NewWorker10(5)
+	x := NewRecentIndex(w, time.Second)
+	if got := x.IssuedBetween(time.Time{}, time.Now()); got != nil || x.CountSince(time.Time{}) != 0 {
+		t.Errorf("empty index returned %v", got)
+	}
+	if w.Recent != x {
+		t.Error("NewRecentIndex did not attach the index")
+	}
+}
+
+// More IDs than the queue holds are drained by generation itself.
+func TestRecentIndexQueueFull(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	_, x, ids := recentWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick, 3, 3000)
+	if n := x.CountSince(time.Time{}); n != int64(len(ids)) {
+		t.Errorf("counted %d of %d IDs", n, len(ids))
+	}
+	if got := x.IssuedBetween(time.Time{}, time.Now().Add(time.Hour)); !slices.Equal(got, ids) {
+		t.Errorf("IssuedBetween returned %d of %d IDs", len(got), len(ids))
+	}
+}
+
+func TestRecentIndexTagged(t *testing.T) {
+	ref := NewWorker10(0)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 3, Layout: taggedLayout()}, tick)
+	x := NewRecentIndex(w, time.Second)
+	var ids []int64
+	for _, tag := range []int64{2, 0, 3} {
+		id, _ := w.NextIDTagged(tag)
+		ids = append(ids, id)
+	}
+	c.set(tick + 1)
+	ids = append(ids, w.NextID())
+	slices.Sort(ids[:3]) // within a tick, the index orders by tag
+	if got := x.IssuedBetween(time.Time{}, time.Now().Add(time.Hour)); !slices.Equal(got, ids) {
+		t.Errorf("IssuedBetween = %v, want %v", got, ids)
+	}
+}
+
+func TestRecentIndexConcurrent(t *testing.T) {
+	w := NewWorker10(5)
+	x := NewRecentIndex(w, time.Minute)
+	const goroutines, each = 4, 5000
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range each {
+				w.NextID()
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var last int64
+		for range 100 {
+			n := x.CountSince(time.Time{})
+			if n < last {
+				t.Errorf("count went down from %d to %d", last, n)
+			}
+			last = n
+			x.IssuedBetween(time.Now().Add(-time.Millisecond), time.Now())
+		}
+	}()
+	wg.Wait()
+	<-done
+	if n := x.CountSince(time.Time{}); n != goroutines*each {
+		t.Errorf("counted %d IDs, want %d", n, goroutines*each)
+	}
+}
@@ -0,0 +1,58 @@
+package sanic
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Preset is a named layout in the preset registry. The generated presets
+// are registered under their constructor names, such as "NewWorker10".
+type Preset struct {
+	Name   string
+	Layout Layout
+	Code   byte // set by RegisterLayoutCode, or 0
+}
+
+// StringLength is the length of the preset's ID strings.
+func (p Preset) StringLength() int {
+	return StringLength(p.Layout.TotalBits())
+}
+
+var registry struct {
+	sync.RWMutex
+	presets []Preset
+}
+
+// RegisterPreset adds a named layout to the preset registry, so that tools
+// can recognize its IDs. Names must be unique.
+func RegisterPreset(name string, l Layout) error {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for _, p := range registry.presets {
+		if p.Name == name {
+			return fmt.Errorf("sanic: preset %q already registered", name)
+		}
+	}
+	registry.presets = append(registry.presets, Preset{Name: name, Layout: l})
+	return nil
+}
+
+// Presets returns the registered presets, built-in ones first.
+func Presets() []Preset {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]Preset(nil), registry.presets...)
+}
+
+// presetsWithLength returns the names of registered presets whose ID
+// strings are n characters long.
+func presetsWithLength(n int) []string {
+	var names []string
+	for _, p := range Presets() {
+		if p.StringLength() == n {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package sanic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Every preset's strings are rejected by every other preset's parser, with
+// a hint naming the preset they came from.
+func TestParseOtherPreset(t *testing.T) {
+	names := []string{"NewWorker10", "NewWorker9", "NewWorker8", "NewWorker7"}
+	workers := presetWorkers()
+	for i, src := range workers {
+		id := src.NextID()
+		s := src.IDString(id)
+		for j, dst := range workers {
+			got, err := dst.ParseIDString(s)
+			if i == j {
+				if err != nil || got != id {
+					t.Errorf("%s: ParseIDString(%q) = %d, %v", names[j], s, got, err)
+				}
+				continue
+			}
+			var le *LengthError
+			if !errors.As(err, &le) || !errors.Is(err, ErrMalformedID) {
+				t.Errorf("%s accepted %s string %q: %d, %v", names[j], names[i], s, got, err)
+				continue
+			}
+			if le.Expected != dst.StringLength() || le.Actual != len(s) ||
+				!slices.Equal(le.Matches, []string{names[i]}) {
+				t.Errorf("%s parsing %s string: %+v", names[j], names[i], le)
+			}
+			if !strings.Contains(err.Error(), "looks like an id from "+names[i]) {
+				t.Errorf("error %q has no hint", err)
+			}
+		}
+	}
+}
+
+func TestRegisterPreset(t *testing.T) {
+	l := NewWorker7().Layout()
+	l.TimeStampBits = 25 // 36 bits, 6 characters
+	// The registry is global, so each run of the test needs a new name.
+	name := fmt.Sprintf("test6-%d", len(Presets()))
+	if err := RegisterPreset(name, l); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterPreset(name, l); err == nil {
+		t.Error("duplicate preset name accepted")
+	}
+	ps := Presets()
+	if p := ps[len(ps)-1]; p.Name != name || p.Layout != l || p.StringLength() != 6 {
+		t.Errorf("last preset %+v", p)
+	}
+
+	var le *LengthError
+	if _, err := NewWorker10(1).ParseIDString("000000"); !errors.As(err, &le) ||
+		!slices.Contains(le.Matches, name) {
+		t.Errorf("6-character string: %v", err)
+	}
+	if _, err := NewWorker10(1).ParseIDString("00000"); !errors.As(err, &le) || le.Matches != nil {
+		t.Errorf("5-character string: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package sanic
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// remapProgressEvery is how many IDs RemapBatch remaps between calls to its
+// progress callback.
+const remapProgressEvery = 4096
+
+// Remap re-mints id, generated with from's layout, in to's layout, as when
+// migrating stored IDs between presets. The new ID keeps id's creation time,
+// to to's Frequency, and its worker ID, tag and sequence, so the mapping is
+// stable: an ID always maps to the same result, and distinct IDs map to
+// distinct results in the same order as long as to's ticks are no coarser
+// than from's. It returns ErrInvalidID if id does not fit from's layout, and
+// ErrOutOfRange if its time is before to's epoch or past to's last tick, or
+// one of its fields does not fit in to's bits.
+func Remap(from, to *Worker, id int64) (int64, error) {
+	return remap(from, to, id, false)
+}
+
+func remap(from, to *Worker, id int64, clamp bool) (int64, error) {
+	if err := from.Validate(id); err != nil {
+		return 0, err
+	}
+	p := from.Decompose(id)
+	if clamp {
+		p.Sequence = min(p.Sequence, to.MaxSequence)
+	}
+
+	l := to.Layout()
+	switch tick := l.tick(p.Time) - l.CustomEpoch; {
+	case tick < 0:
+		return 0, fmt.Errorf("%w: id %d is from %s, before the target epoch %s",
+			ErrOutOfRange, id, p.Time, l.Epoch())
+	case tick > to.MaxTimeStamp:
+		return 0, fmt.Errorf("%w: id %d is from %s, the target is exhausted at %s",
+			ErrOutOfRange, id, p.Time, l.Exhausts())
+	case p.WorkerID > to.MaxWorkerID:
+		return 0, fmt.Errorf("%w: worker ID %d does not fit in %d bits",
+			ErrOutOfRange, p.WorkerID, to.IDBits)
+	case p.Tag > to.MaxTag:
+		return 0, fmt.Errorf("%w: tag %d does not fit in %d bits",
+			ErrOutOfRange, p.Tag, to.TagBits)
+	case p.Sequence > to.MaxSequence:
+		return 0, fmt.Errorf("%w: sequence %d does not fit in %d bits",
+			ErrOutOfRange, p.Sequence, to.SequenceBits)
+	}
+
+	out := l.FirstID(p.Time) |
+		p.WorkerID<<to.IDShift |
+		p.Tag<<to.TagShift |
+		p.Sequence
+	if to.VersionBit {
+		out |= math.MinInt64
+	}
+	return out, nil
+}
+
+// RemapBatch remaps ids, in any order, and returns the results in the same
+// order. Each result is the lowest target ID that is no less than the ID's
+// Remap result, with a sequence too large for to clamped to to's largest,
+// and greater than the result for the ID before it in source order. Where
+// Remap results collide or fall out of order, as when to has fewer sequence
+// bits or coarser ticks than from, later IDs so take the next free sequence
+// numbers in turn, carrying into the tag, worker ID and timestamp bits. The
+// results are therefore distinct and in source order, and equal Remap's
+// when those already are; repeated IDs in ids get the same result.
+//
+// RemapBatch returns ErrInvalidID and ErrOutOfRange as Remap does, except
+// for sequences that do not fit, and ErrOutOfRange if carrying would date an
+// ID at or after the end of its source tick.
+//
+// If progress is not nil, it is called with the number of IDs remapped so
+// far and len(ids) after every 4096 IDs and after the last one.
+func RemapBatch(from, to *Worker, ids []int64,
+	progress func(done, total int)) ([]int64, error) {
+
+	order := make([]int, len(ids))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(ids[a], ids[b])
+	})
+
+	out := make([]int64, len(ids))
+	var prev int64
+	for n, i := range order {
+		if id := ids[i]; n > 0 && id == ids[order[n-1]] {
+			out[i] = prev
+		} else {
+			r, err := remap(from, to, id, true)
+			if err != nil {
+				return nil, err
+			}
+			// Source order keeps Remap results in tick order, so any
+			// collision is with prev's tick.
+			if n > 0 && r <= prev {
+				r = prev + 1
+				end := from.Timestamp(id).Add(from.Frequency)
+				if !to.Timestamp(r).Before(end) {
+					return nil, fmt.Errorf("%w: no room for id %d before %s",
+						ErrOutOfRange, id, end)
+				}
+			}
+			out[i], prev = r, r
+		}
+		if done := n + 1; progress != nil &&
+			(done%remapProgressEvery == 0 || done == len(ids)) {
+			progress(done, len(ids))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package sanic
+
+import (
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemap(t *testing.T) {
+	from, to := NewWorker9(2), NewWorker10(2)
+	tick := from.Time()
+	ids := []int64{
+		from.compose(tick-100, 0, 0),
+		from.compose(tick-100, 0, 1),
+		from.compose(tick-99, 0, 4095),
+		from.compose(tick, 0, 7),
+	}
+	var prev int64
+	for _, id := range ids {
+		got, err := Remap(from, to, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, q := from.Decompose(id), to.Decompose(got)
+		if !q.Time.Equal(p.Time) || q.WorkerID != p.WorkerID || q.Sequence != p.Sequence {
+			t.Errorf("Remap(%+v) = %+v", p, q)
+		}
+		if again, _ := Remap(from, to, id); again != got {
+			t.Errorf("Remap(%d) is not stable: %d, %d", id, got, again)
+		}
+		if got <= prev {
+			t.Errorf("Remap(%d) = %d, not after %d", id, got, prev)
+		}
+		prev = got
+	}
+}
+
+// Coarser target ticks keep the time to the target's Frequency.
+func TestRemapCoarser(t *testing.T) {
+	from, to := NewWorker10(0), NewWorker7()
+	id := from.compose(from.Time(), 0, 3)
+	got, err := Remap(from, to, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := from.Timestamp(id).Truncate(time.Second); !to.Timestamp(got).Equal(want) {
+		t.Errorf("remapped time %s, want %s", to.Timestamp(got), want)
+	}
+}
+
+func TestRemapVersionBit(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	to, err := New(WorkerConfig{ID: 1, Layout: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := NewWorker9(1)
+	id := from.NextID()
+	got, err := Remap(from, to, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Version(got) != 1 || to.Decompose(got).WorkerID != 1 {
+		t.Errorf("remapped to %+v", to.Decompose(got))
+	}
+}
+
+func TestRemapErrors(t *testing.T) {
+	w9, w10 := NewWorker9(3), NewWorker10(5)
+	late := w10.Layout()
+	late.CustomEpoch = w10.Time() + 1000
+	lateW, err := New(WorkerConfig{ID: 5, Layout: late})
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := w10.Layout()
+	short.TimeStampBits = 35 // about a year from 2016
+	shortW, err := New(WorkerConfig{ID: 5, Layout: short})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		from, to *Worker
+		id       int64
+		want     error
+	}{
+		{"invalid", w10, w9, -1, ErrInvalidID},
+		{"too wide", w10, w9, 1 << 62, ErrInvalidID},
+		{"worker ID", w10, NewWorker9(0), w10.NextID(), ErrOutOfRange},
+		{"sequence", w9, NewWorker10(3), w9.compose(w9.Time(), 0, 5000), ErrOutOfRange},
+		{"before epoch", w10, lateW, w10.NextID(), ErrOutOfRange},
+		{"exhausted", w10, shortW, w10.NextID(), ErrOutOfRange},
+	} {
+		if _, err := Remap(tc.from, tc.to, tc.id); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Remap = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestRemapBatch(t *testing.T) {
+	// 10 source ticks fall in one target tick, so Remap results collide.
+	from, to := NewWorker10(2), NewWorker9(2)
+	tick := from.Time() / 10 * 10
+	var ids []int64
+	for i := range int64(10) {
+		for seq := range int64(300) {
+			ids = append(ids, from.compose(tick+i, 0, seq))
+		}
+	}
+	ids = append(ids, ids[17], ids[2000])
+	rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	got, err := RemapBatch(from, to, ids, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byID := map[int64]int64{}
+	for i, id := range ids {
+		if r, ok := byID[id]; ok && r != got[i] {
+			t.Errorf("repeated ID %d remapped to %d and %d", id, r, got[i])
+		}
+		byID[id] = got[i]
+		if !to.Timestamp(got[i]).Equal(from.Timestamp(id).Truncate(10 * time.Millisecond)) {
+			t.Errorf("ID %d remapped to %s", id, to.Timestamp(got[i]))
+		}
+	}
+	src := slices.Sorted(maps.Keys(byID))
+	for i := 1; i < len(src); i++ {
+		if byID[src[i]] <= byID[src[i-1]] {
+			t.Fatalf("results out of source order at %d", src[i])
+		}
+	}
+}
+
+func TestRemapBatchProgress(t *testing.T) {
+	w := NewWorker10(1)
+	ids := make([]int64, 10000)
+	w.NextIDs(ids)
+	var calls [][2]int
+	if _, err := RemapBatch(w, w, ids, func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := [][2]int{{4096, 10000}, {8192, 10000}, {10000, 10000}}; !slices.Equal(calls, want) {
+		t.Errorf("progress calls %v, want %v", calls, want)
+	}
+}
+
+func TestRemapBatchClamp(t *testing.T) {
+	from, to := NewWorker9(1), NewWorker10(1)
+	tick := from.Time()
+	ids := []int64{from.compose(tick, 0, 5000), from.compose(tick, 0, 5001)}
+	got, err := RemapBatch(from, to, ids, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The clamped sequence carries into the worker ID bits.
+	if p := to.Decompose(got[0]); p.Sequence != to.MaxSequence || p.WorkerID != 1 {
+		t.Errorf("first result %+v", p)
+	}
+	if p := to.Decompose(got[1]); p.Sequence != 0 || p.WorkerID != 2 {
+		t.Errorf("second result %+v", p)
+	}
+
+	// Carrying into the next target tick would date an ID after its
+	// source tick.
+	from, to = NewWorker10(0), NewWorker7()
+	tick = from.Time() / 1000 * 1000
+	ids = []int64{from.compose(tick, 0, to.MaxSequence), from.compose(tick+1, 0, to.MaxSequence)}
+	if _, err := RemapBatch(from, to, ids, nil); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("RemapBatch = %v, want ErrOutOfRange", err)
+	}
+	if _, err := RemapBatch(from, to, []int64{0}, nil); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("RemapBatch(0) = %v, want ErrInvalidID", err)
+	}
+}
@@ -0,0 +1,203 @@
+package sanic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// Reservation is a batch of IDs from Reserve, allocated provisionally until
+// Commit or Release, as with a database sequence's nextval inside a
+// transaction. Either way its IDs are never issued again: releasing them
+// only records them as burned.
+type Reservation struct {
+	r *reservation
+}
+
+// ReservationInfo describes an open Reservation.
+type ReservationInfo struct {
+	Serial   int64     // in order of Reserve calls, from 1
+	Size     int       // IDs reserved
+	Reserved time.Time // the time of its first ID, to the worker's Frequency
+}
+
+// ReservationStats counts the IDs in a worker's Reservations.
+type ReservationStats struct {
+	Open, Committed, Released, Leaked int
+	// OpenIDs, CommittedIDs and BurnedIDs count the IDs in open, committed
+	// and released or leaked reservations.
+	OpenIDs, CommittedIDs, BurnedIDs int64
+	// Since is when the first reservation was made, or the zero time.
+	Since time.Time
+}
+
+// BurnRate returns the IDs burned per second from Since to now.
+func (s ReservationStats) BurnRate(now time.Time) float64 {
+	d := now.Sub(s.Since).Seconds()
+	if s.Since.IsZero() || d <= 0 {
+		return 0
+	}
+	return float64(s.BurnedIDs) / d
+}
+
+type reservation struct {
+	w      *Worker
+	serial int64
+	ids    []int64
+}
+
+// reservationBook tracks a worker's open Reservations. It is guarded by the
+// worker's mutex, and never refers to a reservation, so that unreachable
+// open ones are finalized and counted as leaked.
+type reservationBook struct {
+	serial int64
+	open   map[int64]ReservationInfo
+	stats  ReservationStats
+}
+
+// Reserve generates n IDs in one batch, as NextIDs does, and returns them as
+// an open Reservation. It returns the errors NextIDContext does, which may
+// come after some IDs were generated, in which case they are counted as
+// burned. An open Reservation that becomes unreachable is counted as leaked
+// and burned once the garbage collector finds it; Audit lists those still
+// open.
+func (w *Worker) Reserve(n int) (Reservation, error) {
+	if err := w.checkInitialized(); err != nil {
+		return Reservation{}, err
+	}
+	if n <= 0 {
+		return Reservation{}, fmt.Errorf("sanic: Reserve needs a positive n, got %d", n)
+	}
+
+	ids := make([]int64, 0, n)
+	var tick int64
+	w.mutex.Lock()
+	err := w.waitTurn(context.Background(), true)
+	if err == nil {
+		err = w.checkTick()
+	}
+	for err == nil && len(ids) < n {
+		var id int64
+		id, tick, err = w.tryGenerate(context.Background(), 0)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	b := &w.reservations
+	if len(ids) > 0 && b.stats.Since.IsZero() {
+		b.stats.Since = w.Decompose(ids[0]).Time
+	}
+	if err != nil {
+		b.stats.BurnedIDs += int64(len(ids))
+		w.mutex.Unlock()
+		return Reservation{}, err
+	}
+	b.serial++
+	if b.open == nil {
+		b.open = make(map[int64]ReservationInfo)
+	}
+	b.open[b.serial] = ReservationInfo{
+		Serial:   b.serial,
+		Size:     n,
+		Reserved: w.Decompose(ids[0]).Time,
+	}
+	r := &reservation{w: w, serial: b.serial, ids: ids}
+	w.mutex.Unlock()
+
+	runtime.SetFinalizer(r, (*reservation).leak)
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
+	}
+	return Reservation{r}, nil
+}
+
+// IDs returns the reservation's IDs in increasing order. They must not be
+// modified.
+func (r Reservation) IDs() []int64 {
+	if r.r == nil {
+		return nil
+	}
+	return r.r.ids
+}
+
+// Commit closes the reservation, counting its IDs as used.
+func (r Reservation) Commit() error {
+	_, err := r.close(func(s *ReservationStats, size int) {
+		s.Committed++
+		s.CommittedIDs += int64(size)
+	})
+	return err
+}
+
+// Release closes the reservation, counting its IDs as burned, and returns
+// how many there were. They are never issued again.
+func (r Reservation) Release() (burned int, err error) {
+	return r.close(func(s *ReservationStats, size int) {
+		s.Released++
+		s.BurnedIDs += int64(size)
+	})
+}
+
+// close removes r from the open reservations and counts its size with
+// count, or returns ErrReservationClosed if it is not open.
+func (r Reservation) close(count func(s *ReservationStats, size int)) (int, error) {
+	if r.r == nil {
+		return 0, ErrReservationClosed
+	}
+	w := r.r.w
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	info, ok := w.reservations.open[r.r.serial]
+	if !ok {
+		return 0, ErrReservationClosed
+	}
+	delete(w.reservations.open, r.r.serial)
+	runtime.SetFinalizer(r.r, nil)
+	count(&w.reservations.stats, info.Size)
+	return info.Size, nil
+}
+
+// leak counts r as leaked if it is still open. It is r's finalizer.
+func (r *reservation) leak() {
+	w := r.w
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	b := &w.reservations
+	if info, ok := b.open[r.serial]; ok {
+		delete(b.open, r.serial)
+		b.stats.Leaked++
+		b.stats.BurnedIDs += int64(info.Size)
+	}
+}
+
+// Audit returns the worker's open Reservations, oldest first, for finding
+// ones that were neither committed nor released before they should have
+// been.
+func (w *Worker) Audit() []ReservationInfo {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	out := make([]ReservationInfo, 0, len(w.reservations.open))
+	for _, info := range w.reservations.open {
+		out = append(out, info)
+	}
+	slices.SortFunc(out, func(a, b ReservationInfo) int {
+		return cmp.Compare(a.Serial, b.Serial)
+	})
+	return out
+}
+
+// ReservationStats returns the accounting of the worker's Reservations.
+func (w *Worker) ReservationStats() ReservationStats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	s := w.reservations.stats
+	s.Open = len(w.reservations.open)
+	for _, info := range w.reservations.open {
+		s.OpenIDs += int64(info.Size)
+	}
+	return s
+}
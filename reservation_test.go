@@ -0,0 +1,138 @@
+package sanic
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	w := NewWorker10(7)
+	before := w.NextID()
+	r, err := w.Reserve(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := r.IDs()
+	if len(ids) != 10 || !slices.IsSorted(ids) || ids[0] <= before {
+		t.Errorf("reserved %v after %d", ids, before)
+	}
+	if after := w.NextID(); after <= ids[9] {
+		t.Errorf("issued %d within the reservation", after)
+	}
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Commit(); !errors.Is(err, ErrReservationClosed) {
+		t.Errorf("second Commit = %v", err)
+	}
+	if _, err := r.Release(); !errors.Is(err, ErrReservationClosed) {
+		t.Errorf("Release after Commit = %v", err)
+	}
+
+	r, _ = w.Reserve(5)
+	if n, err := r.Release(); n != 5 || err != nil {
+		t.Errorf("Release = %d, %v", n, err)
+	}
+	want := ReservationStats{Committed: 1, Released: 1, CommittedIDs: 10, BurnedIDs: 5,
+		Since: w.Decompose(ids[0]).Time}
+	if s := w.ReservationStats(); s != want {
+		t.Errorf("stats %+v, want %+v", s, want)
+	}
+}
+
+func TestReserveErrors(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if _, err := NewWorker10(7).Reserve(n); err == nil {
+			t.Errorf("Reserve(%d) succeeded", n)
+		}
+	}
+	if _, err := (&Worker{}).Reserve(1); !errors.Is(err, ErrUninitialized) {
+		t.Errorf("Reserve on a zero Worker = %v", err)
+	}
+	var r Reservation
+	if r.IDs() != nil || r.Commit() == nil {
+		t.Error("the zero Reservation is open")
+	}
+}
+
+func TestReservationAudit(t *testing.T) {
+	w := NewWorker10(7)
+	var rs []Reservation
+	for n := 1; n <= 3; n++ {
+		r, err := w.Reserve(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	rs[1].Commit()
+	got := w.Audit()
+	if len(got) != 2 || got[0].Serial != 1 || got[0].Size != 1 || got[1].Serial != 3 || got[1].Size != 3 {
+		t.Errorf("Audit = %+v", got)
+	}
+	if !got[1].Reserved.Equal(w.Decompose(rs[2].IDs()[0]).Time) {
+		t.Errorf("Reserved %s", got[1].Reserved)
+	}
+	if s := w.ReservationStats(); s.Open != 2 || s.OpenIDs != 4 {
+		t.Errorf("stats %+v", s)
+	}
+	runtime.KeepAlive(rs)
+}
+
+// An unreachable open reservation is counted as leaked and burned.
+func TestReservationLeak(t *testing.T) {
+	w := NewWorker10(7)
+	if _, err := w.Reserve(8); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for w.ReservationStats().Leaked == 0 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if s := w.ReservationStats(); s.Leaked != 1 || s.BurnedIDs != 8 || s.Open != 0 {
+		t.Errorf("stats %+v", s)
+	}
+	if len(w.Audit()) != 0 {
+		t.Error("Audit lists a leaked reservation")
+	}
+}
+
+// IDs generated before Reserve fails are burned.
+func TestReserveExhausted(t *testing.T) {
+	l := NewWorker7().Layout()
+	last := l.CustomEpoch + l.MaxTimeStamp()
+	w, c := fakeClockWorker(t, WorkerConfig{Layout: l}, last)
+	w.NextIDs(make([]int64, 1000))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Reserve(100)
+		done <- err
+	}()
+	c.spinning()
+	c.set(last + 1)
+	if err := <-done; !errors.Is(err, ErrEpochExhausted) {
+		t.Fatalf("Reserve = %v, want ErrEpochExhausted", err)
+	}
+	if s := w.ReservationStats(); s.BurnedIDs != 24 || s.Open != 0 {
+		t.Errorf("stats %+v", s)
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	since := time.Unix(1000, 0)
+	s := ReservationStats{BurnedIDs: 50, Since: since}
+	if r := s.BurnRate(since.Add(10 * time.Second)); r != 5 {
+		t.Errorf("BurnRate = %v, want 5", r)
+	}
+	if r := s.BurnRate(since); r != 0 {
+		t.Errorf("BurnRate at Since = %v", r)
+	}
+	if r := (ReservationStats{BurnedIDs: 5}).BurnRate(since); r != 0 {
+		t.Errorf("BurnRate without Since = %v", r)
+	}
+}
@@ -0,0 +1,154 @@
+package sanic
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// mergeRanges sorts ranges and merges any that overlap or touch. It returns
+// ErrInvalidLayout for a range whose start is after its end.
+func mergeRanges(ranges [][2]int64) ([][2]int64, error) {
+	merged := slices.Clone(ranges)
+	for _, r := range merged {
+		if r[0] > r[1] {
+			return nil, fmt.Errorf("%w: reserved range %d-%d ends before it starts",
+				ErrInvalidLayout, r[0], r[1])
+		}
+	}
+	slices.SortFunc(merged, func(a, b [2]int64) int {
+		return cmp.Compare(a[0], b[0])
+	})
+
+	out := merged[:0]
+	for _, r := range merged {
+		if n := len(out); n > 0 && r[0] <= out[n-1][1]+1 {
+			out[n-1][1] = max(out[n-1][1], r[1])
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// checkReserved returns ErrInvalidLayout if the reserved ranges cover every
+// ID the worker could issue with some tag in the layout's last tick, so that
+// skipping them would need a tick the layout cannot hold.
+func (w *Worker) checkReserved() error {
+	tick := w.CustomEpoch + w.MaxTimeStamp
+	first := w.compose(tick, 0, 0)
+	last := w.compose(tick, w.MaxTag, w.MaxSequence)
+	span := w.MaxSequence + 1 // the IDs of one tag in the tick
+	for _, r := range w.ReservedRanges {
+		lo, hi := max(r[0], first), min(r[1], last)
+		if lo > hi {
+			continue
+		}
+		// The first tag whose IDs start within the range.
+		start := first + (lo-first+span-1)/span*span
+		if start+w.MaxSequence <= hi {
+			return fmt.Errorf("%w: reserved range %d-%d covers tag %d in the layout's last tick",
+				ErrInvalidLayout, r[0], r[1], (start-first)/span)
+		}
+	}
+	return nil
+}
+
+// reservedRange returns the reserved range containing id, if any.
+func (w *Worker) reservedRange(id int64) ([2]int64, bool) {
+	rs := w.ReservedRanges
+	i := sort.Search(len(rs), func(i int) bool { return rs[i][1] >= id })
+	if i < len(rs) && rs[i][0] <= id {
+		return rs[i], true
+	}
+	return [2]int64{}, false
+}
+
+// skipReserved moves the worker's sequence, and if need be its timestamp,
+// past any reserved range holding the ID it would issue with tag at
+// timestamp, and returns the timestamp to use. When a range covers the rest
+// of the tick it waits for the clock to reach the first tick with room,
+// releasing w.mutex meanwhile if locked is set, as the wait may be long. It
+// returns the errors of waitUnlocked.
+func (w *Worker) skipReserved(timestamp, tag int64, locked bool) (int64, error) {
+	for {
+		r, ok := w.reservedRange(w.compose(timestamp, tag, w.Sequence))
+		if !ok {
+			return timestamp, nil
+		}
+		base := w.compose(timestamp, tag, 0)
+		if r[1] < base+w.MaxSequence {
+			w.Sequence = r[1] - base + 1
+			continue
+		}
+
+		// Nothing is left in this tick. The range's last tick may have room
+		// after it, so wait for that.
+		w.LastTimeStamp, w.Sequence = timestamp, w.MaxSequence
+		after := max(timestamp, w.Decompose(r[1]).Tick-1)
+		for {
+			var err error
+			if timestamp, err = w.waitUnlocked(after, locked); err != nil {
+				return 0, err
+			}
+			if timestamp > w.LastTimeStamp {
+				w.LastTimeStamp, w.Sequence = timestamp, 0
+				break
+			}
+			// Other callers generated while the lock was released; carry on
+			// after their last ID.
+			if w.Sequence < w.MaxSequence {
+				timestamp = w.LastTimeStamp
+				w.Sequence++
+				break
+			}
+			after = w.LastTimeStamp
+		}
+	}
+}
+
+// waitUnlocked is tickAfter, releasing w.mutex while it waits if locked is
+// set. The caller's per-call state is set aside meanwhile so that other
+// callers do not see it.
+//
+// If the worker was paused or closed while the mutex was released, the
+// caller waits its turn again as waitTurn does, and then for a clock
+// reading taken after it, so that no ID is stamped inside the paused
+// window. Callers that can fail get waitTurn's error instead; others panic
+// with ErrClosed, after unlocking w.mutex, once the worker is closed.
+func (w *Worker) waitUnlocked(tick int64, locked bool) (int64, error) {
+	if !locked {
+		return w.tickAfter(tick), nil
+	}
+	gen, timeWaits, canFail, genCtx := w.gen, w.timeWaits, w.canFail, w.genCtx
+	w.gen, w.timeWaits, w.canFail, w.genCtx = GenInfo{}, false, false, nil
+	ctx := genCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var start int64
+	if timeWaits {
+		start = w.clockNanos()
+	}
+	var ts int64
+	var err error
+	for {
+		w.mutex.Unlock()
+		ts = w.awaitTick(tick)
+		w.mutex.Lock()
+		if !w.paused && !w.closed {
+			break
+		}
+		if err = w.waitTurn(ctx, canFail); err != nil {
+			break
+		}
+	}
+	if timeWaits {
+		gen.WaitedFor += time.Duration(w.clockNanos() - start)
+	}
+	w.gen, w.timeWaits, w.canFail, w.genCtx = gen, timeWaits, canFail, genCtx
+	return ts, err
+}
@@ -0,0 +1,267 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that reads tick until it is set, counting its
+// reads.
+type fakeClock struct {
+	nanos, reads atomic.Int64
+	frequency    time.Duration
+}
+
+func (c *fakeClock) set(tick int64) { c.nanos.Store(tick * int64(c.frequency)) }
+
+func (c *fakeClock) now() time.Time {
+	c.reads.Add(1)
+	return time.Unix(0, c.nanos.Load())
+}
+
+// spinning waits until something reads the clock many times.
+func (c *fakeClock) spinning() {
+	for start := c.reads.Load(); c.reads.Load()-start < 1000; {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fakeClockWorker returns a worker with cfg on a fakeClock reading tick.
+func fakeClockWorker(t *testing.T, cfg WorkerConfig, tick int64) (*Worker, *fakeClock) {
+	t.Helper()
+	w, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClock{frequency: w.Frequency}
+	c.set(tick)
+	w.TimeFunc = c.now
+	if err := w.Warmup(); err != nil {
+		t.Fatal(err)
+	}
+	return w, c
+}
+
+func TestReservedWithinTick(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, _ := fakeClockWorker(t, WorkerConfig{
+		ID:             5,
+		Layout:         ref.Layout(),
+		ReservedRanges: [][2]int64{{ref.compose(tick, 0, 0), ref.compose(tick, 0, 9)}, {0, 1000}},
+	}, tick)
+	if id := w.NextID(); id != ref.compose(tick, 0, 10) {
+		t.Errorf("first ID has sequence %d, want 10", w.Decompose(id).Sequence)
+	}
+}
+
+func TestReservedStraddlesTicks(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	r := [2]int64{ref.compose(tick, 0, 100), ref.compose(tick+3, 0, 50)}
+	w, clock := fakeClockWorker(t, WorkerConfig{
+		ID: 5, Layout: ref.Layout(), ReservedRanges: [][2]int64{r},
+	}, tick)
+	for range 100 {
+		w.NextID()
+	}
+
+	got := make(chan int64)
+	go func() { got <- w.NextID() }()
+	clock.spinning()
+	// The clock is stuck inside the range, so NextID waits. It must not
+	// hold the lock while it does.
+	stats := make(chan Stats)
+	go func() { stats <- w.Stats() }()
+	select {
+	case <-stats:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stats blocked while NextID waited for a reserved range")
+	}
+	select {
+	case id := <-got:
+		t.Fatalf("NextID returned %d inside the reserved range", id)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.set(tick + 3)
+	if id := <-got; id != ref.compose(tick+3, 0, 51) {
+		t.Errorf("after the range got %+v, want tick %d sequence 51", w.Decompose(id), tick+3)
+	}
+}
+
+// reservedWaiter returns a worker whose next ID waits out a reserved range
+// ending in tick+3, and a worker with its layout and ID for composing IDs.
+func reservedWaiter(t *testing.T) (w *Worker, clock *fakeClock, ref *Worker, tick int64) {
+	t.Helper()
+	ref = NewWorker10(5)
+	tick = ref.Time()
+	r := [2]int64{ref.compose(tick, 0, 100), ref.compose(tick+3, 0, 50)}
+	w, clock = fakeClockWorker(t, WorkerConfig{
+		ID: 5, Layout: ref.Layout(), ReservedRanges: [][2]int64{r},
+	}, tick)
+	for range 100 {
+		w.NextID()
+	}
+	return w, clock, ref, tick
+}
+
+// Pause stops an ID that is waiting out a reserved range from being
+// issued until Resume, and it is then stamped after the paused window.
+func TestReservedPause(t *testing.T) {
+	w, clock, ref, tick := reservedWaiter(t)
+	got := make(chan int64)
+	go func() { got <- w.NextID() }()
+	clock.spinning()
+	w.Pause()
+	clock.set(tick + 3)
+	if !blocked(got) {
+		t.Fatal("NextID returned while paused")
+	}
+	clock.set(tick + 5)
+	if err := w.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-got; id != ref.compose(tick+5, 0, 0) {
+		t.Errorf("after Resume got %+v, want tick %d sequence 0", w.Decompose(id), tick+5)
+	}
+}
+
+func TestReservedPauseError(t *testing.T) {
+	w, clock, _, tick := reservedWaiter(t)
+	w.PausePolicy = PauseError
+	errs := make(chan error)
+	go func() {
+		_, err := w.NextIDContext(context.Background())
+		errs <- err
+	}()
+	clock.spinning()
+	w.Pause()
+	clock.set(tick + 3)
+	if err := <-errs; !errors.Is(err, ErrPaused) {
+		t.Errorf("NextIDContext = %v, want ErrPaused", err)
+	}
+}
+
+func TestReservedClose(t *testing.T) {
+	w, clock, _, tick := reservedWaiter(t)
+	errs := make(chan error)
+	go func() {
+		_, err := w.NextIDContext(context.Background())
+		errs <- err
+	}()
+	clock.spinning()
+	w.Close()
+	clock.set(tick + 3)
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Errorf("NextIDContext = %v, want ErrClosed", err)
+	}
+}
+
+func TestReservedCloseNextID(t *testing.T) {
+	w, clock, _, tick := reservedWaiter(t)
+	got := make(chan any)
+	go func() {
+		defer func() { got <- recover() }()
+		w.NextID()
+	}()
+	clock.spinning()
+	w.Close()
+	clock.set(tick + 3)
+	if r := <-got; r != ErrClosed {
+		t.Errorf("NextID after Close recovered %v, want ErrClosed", r)
+	}
+	w.Stats() // hangs if the lock was left held
+}
+
+// Waiting out a range that covers the rest of the last tick exhausts the
+// layout.
+func TestReservedExhausts(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	last := l.CustomEpoch + l.MaxTimeStamp()
+	ref := NewWorker10(5)
+	w, clock := fakeClockWorker(t, WorkerConfig{
+		ID: 5, Layout: l, ReservedRanges: [][2]int64{
+			{ref.compose(last, 0, 5), ref.compose(last, 0, l.MaxSequence())},
+		},
+	}, last)
+	for range 5 {
+		w.NextID()
+	}
+	errs := make(chan error)
+	go func() {
+		_, err := w.NextIDContext(context.Background())
+		errs <- err
+	}()
+	clock.spinning()
+	clock.set(last + 1)
+	if err := <-errs; !errors.Is(err, ErrEpochExhausted) {
+		t.Errorf("NextIDContext = %v, want ErrEpochExhausted", err)
+	}
+	if id, err := w.NextIDContext(context.Background()); !errors.Is(err, ErrEpochExhausted) {
+		t.Errorf("next NextIDContext = %+v, %v", w.Decompose(id), err)
+	}
+}
+
+func TestReservedConcurrent(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	r := [2]int64{ref.compose(tick, 0, 10), ref.compose(tick+2, 0, 10)}
+	w, clock := fakeClockWorker(t, WorkerConfig{
+		ID: 5, Layout: ref.Layout(), ReservedRanges: [][2]int64{r},
+	}, tick)
+
+	const n = 8
+	ids := make(chan int64, n*20)
+	for range n {
+		go func() {
+			for range 20 {
+				ids <- w.NextID()
+			}
+		}()
+	}
+	clock.spinning()
+	clock.set(tick + 2)
+	seen := map[int64]bool{}
+	for range n * 20 {
+		id := <-ids
+		if id >= r[0] && id <= r[1] {
+			t.Errorf("ID %d is reserved", id)
+		}
+		if seen[id] {
+			t.Errorf("ID %d issued twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestReservedLastTick(t *testing.T) {
+	l := NewWorker10(0).Layout()
+	l.IDBits, l.TagBits, l.SequenceBits, l.TimeStampBits = 4, 2, 10, 43
+	ref, err := New(WorkerConfig{ID: 1, Layout: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := ref.CustomEpoch + ref.MaxTimeStamp
+
+	// All of tag 2's IDs in the last tick leave it no way forward.
+	cfg := WorkerConfig{ID: 1, Layout: l, ReservedRanges: [][2]int64{
+		{ref.compose(last, 1, 5), ref.compose(last, 2, ref.MaxSequence)},
+	}}
+	if _, err := New(cfg); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("covering tag 2's last tick: %v, want ErrInvalidLayout", err)
+	}
+	// Part of every tag's IDs leaves room.
+	cfg.ReservedRanges = [][2]int64{
+		{ref.compose(last, 1, 5), ref.compose(last, 2, ref.MaxSequence-1)},
+	}
+	if _, err := New(cfg); err != nil {
+		t.Errorf("partial cover: %v", err)
+	}
+	if _, err := New(WorkerConfig{Layout: l, ReservedRanges: [][2]int64{{5, 1}}}); !errors.Is(err, ErrInvalidLayout) {
+		t.Errorf("backwards range: %v", err)
+	}
+}
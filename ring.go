@@ -0,0 +1,142 @@
+package sanic
+
+import (
+	"cmp"
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// ringSample is how many IDs Rebalance checks.
+const ringSample = 100000
+
+// Ring assigns IDs to nodes by consistent hashing, so that adding or
+// removing one of N nodes moves only about 1/N of the IDs. It is safe for
+// concurrent use.
+//
+// Each node is placed on the ring at replicas points, the hashes of
+// "node#i" for i in [0, replicas). An ID is hashed as 8 big-endian bytes,
+// and belongs to the node at the first point at or after its hash, wrapping
+// around. Both hashes are 64-bit FNV-1a followed by the splitmix64
+// finalizer, and will not change between versions.
+type Ring struct {
+	// IgnoreBits, if set, clears that many low bits of each ID before
+	// hashing. Set it to the layout's SequenceBits to send IDs from the same
+	// worker and tick to the same node, or to its timestamp shift to do so
+	// for all workers. It must be set before the ring is used.
+	IgnoreBits uint64
+
+	mutex    sync.RWMutex
+	replicas int
+	nodes    []string
+	points   []ringPoint
+}
+
+type ringPoint struct {
+	hash uint64
+	node string
+}
+
+// MoveReport describes how a Rebalance changed assignments, measured over
+// a fixed sample of IDs.
+type MoveReport struct {
+	Sampled  int
+	Moved    int
+	Fraction float64 // Moved / Sampled
+}
+
+// NewRing returns a Ring over nodes, each placed at replicas points; more
+// replicas spread IDs more evenly. replicas below 1 is treated as 1.
+func NewRing(nodes []string, replicas int) *Ring {
+	r := &Ring{replicas: max(replicas, 1)}
+	r.points = r.build(nodes)
+	r.nodes = slices.Clone(nodes)
+	return r
+}
+
+// Node returns the node id is assigned to, or "" if the ring has no nodes.
+func (r *Ring) Node(id int64) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.lookup(r.points, id)
+}
+
+// Nodes returns the ring's nodes.
+func (r *Ring) Nodes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return slices.Clone(r.nodes)
+}
+
+// Rebalance replaces the ring's nodes with newNodes and reports how many
+// of a fixed, evenly spread sample of IDs changed node.
+func (r *Ring) Rebalance(newNodes []string) MoveReport {
+	points := r.build(newNodes)
+
+	r.mutex.Lock()
+	old := r.points
+	r.points = points
+	r.nodes = slices.Clone(newNodes)
+	r.mutex.Unlock()
+
+	m := MoveReport{Sampled: ringSample}
+	var x uint64
+	for i := 0; i < ringSample; i++ {
+		x += 0x9e3779b97f4a7c15
+		id := int64(mix64(x) >> 1)
+		if r.lookup(old, id) != r.lookup(points, id) {
+			m.Moved++
+		}
+	}
+	m.Fraction = float64(m.Moved) / float64(m.Sampled)
+	return m
+}
+
+func (r *Ring) build(nodes []string) []ringPoint {
+	points := make([]ringPoint, 0, len(nodes)*r.replicas)
+	for _, n := range nodes {
+		for i := 0; i < r.replicas; i++ {
+			h := fnv.New64a()
+			h.Write([]byte(n + "#" + strconv.Itoa(i)))
+			points = append(points, ringPoint{mix64(h.Sum64()), n})
+		}
+	}
+	// Ties, which need a 64-bit collision, go to the smaller node name so
+	// the ring does not depend on the order nodes were given in.
+	slices.SortFunc(points, func(a, b ringPoint) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.node, b.node))
+	})
+	return points
+}
+
+func (r *Ring) lookup(points []ringPoint, id int64) string {
+	if len(points) == 0 {
+		return ""
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(id)>>r.IgnoreBits<<r.IgnoreBits)
+	h := fnv.New64a()
+	h.Write(buf[:])
+	hash := mix64(h.Sum64())
+
+	i, _ := slices.BinarySearchFunc(points, hash, func(p ringPoint, h uint64) int {
+		return cmp.Compare(p.hash, h)
+	})
+	if i == len(points) {
+		i = 0
+	}
+	return points[i].node
+}
+
+// mix64 is the splitmix64 finalizer, which spreads FNV's output over all 64
+// bits.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
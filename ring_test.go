@@ -0,0 +1,155 @@
+package sanic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func ringNodes(n int) []string {
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = fmt.Sprintf("node-%d", i)
+	}
+	return nodes
+}
+
+// ringHash is the documented hash: FNV-1a, then the splitmix64 finalizer.
+func ringHash(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// The hash is documented as stable, so assignments are recomputed here
+// independently, and a few are pinned.
+func TestRingDocumentedAlgorithm(t *testing.T) {
+	nodes := ringNodes(5)
+	r := NewRing(nodes, 10)
+	documented := func(id int64) string {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], uint64(id))
+		hash := ringHash(buf[:])
+		best, bestNode := uint64(math.MaxUint64), ""
+		first, firstNode := uint64(math.MaxUint64), ""
+		for _, n := range nodes {
+			for i := range 10 {
+				p := ringHash([]byte(n + "#" + strconv.Itoa(i)))
+				if p >= hash && p < best {
+					best, bestNode = p, n
+				}
+				if p < first {
+					first, firstNode = p, n
+				}
+			}
+		}
+		if bestNode == "" {
+			return firstNode
+		}
+		return bestNode
+	}
+	for range 10000 {
+		id := rand.Int64()
+		if got, want := r.Node(id), documented(id); got != want {
+			t.Fatalf("Node(%d) = %s, want %s", id, got, want)
+		}
+	}
+	for id, want := range map[int64]string{1: "node-0", 1 << 40: "node-1", math.MaxInt64: "node-1"} {
+		if got := r.Node(id); got != want {
+			t.Errorf("Node(%d) = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestRingSpread(t *testing.T) {
+	const nodes, n = 5, 100000
+	r := NewRing(ringNodes(nodes), 200)
+	w := NewWorker10(3)
+	counts := map[string]int{}
+	ids := make([]int64, n)
+	w.NextIDs(ids)
+	for _, id := range ids {
+		counts[r.Node(id)]++
+	}
+	for node, c := range counts {
+		if c < n/nodes*3/4 || c > n/nodes*5/4 {
+			t.Errorf("%s got %d of %d IDs", node, c, n)
+		}
+	}
+	if len(counts) != nodes {
+		t.Errorf("IDs went to %d nodes", len(counts))
+	}
+}
+
+func TestRingRebalance(t *testing.T) {
+	r := NewRing(ringNodes(10), 200)
+	ids := make([]int64, 20000)
+	for i := range ids {
+		ids[i] = rand.Int64()
+	}
+	before := make([]string, len(ids))
+	for i, id := range ids {
+		before[i] = r.Node(id)
+	}
+
+	m := r.Rebalance(ringNodes(11))
+	if m.Sampled != ringSample || m.Fraction < 0.06 || m.Fraction > 0.13 ||
+		m.Fraction != float64(m.Moved)/float64(m.Sampled) {
+		t.Errorf("adding an 11th node: %+v, want about 1/11 moved", m)
+	}
+	// Only IDs now on the new node moved.
+	for i, id := range ids {
+		if now := r.Node(id); now != before[i] && now != "node-10" {
+			t.Fatalf("ID %d moved from %s to %s", id, before[i], now)
+		}
+	}
+	if !slices.Equal(r.Nodes(), ringNodes(11)) {
+		t.Errorf("nodes %v", r.Nodes())
+	}
+
+	// Removing it again moves the same IDs back.
+	if back := r.Rebalance(ringNodes(10)); back.Moved != m.Moved {
+		t.Errorf("removing the node moved %d, adding it %d", back.Moved, m.Moved)
+	}
+	for i, id := range ids {
+		if r.Node(id) != before[i] {
+			t.Fatalf("ID %d on %s, was %s", id, r.Node(id), before[i])
+		}
+	}
+}
+
+func TestRingNodeOrder(t *testing.T) {
+	nodes := ringNodes(6)
+	a := NewRing(nodes, 50)
+	slices.Reverse(nodes)
+	b := NewRing(nodes, 50)
+	for range 10000 {
+		if id := rand.Int64(); a.Node(id) != b.Node(id) {
+			t.Fatalf("Node(%d) depends on node order", id)
+		}
+	}
+	if got := NewRing(nil, 10).Node(1); got != "" {
+		t.Errorf("empty ring returned %q", got)
+	}
+}
+
+func TestRingIgnoreBits(t *testing.T) {
+	w := NewWorker10(3)
+	r := NewRing(ringNodes(8), 100)
+	r.IgnoreBits = w.SequenceBits
+	tick := w.CustomEpoch + 123456
+	node := r.Node(w.compose(tick, 0, 0))
+	for seq := int64(1); seq <= w.MaxSequence; seq++ {
+		if got := r.Node(w.compose(tick, 0, seq)); got != node {
+			t.Fatalf("sequence %d went to %s, sequence 0 to %s", seq, got, node)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package sanic
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"iter"
+	"math"
+)
+
+// InSample reports whether id is in the sample of fraction of all IDs chosen
+// by seed. It depends on nothing but its arguments, so services that agree on
+// fraction and seed agree on the sample without sharing state, and the same
+// call gives the same answer on every run and in every release.
+//
+// The decision is fixed as follows and will not change: h is the first 8
+// bytes, big-endian, of SHA-256 over seed then id, each as 8 big-endian
+// bytes, and id is in the sample if h < fraction * 2^64, rounded down. Each
+// ID is thus in the sample with probability fraction rounded down to a
+// multiple of 2^-64, whatever its layout or worker ID. A fraction of 0 or
+// less, below 2^-64, or NaN selects no IDs; 1 or more selects all of them.
+// The sample for a smaller fraction under the same seed is a subset of the
+// sample for a larger one.
+func InSample(id int64, fraction float64, seed int64) bool {
+	switch {
+	case fraction >= 1:
+		return true
+	case !(fraction > 0):
+		return false
+	}
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(id))
+	sum := sha256.Sum256(buf[:])
+	// Exact: scaling by a power of two keeps every bit of fraction, and
+	// below 1 the result is under 2^64.
+	threshold := uint64(math.Ldexp(fraction, 64))
+	return binary.BigEndian.Uint64(sum[:8]) < threshold
+}
+
+// Sample returns an iterator over the IDs of ids that InSample chooses, in
+// their order in ids.
+func Sample(ids iter.Seq[int64], fraction float64, seed int64) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		for id := range ids {
+			if InSample(id, fraction, seed) && !yield(id) {
+				return
+			}
+		}
+	}
+}
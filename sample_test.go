@@ -0,0 +1,105 @@
+package sanic
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// The decision is pinned: these hashes were computed independently of the
+// package.
+func TestInSampleFixed(t *testing.T) {
+	for _, tc := range []struct {
+		id, seed int64
+		h        float64 // the hash divided by 2^64
+	}{
+		{1, 0, 0.48530275019},
+		{1 << 40, 42, 0.36697608805},
+		{math.MaxInt64, -7, 0.06592259330},
+		{123456789, 42, 0.10080756061},
+	} {
+		if !InSample(tc.id, tc.h+1e-9, tc.seed) || InSample(tc.id, tc.h-1e-9, tc.seed) {
+			t.Errorf("InSample(%d, seed %d) does not switch at %v", tc.id, tc.seed, tc.h)
+		}
+	}
+}
+
+func TestInSampleEdges(t *testing.T) {
+	for _, id := range []int64{1, -1, math.MinInt64, math.MaxInt64} {
+		for _, f := range []float64{0, -1, math.Inf(-1), math.NaN(), 0x1p-65} {
+			if InSample(id, f, 3) {
+				t.Errorf("InSample(%d, %v) = true", id, f)
+			}
+		}
+		for _, f := range []float64{1, 2, math.Inf(1)} {
+			if !InSample(id, f, 3) {
+				t.Errorf("InSample(%d, %v) = false", id, f)
+			}
+		}
+	}
+}
+
+func TestInSampleRate(t *testing.T) {
+	w := NewWorker10(7)
+	ids := make([]int64, 100_000)
+	w.NextIDs(ids)
+	var prev []int64
+	for _, f := range []float64{0.01, 0.1, 0.5} {
+		var got []int64
+		for _, id := range ids {
+			if InSample(id, f, 99) {
+				got = append(got, id)
+			}
+		}
+		// Within 5 standard deviations.
+		n, want := float64(len(got)), f*float64(len(ids))
+		if math.Abs(n-want) > 5*math.Sqrt(want*(1-f)) {
+			t.Errorf("fraction %v sampled %d of %d", f, len(got), len(ids))
+		}
+		// Smaller fractions sample subsets of larger ones.
+		for _, id := range prev {
+			if _, ok := slices.BinarySearch(got, id); !ok {
+				t.Fatalf("fraction %v dropped %d", f, id)
+			}
+		}
+		prev = got
+	}
+}
+
+// Different seeds choose different samples.
+func TestInSampleSeed(t *testing.T) {
+	same := 0
+	for range 1000 {
+		id := rand.Int64()
+		if InSample(id, 0.5, 1) == InSample(id, 0.5, 2) {
+			same++
+		}
+	}
+	if same < 400 || same > 600 {
+		t.Errorf("seeds 1 and 2 agreed on %d of 1000 IDs", same)
+	}
+}
+
+func TestSample(t *testing.T) {
+	ids := make([]int64, 1000)
+	NewWorker10(7).NextIDs(ids)
+	var want []int64
+	for _, id := range ids {
+		if InSample(id, 0.2, 5) {
+			want = append(want, id)
+		}
+	}
+	if got := slices.Collect(Sample(slices.Values(ids), 0.2, 5)); !slices.Equal(got, want) {
+		t.Errorf("Sample chose %d IDs, InSample %d", len(got), len(want))
+	}
+	var got []int64
+	for id := range Sample(slices.Values(ids), 0.2, 5) {
+		if got = append(got, id); len(got) == 3 {
+			break
+		}
+	}
+	if !slices.Equal(got, want[:3]) {
+		t.Errorf("Sample after break = %v, want %v", got, want[:3])
+	}
+}
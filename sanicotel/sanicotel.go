@@ -0,0 +1,57 @@
+// Package sanicotel records anomalous ID generation as OpenTelemetry span
+// events on the span in the context given to Worker.NextIDContext. It is a
+// module of its own, so that package sanic itself has no dependency on
+// OpenTelemetry.
+package sanicotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ifo/sanic"
+)
+
+// The span events Hook records.
+const (
+	// EventSequenceRollover is recorded when the sequence was exhausted, so
+	// the ID is from a later tick.
+	EventSequenceRollover = "sanic.sequence_rollover"
+	// EventClockBackwards is recorded when the clock read behind the last
+	// ID.
+	EventClockBackwards = "sanic.clock_backwards"
+)
+
+// The attributes of the events, durations in nanoseconds.
+const (
+	AttrTick   = attribute.Key("sanic.tick")
+	AttrWaited = attribute.Key("sanic.waited_ns")
+	AttrDrift  = attribute.Key("sanic.drift_ns")
+)
+
+// Hook is a Worker.OnAnomaly that adds events to the recording span in ctx,
+// if any: EventSequenceRollover with AttrTick and AttrWaited, and
+// EventClockBackwards with AttrTick, AttrWaited and AttrDrift.
+func Hook(ctx context.Context, info sanic.GenInfo) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	tick := AttrTick.Int64(info.Tick)
+	waited := AttrWaited.Int64(int64(info.WaitedFor))
+	if info.ClockRegressed {
+		span.AddEvent(EventClockBackwards, trace.WithAttributes(tick, waited,
+			AttrDrift.Int64(int64(info.ClockDrift))))
+	}
+	if info.RolledOver {
+		span.AddEvent(EventSequenceRollover,
+			trace.WithAttributes(tick, waited))
+	}
+}
+
+// Instrument sets w.OnAnomaly to Hook. It must be called before w generates
+// IDs.
+func Instrument(w *sanic.Worker) {
+	w.OnAnomaly = Hook
+}
@@ -0,0 +1,99 @@
+package sanicotel
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/ifo/sanic"
+)
+
+// stepClock moves one millisecond on every perTick reads, so a worker can
+// exhaust its sequence within a tick, and can be set back to make the
+// clock regress.
+type stepClock struct {
+	mutex   sync.Mutex
+	base    time.Time
+	reads   int
+	perTick int
+	offset  time.Duration
+}
+
+func (c *stepClock) now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reads++
+	return c.base.Add(time.Duration(c.reads/c.perTick)*time.Millisecond + c.offset)
+}
+
+func (c *stepClock) setBack(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.offset -= d
+}
+
+func TestHookEvents(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+
+	clock := &stepClock{base: time.Now().Truncate(time.Millisecond), perTick: 10000}
+	w := sanic.NewWorker10(1)
+	w.TimeFunc = clock.now
+	if err := w.Warmup(); err != nil {
+		t.Fatal(err)
+	}
+	Instrument(w)
+
+	ctx, span := tp.Tracer("sanicotel_test").Start(context.Background(), "generate")
+	// One more ID than a tick holds rolls the sequence over once.
+	for range w.Layout().MaxSequence() + 2 {
+		if _, err := w.NextIDContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.setBack(5 * time.Millisecond)
+	if _, err := w.NextIDContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("%d spans exported, want 1", len(spans))
+	}
+	events := spans[0].Events
+	if len(events) != 2 || events[0].Name != EventSequenceRollover ||
+		events[1].Name != EventClockBackwards {
+		t.Fatalf("events %+v, want a rollover then a clock regression", events)
+	}
+	attrs := func(kvs []attribute.KeyValue) map[attribute.Key]int64 {
+		m := map[attribute.Key]int64{}
+		for _, kv := range kvs {
+			m[kv.Key] = kv.Value.AsInt64()
+		}
+		return m
+	}
+	roll, back := attrs(events[0].Attributes), attrs(events[1].Attributes)
+	if roll[AttrTick] == 0 || roll[AttrWaited] <= 0 {
+		t.Errorf("rollover attributes %v", roll)
+	}
+	if drift := time.Duration(back[AttrDrift]); drift < 4*time.Millisecond || back[AttrTick] == 0 {
+		t.Errorf("clock regression attributes %v", back)
+	}
+}
+
+func TestHookWithoutSpan(t *testing.T) {
+	w := sanic.NewWorker10(1)
+	Instrument(w)
+	// No span in the context: Hook must do nothing, however IDs go.
+	Hook(context.Background(), sanic.GenInfo{RolledOver: true, ClockRegressed: true})
+	if _, err := w.NextIDContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
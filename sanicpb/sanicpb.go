@@ -0,0 +1,27 @@
+// Package sanicpb holds the SanicID message defined in sanic.proto.
+//
+// SanicID here is a plain Go mirror of the message with the field names and
+// getters protoc-gen-go gives it, so that sanic's conversions do not depend
+// on the protobuf runtime. Services that marshal it with protobuf should
+// generate their own code from sanic.proto and copy the two fields across.
+package sanicpb
+
+// SanicID mirrors the SanicID message in sanic.proto.
+type SanicID struct {
+	Id  int64  // sfixed64 id = 1
+	Str string // string str = 2
+}
+
+func (x *SanicID) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SanicID) GetStr() string {
+	if x != nil {
+		return x.Str
+	}
+	return ""
+}
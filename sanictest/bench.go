@@ -0,0 +1,232 @@
+package sanictest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+// The generation strategies RunContentionBench can compare.
+const (
+	// ImplMutex calls Worker.NextID for every ID.
+	ImplMutex = "mutex"
+	// ImplBatch reserves blocks of benchBlock IDs at a time with
+	// Worker.NextIDs.
+	ImplBatch = "batch"
+	// ImplBuffered hands IDs out of a BufferedWorker.
+	ImplBuffered = "buffered"
+)
+
+// benchBlock is the block size for ImplBatch, and the number of IDs timed
+// together when measuring lock wait.
+const benchBlock = 64
+
+// ContentionConfig configures RunContentionBench.
+type ContentionConfig struct {
+	// Preset names the layout to use, default "NewWorker10".
+	Preset string
+	// Processes is how many workers run at once, each with its own worker
+	// ID as separate processes would have. They run in this process, so
+	// they compete for CPU but not for a lock. Default 1.
+	Processes int
+	// Goroutines is how many goroutines share each worker. Default 1.
+	Goroutines int
+	// Duration is how long each implementation runs.
+	Duration time.Duration
+	// Implementations lists what to compare, default all of them.
+	Implementations []string
+}
+
+// ContentionReport is the result of RunContentionBench, one row per
+// implementation in the order requested.
+type ContentionReport struct {
+	Config ContentionConfig
+	Rows   []ContentionRow
+}
+
+// ContentionRow is the result for one implementation.
+type ContentionRow struct {
+	Implementation string
+	IDs            int64
+	Rate           float64 // IDs per second, across all workers
+	// LockWait is the mean time a goroutine waited for each ID. Under
+	// contention this is mostly time spent waiting for the worker's lock.
+	LockWait time.Duration
+	// Rollovers counts sequence exhaustions that made a worker wait for the
+	// next tick, summed over all workers.
+	Rollovers int64
+}
+
+var errBadBenchConfig = errors.New("sanictest: ContentionConfig needs positive Duration")
+
+// RunContentionBench runs each implementation in turn against fresh
+// workers for cfg.Duration, with cfg.Goroutines goroutines on each of
+// cfg.Processes workers, and reports the throughput each achieved.
+func RunContentionBench(cfg ContentionConfig) (ContentionReport, error) {
+	if cfg.Duration <= 0 {
+		return ContentionReport{}, errBadBenchConfig
+	}
+	if cfg.Preset == "" {
+		cfg.Preset = "NewWorker10"
+	}
+	if cfg.Processes <= 0 {
+		cfg.Processes = 1
+	}
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 1
+	}
+	if len(cfg.Implementations) == 0 {
+		cfg.Implementations = []string{ImplMutex, ImplBatch, ImplBuffered}
+	}
+
+	var layout *sanic.Layout
+	for _, p := range sanic.Presets() {
+		if p.Name == cfg.Preset {
+			layout = &p.Layout
+			break
+		}
+	}
+	if layout == nil {
+		return ContentionReport{}, fmt.Errorf("sanictest: unknown preset %q", cfg.Preset)
+	}
+
+	r := ContentionReport{Config: cfg}
+	for _, impl := range cfg.Implementations {
+		switch impl {
+		case ImplMutex, ImplBatch, ImplBuffered:
+		default:
+			return ContentionReport{}, fmt.Errorf("sanictest: unknown implementation %q", impl)
+		}
+		workers := make([]*sanic.Worker, cfg.Processes)
+		for i := range workers {
+			w, err := sanic.New(sanic.WorkerConfig{ID: int64(i), Layout: *layout})
+			if err != nil {
+				return ContentionReport{}, err
+			}
+			workers[i] = w
+		}
+		r.Rows = append(r.Rows, runContention(impl, workers, cfg))
+	}
+	return r, nil
+}
+
+// runContention runs one implementation against workers.
+func runContention(impl string, workers []*sanic.Worker, cfg ContentionConfig) ContentionRow {
+	var ids, waited atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+
+	var buffers []*sanic.BufferedWorker
+	for _, w := range workers {
+		var next func([]int64)
+		switch impl {
+		case ImplMutex:
+			next = func(block []int64) {
+				for i := range block {
+					block[i] = w.NextID()
+				}
+			}
+		case ImplBatch:
+			next = w.NextIDs
+		case ImplBuffered:
+			b := sanic.Buffered(w, 4*benchBlock*cfg.Goroutines, benchBlock*cfg.Goroutines)
+			buffers = append(buffers, b)
+			next = func(block []int64) {
+				for i := range block {
+					block[i] = b.NextID()
+				}
+			}
+		}
+		for g := 0; g < cfg.Goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				block := make([]int64, benchBlock)
+				var n int64
+				var d time.Duration
+				for t := time.Now(); t.Before(deadline); {
+					next(block)
+					now := time.Now()
+					d += now.Sub(t)
+					n += benchBlock
+					t = now
+				}
+				ids.Add(n)
+				waited.Add(int64(d))
+			}()
+		}
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	for _, b := range buffers {
+		b.Close()
+	}
+
+	row := ContentionRow{
+		Implementation: impl,
+		IDs:            ids.Load(),
+		Rate:           float64(ids.Load()) / elapsed.Seconds(),
+	}
+	if row.IDs > 0 {
+		row.LockWait = time.Duration(waited.Load() / row.IDs)
+	}
+	for _, w := range workers {
+		row.Rollovers += w.Stats().Waits
+	}
+	return row
+}
+
+// WriteTable writes the report as an aligned table for comparing the
+// implementations.
+func (r ContentionReport) WriteTable(out io.Writer) error {
+	_, err := fmt.Fprintf(out, "%s, %d workers x %d goroutines, %s each\n",
+		r.Config.Preset, r.Config.Processes, r.Config.Goroutines, r.Config.Duration)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "implementation\tids\tids/s\tlock wait/id\trollovers\t\n")
+	for _, row := range r.Rows {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%s\t%d\t\n", row.Implementation,
+			row.IDs, row.Rate, row.LockWait, row.Rollovers)
+	}
+	return tw.Flush()
+}
+
+// matrixGoroutines are the goroutine counts RunBenchMatrix covers.
+var matrixGoroutines = []int{1, 2, 4, 8}
+
+// RunBenchMatrix runs ImplMutex on preset with 1, 2, 4 and 8 goroutines,
+// first all sharing one worker and then each with a worker of its own, for
+// d per run, so that changes to the generation path can be compared with
+// and without lock contention. The contended reports come first.
+func RunBenchMatrix(preset string, d time.Duration) ([]ContentionReport, error) {
+	var out []ContentionReport
+	for _, contended := range []bool{true, false} {
+		for _, n := range matrixGoroutines {
+			cfg := ContentionConfig{
+				Preset:          preset,
+				Processes:       n,
+				Goroutines:      1,
+				Duration:        d,
+				Implementations: []string{ImplMutex},
+			}
+			if contended {
+				cfg.Processes, cfg.Goroutines = 1, n
+			}
+			r, err := RunContentionBench(cfg)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
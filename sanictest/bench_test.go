@@ -0,0 +1,75 @@
+package sanictest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunContentionBench(t *testing.T) {
+	r, err := RunContentionBench(ContentionConfig{
+		Processes:  2,
+		Goroutines: 2,
+		Duration:   20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Config.Preset != "NewWorker10" || len(r.Rows) != 3 {
+		t.Fatalf("report %+v", r)
+	}
+	for i, impl := range []string{ImplMutex, ImplBatch, ImplBuffered} {
+		row := r.Rows[i]
+		if row.Implementation != impl || row.IDs <= 0 || row.Rate <= 0 || row.LockWait <= 0 {
+			t.Errorf("row %d: %+v", i, row)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := r.WriteTable(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || lines[0] != "NewWorker10, 2 workers x 2 goroutines, 20ms each" ||
+		!strings.Contains(lines[1], "lock wait/id") || !strings.Contains(lines[4], ImplBuffered) {
+		t.Errorf("table:\n%s", out.String())
+	}
+}
+
+func TestRunContentionBenchConfig(t *testing.T) {
+	for _, cfg := range []ContentionConfig{
+		{},
+		{Duration: time.Millisecond, Preset: "NewWorker11"},
+		{Duration: time.Millisecond, Implementations: []string{"atomic"}},
+		{Duration: time.Millisecond, Preset: "NewWorker9", Processes: 8}, // 2 ID bits
+	} {
+		if _, err := RunContentionBench(cfg); err == nil {
+			t.Errorf("RunContentionBench(%+v) accepted", cfg)
+		}
+	}
+}
+
+func TestRunBenchMatrix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs 8 benchmarks")
+	}
+	reports, err := RunBenchMatrix("NewWorker10", 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2*len(matrixGoroutines) {
+		t.Fatalf("%d reports", len(reports))
+	}
+	for i, r := range reports {
+		n := matrixGoroutines[i%len(matrixGoroutines)]
+		contended := i < len(matrixGoroutines)
+		if (contended && (r.Config.Processes != 1 || r.Config.Goroutines != n)) ||
+			(!contended && (r.Config.Processes != n || r.Config.Goroutines != 1)) {
+			t.Errorf("report %d config %+v", i, r.Config)
+		}
+		if len(r.Rows) != 1 || r.Rows[0].Implementation != ImplMutex || r.Rows[0].IDs == 0 {
+			t.Errorf("report %d rows %+v", i, r.Rows)
+		}
+	}
+}
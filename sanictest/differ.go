@@ -0,0 +1,131 @@
+package sanictest
+
+import (
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+// Generator is what Differ compares: *sanic.Worker, or a reimplementation
+// of it.
+type Generator interface {
+	NextID() int64
+	Decompose(id int64) sanic.Parts
+}
+
+// NewGenerator builds a Generator that reads the given clock instead of
+// the wall clock.
+type NewGenerator func(clock func() time.Time) (Generator, error)
+
+// ClockScript is the input Differ replays: the IDs to generate and the
+// clock to generate them under.
+type ClockScript struct {
+	Steps []ClockStep
+	// Resolution is how far the clock moves on each extra read while one ID
+	// is generated, as when a worker waits for the next tick. It should be
+	// the layout's Frequency; default one millisecond.
+	Resolution time.Duration
+}
+
+// ClockStep generates IDs IDs with the clock reading At, or later if the
+// clock has already moved past it.
+type ClockStep struct {
+	At  time.Time
+	IDs int
+}
+
+// Mismatch is an ID on which two Generators disagreed.
+type Mismatch struct {
+	Step  int // index into ClockScript.Steps
+	Index int // index of the ID over the whole script
+	A, B  int64
+	// APart and BPart decompose A and B with their own Generators.
+	APart, BPart sanic.Parts
+}
+
+// WorkerGenerator returns a NewGenerator for workers built by sanic.New
+// from cfg, warmed up on the injected clock.
+func WorkerGenerator(cfg sanic.WorkerConfig) NewGenerator {
+	return func(clock func() time.Time) (Generator, error) {
+		w, err := sanic.New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		w.TimeFunc = clock
+		if err := w.Warmup(); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+}
+
+// Differ builds a Generator with a and with b, drives both through script
+// on identical scripted clocks, and returns the IDs on which they differ,
+// first divergence first, up to 100 of them.
+//
+// Each Generator gets its own clock, which reads the current step's time
+// the first time it is read for an ID and then moves Resolution further on
+// each read, so both see the same clock as long as they read it the same
+// number of times. The clock never moves backwards between steps.
+func Differ(a, b NewGenerator, script ClockScript) ([]Mismatch, error) {
+	res := script.Resolution
+	if res <= 0 {
+		res = time.Millisecond
+	}
+	ca, cb := &scriptClock{res: res}, &scriptClock{res: res}
+	if len(script.Steps) > 0 {
+		ca.now = script.Steps[0].At
+		cb.now = script.Steps[0].At
+	}
+	ga, err := a(ca.read)
+	if err != nil {
+		return nil, err
+	}
+	gb, err := b(cb.read)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Mismatch
+	index := 0
+	for s, step := range script.Steps {
+		ca.advance(step.At)
+		cb.advance(step.At)
+		for i := 0; i < step.IDs; i++ {
+			ca.reads, cb.reads = 0, 0
+			idA, idB := ga.NextID(), gb.NextID()
+			if idA != idB && len(out) < maxViolations {
+				out = append(out, Mismatch{
+					Step:  s,
+					Index: index,
+					A:     idA,
+					B:     idB,
+					APart: ga.Decompose(idA),
+					BPart: gb.Decompose(idB),
+				})
+			}
+			index++
+		}
+	}
+	return out, nil
+}
+
+type scriptClock struct {
+	now   time.Time
+	res   time.Duration
+	reads int
+}
+
+func (c *scriptClock) read() time.Time {
+	c.reads++
+	if c.reads > 1 {
+		c.now = c.now.Add(c.res)
+	}
+	return c.now
+}
+
+func (c *scriptClock) advance(t time.Time) {
+	if t.After(c.now) {
+		c.now = t
+	}
+}
@@ -0,0 +1,95 @@
+package sanictest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+// refGenerator reimplements generation for one layout, for Differ to check
+// against the real worker. After the sequence rolls over it starts the next
+// tick at sequence wrapTo, which is 0 in a faithful copy.
+type refGenerator struct {
+	clock     func() time.Time
+	layout    sanic.Layout
+	workerID  int64
+	last, seq int64
+	wrapTo    int64
+}
+
+func refGenerator10(workerID, wrapTo int64) NewGenerator {
+	return func(clock func() time.Time) (Generator, error) {
+		return &refGenerator{
+			clock:    clock,
+			layout:   sanic.NewWorker10(0).Layout(),
+			workerID: workerID,
+			last:     -1,
+			wrapTo:   wrapTo,
+		}, nil
+	}
+}
+
+func (g *refGenerator) tick() int64 {
+	return g.clock().UnixNano() / int64(g.layout.Frequency)
+}
+
+func (g *refGenerator) NextID() int64 {
+	l := g.layout
+	if now := g.tick(); now > g.last {
+		g.last, g.seq = now, 0
+	} else if g.seq++; g.seq > l.MaxSequence() {
+		for now <= g.last {
+			now = g.tick()
+		}
+		g.last, g.seq = now, g.wrapTo
+	}
+	return (g.last-l.CustomEpoch)<<(l.TotalBits()-1-l.TimeStampBits) |
+		g.workerID<<l.SequenceBits | g.seq
+}
+
+func (g *refGenerator) Decompose(id int64) sanic.Parts {
+	return g.layout.Decompose(id)
+}
+
+// differScript generates past a sequence rollover, then again after a gap.
+func differScript() ClockScript {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return ClockScript{Steps: []ClockStep{
+		{At: start, IDs: 5000},
+		{At: start.Add(time.Second), IDs: 10},
+	}}
+}
+
+func TestDifferFaithful(t *testing.T) {
+	worker := WorkerGenerator(sanic.WorkerConfig{ID: 3, Layout: sanic.NewWorker10(0).Layout()})
+	got, err := Differ(worker, refGenerator10(3, 0), differScript())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("%d mismatches with a faithful reimplementation, first %+v", len(got), got[0])
+	}
+}
+
+func TestDifferCatchesOffByOne(t *testing.T) {
+	worker := WorkerGenerator(sanic.WorkerConfig{ID: 3, Layout: sanic.NewWorker10(0).Layout()})
+	got, err := Differ(worker, refGenerator10(3, 1), differScript())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 {
+		t.Fatal("Differ missed a reimplementation that restarts ticks at sequence 1")
+	}
+	// The first ID after the rollover is the first to differ, and only
+	// the rest of that tick does.
+	m := got[0]
+	if m.Step != 0 || m.Index != 4096 || m.APart.Sequence != 0 || m.BPart.Sequence != 1 ||
+		m.APart.Tick != m.BPart.Tick {
+		t.Errorf("first mismatch %+v", m)
+	}
+	// 904 IDs differ, of which Differ reports the first maxViolations.
+	if n := len(got); n != maxViolations || got[n-1].Index != 4096+n-1 {
+		t.Errorf("%d mismatches, want %d in a run from the rollover", n, maxViolations)
+	}
+}
@@ -0,0 +1,150 @@
+package sanictest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+// SoakConfig configures Soak.
+type SoakConfig struct {
+	Worker     *sanic.Worker // defaults to sanic.NewWorker10(0)
+	Duration   time.Duration
+	Goroutines int
+	// MinRate is the lowest acceptable IDs/second. Zero disables the check.
+	MinRate float64
+	// Window is how far back duplicates are checked, default one second.
+	Window time.Duration
+}
+
+// SoakReport is the result of a Soak run.
+type SoakReport struct {
+	Duration time.Duration
+	IDs      int64
+	Rate     float64 // IDs per second
+	// Waits counts ticks whose sequence was exhausted, each of which forced
+	// the worker to wait for the next tick.
+	Waits int64
+	// Unverified counts IDs that arrived too late to check for duplicates.
+	Unverified int64
+	// Violations holds the first maxViolations problems found;
+	// TotalViolations counts all of them.
+	Violations      []Violation
+	TotalViolations int64
+}
+
+// Failed reports whether the run found any violations.
+func (r SoakReport) Failed() bool {
+	return r.TotalViolations > 0
+}
+
+// Violation describes one failed check.
+type Violation struct {
+	Kind      string // "duplicate", "order", "worker" or "rate"
+	ID        int64
+	Goroutine int
+	Detail    string
+}
+
+const (
+	maxViolations = 100
+	batchSize     = 1024
+)
+
+var errBadConfig = errors.New("sanictest: SoakConfig needs positive Duration and Goroutines")
+
+// Soak generates IDs with cfg.Goroutines goroutines sharing one worker for
+// cfg.Duration and checks that they are unique, strictly increasing within
+// each goroutine, all stamped with the worker's ID, and produced at no less
+// than cfg.MinRate. Memory use is bounded by the goroutine count and the
+// verifier window, not by the duration.
+func Soak(cfg SoakConfig) (SoakReport, error) {
+	if cfg.Duration <= 0 || cfg.Goroutines <= 0 {
+		return SoakReport{}, errBadConfig
+	}
+	w := cfg.Worker
+	if w == nil {
+		w = sanic.NewWorker10(0)
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = time.Second
+	}
+
+	type batch struct {
+		goroutine int
+		ids       []int64
+	}
+	free := make(chan []int64, 2*cfg.Goroutines)
+	for i := 0; i < cap(free); i++ {
+		free <- make([]int64, 0, batchSize)
+	}
+	batches := make(chan batch, cap(free))
+
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+	var wg sync.WaitGroup
+	for g := 0; g < cfg.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				ids := (<-free)[:0]
+				for i := 0; i < batchSize; i++ {
+					ids = append(ids, w.NextID())
+				}
+				batches <- batch{g, ids}
+			}
+		}(g)
+	}
+	go func() {
+		wg.Wait()
+		close(batches)
+	}()
+
+	var r SoakReport
+	violate := func(v Violation) {
+		r.TotalViolations++
+		if len(r.Violations) < maxViolations {
+			r.Violations = append(r.Violations, v)
+		}
+	}
+	v := NewVerifier(w, cfg.Window)
+	last := make([]int64, cfg.Goroutines)
+	for b := range batches {
+		for _, id := range b.ids {
+			r.IDs++
+			if id <= last[b.goroutine] {
+				violate(Violation{"order", id, b.goroutine,
+					fmt.Sprintf("follows %d", last[b.goroutine])})
+			}
+			last[b.goroutine] = id
+
+			p := w.Decompose(id)
+			if p.WorkerID != w.ID {
+				violate(Violation{"worker", id, b.goroutine,
+					fmt.Sprintf("worker ID %d, want %d", p.WorkerID, w.ID)})
+			}
+			if p.Sequence == w.Layout().MaxSequence() {
+				r.Waits++
+			}
+			switch dup, ok := v.Add(id); {
+			case !ok:
+				r.Unverified++
+			case dup:
+				violate(Violation{"duplicate", id, b.goroutine, ""})
+			}
+		}
+		free <- b.ids
+	}
+
+	r.Duration = time.Since(start)
+	r.Rate = float64(r.IDs) / r.Duration.Seconds()
+	if cfg.MinRate > 0 && r.Rate < cfg.MinRate {
+		violate(Violation{Kind: "rate",
+			Detail: fmt.Sprintf("%.0f ids/s, want at least %.0f", r.Rate, cfg.MinRate)})
+	}
+	return r, nil
+}
@@ -0,0 +1,67 @@
+package sanictest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+func TestSoak(t *testing.T) {
+	r, err := Soak(SoakConfig{
+		Worker:     sanic.NewWorker10(5),
+		Duration:   200 * time.Millisecond,
+		Goroutines: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Failed() || r.IDs == 0 || r.Rate <= 0 {
+		t.Errorf("report %+v", r)
+	}
+}
+
+func TestSoakMinRate(t *testing.T) {
+	r, err := Soak(SoakConfig{Duration: 20 * time.Millisecond, Goroutines: 1, MinRate: 1e12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Failed() || r.Violations[len(r.Violations)-1].Kind != "rate" {
+		t.Errorf("unreachable rate passed: %+v", r.Violations)
+	}
+}
+
+func TestSoakConfig(t *testing.T) {
+	for _, cfg := range []SoakConfig{{Goroutines: 1}, {Duration: time.Second}} {
+		if _, err := Soak(cfg); err == nil {
+			t.Errorf("Soak(%+v) accepted", cfg)
+		}
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	w := sanic.NewWorker10(5)
+	v := NewVerifier(w, 0)
+	ids := make([]int64, 10000)
+	w.NextIDs(ids)
+	for _, id := range ids {
+		if dup, ok := v.Add(id); dup || !ok {
+			t.Fatalf("fresh ID %d: duplicate %v, verified %v", id, dup, ok)
+		}
+	}
+	if dup, ok := v.Add(ids[len(ids)-1]); !dup || !ok {
+		t.Errorf("repeated ID: duplicate %v, verified %v", dup, ok)
+	}
+
+	// An ID more than the window behind the newest is too old to check.
+	p := w.Decompose(ids[len(ids)-1])
+	layout := w.Layout()
+	ahead := (p.Tick+minSlots+1-layout.CustomEpoch)<<(layout.TotalBits()-1-layout.TimeStampBits) |
+		p.WorkerID<<layout.SequenceBits
+	if dup, ok := v.Add(ahead); dup || !ok {
+		t.Fatalf("ID ahead of the window: duplicate %v, verified %v", dup, ok)
+	}
+	if _, ok := v.Add(ids[0]); ok {
+		t.Error("ID behind the window was verified")
+	}
+}
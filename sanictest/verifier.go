@@ -0,0 +1,72 @@
+// Package sanictest provides tools for qualifying sanic workers, such as a
+// bounded-memory uniqueness verifier and a soak test harness.
+package sanictest
+
+import (
+	"time"
+
+	"github.com/ifo/sanic"
+)
+
+// Verifier checks a stream of IDs from one worker for duplicates using
+// memory bounded by its window rather than by the number of IDs.
+//
+// It keeps one bitset of sequences per tick for the most recent window of
+// ticks. IDs whose tick has already left the window cannot be checked and
+// are reported as unverified instead.
+type Verifier struct {
+	w       *sanic.Worker
+	slots   []slot
+	maxTick int64
+}
+
+// minSlots keeps coarse layouts, whose ticks are seconds long, from evicting
+// ticks that goroutines are still reporting on.
+const minSlots = 64
+
+type slot struct {
+	tick int64
+	seen []uint64
+}
+
+// NewVerifier returns a Verifier for IDs generated by w that remembers at
+// least window worth of ticks, and never fewer than minSlots.
+func NewVerifier(w *sanic.Worker, window time.Duration) *Verifier {
+	n := int64(window / w.Layout().Frequency)
+	if n < minSlots {
+		n = minSlots
+	}
+	return &Verifier{
+		w:       w,
+		slots:   make([]slot, n),
+		maxTick: -1 << 63,
+	}
+}
+
+// Add records id. It returns duplicate if id was already added, and
+// verified false if id is too old to be checked.
+func (v *Verifier) Add(id int64) (duplicate, verified bool) {
+	p := v.w.Decompose(id)
+	n := int64(len(v.slots))
+	if v.maxTick > -1<<63 && p.Tick <= v.maxTick-n {
+		return false, false
+	}
+	if p.Tick > v.maxTick {
+		v.maxTick = p.Tick
+	}
+
+	s := &v.slots[(p.Tick%n+n)%n]
+	if s.seen == nil {
+		s.seen = make([]uint64, (1<<v.w.Layout().SequenceBits+63)/64)
+	} else if s.tick != p.Tick {
+		clear(s.seen)
+	}
+	s.tick = p.Tick
+
+	word, bit := p.Sequence/64, uint64(1)<<(p.Sequence%64)
+	if s.seen[word]&bit != 0 {
+		return true, true
+	}
+	s.seen[word] |= bit
+	return false, true
+}
@@ -0,0 +1,35 @@
+package sanic
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+)
+
+// ShardKey derives a shard index in [0, shards) from id.
+//
+// The embedded timestamp is truncated to bucket (one tick of the worker's
+// Frequency if bucket <= 0), then the bucket number, worker ID and sequence
+// are each written as 8 big-endian bytes and hashed with 64-bit FNV-1a. The
+// index is the hash modulo shards. The result depends only on id, the
+// layout, shards and bucket, so every process computes the same key.
+//
+// ShardKey panics if shards is not positive.
+func (w *Worker) ShardKey(id int64, shards int, bucket time.Duration) int {
+	if shards <= 0 {
+		panic("sanic: ShardKey shards must be positive")
+	}
+	if bucket <= 0 {
+		bucket = w.Frequency
+	}
+	p := w.Decompose(id)
+
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:], uint64(p.Time.UnixNano()/int64(bucket)))
+	binary.BigEndian.PutUint64(buf[8:], uint64(p.WorkerID))
+	binary.BigEndian.PutUint64(buf[16:], uint64(p.Sequence))
+
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return int(h.Sum64() % uint64(shards))
+}
@@ -0,0 +1,56 @@
+package sanic
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"testing"
+	"time"
+)
+
+// The algorithm is documented, so other languages can compute the same keys;
+// this recomputes it independently.
+func TestShardKeyDocumentedAlgorithm(t *testing.T) {
+	w := NewWorker10(3)
+	id := w.compose(w.CustomEpoch+1_000_000, 0, 17)
+	p := w.Decompose(id)
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[0:], uint64(p.Time.UnixNano()/int64(time.Minute)))
+	binary.BigEndian.PutUint64(buf[8:], 3)
+	binary.BigEndian.PutUint64(buf[16:], 17)
+	h := fnv.New64a()
+	h.Write(buf[:])
+	if got, want := w.ShardKey(id, 7, time.Minute), int(h.Sum64()%7); got != want {
+		t.Errorf("ShardKey = %d, want %d", got, want)
+	}
+	// Any worker with the layout computes the same key.
+	if other := NewWorker10(40).ShardKey(id, 7, time.Minute); other != w.ShardKey(id, 7, time.Minute) {
+		t.Error("ShardKey depends on the decoding worker")
+	}
+}
+
+func TestShardKeySpread(t *testing.T) {
+	w := NewWorker10(3)
+	const shards, n = 8, 80000
+	counts := make([]int, shards)
+	for range n {
+		k := w.ShardKey(w.NextID(), shards, 0)
+		if k < 0 || k >= shards {
+			t.Fatalf("key %d out of range", k)
+		}
+		counts[k]++
+	}
+	for i, c := range counts {
+		if c < n/shards*9/10 || c > n/shards*11/10 {
+			t.Errorf("shard %d got %d of %d IDs", i, c, n)
+		}
+	}
+}
+
+func TestShardKeyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("ShardKey with no shards did not panic")
+		}
+	}()
+	NewWorker10(3).ShardKey(1, 0, 0)
+}
@@ -0,0 +1,85 @@
+package sanic
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+// SimReport describes how a worker would have handled a trace of request
+// times.
+type SimReport struct {
+	IDs       int64
+	Intervals int64 // ticks in which at least one ID was generated
+	// PeakPerInterval is the most IDs generated in any one tick, out of
+	// Capacity possible.
+	PeakPerInterval int64
+	Capacity        int64
+	// ForcedWaits counts sequence exhaustions that made the worker wait for
+	// the next tick, and TotalWait sums how long those waits were.
+	ForcedWaits int64
+	TotalWait   time.Duration
+	// Blocked reports whether any ID had to wait, that is, whether
+	// uniqueness required blocking somewhere in the trace.
+	Blocked bool
+}
+
+// Simulate replays a trace of request times against a worker built from
+// cfg, with no real clock involved, and reports where it would have had to
+// wait. Each request is served at its own time, or once the worker is done
+// waiting for earlier ones if that is later.
+func Simulate(cfg WorkerConfig, timestamps []time.Time) (SimReport, error) {
+	return SimulateSeq(cfg, slices.Values(timestamps))
+}
+
+// SimulateSeq is Simulate for a stream of request times.
+func SimulateSeq(cfg WorkerConfig, timestamps iter.Seq[time.Time]) (SimReport, error) {
+	w, err := New(cfg)
+	if err != nil {
+		return SimReport{}, err
+	}
+
+	// The simulated clock only jumps forward to the next tick when the
+	// worker reads it more than once for one ID, which it does only while
+	// waiting for the next tick.
+	var now time.Time
+	reads := 0
+	var r SimReport
+	w.TimeFunc = func() time.Time {
+		reads++
+		if reads > 1 {
+			next := w.Layout().tickTime(w.Layout().tick(now) + 1)
+			r.TotalWait += next.Sub(now)
+			now = next
+		}
+		return now
+	}
+
+	started := false
+	var lastTick, count int64
+	for t := range timestamps {
+		if !started {
+			now, started = t, true
+			if err := w.Warmup(); err != nil {
+				return SimReport{}, err
+			}
+		} else if t.After(now) {
+			now = t
+		}
+		reads = 0
+		tick := w.Decompose(w.NextID()).Tick
+
+		r.IDs++
+		if tick != lastTick || r.Intervals == 0 {
+			r.Intervals++
+			lastTick, count = tick, 0
+		}
+		count++
+		r.PeakPerInterval = max(r.PeakPerInterval, count)
+	}
+
+	r.Capacity = w.MaxSequence + 1
+	r.ForcedWaits = w.Stats().Waits
+	r.Blocked = r.ForcedWaits > 0
+	return r, nil
+}
@@ -0,0 +1,82 @@
+package sanic
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+var simStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func simAt(d ...time.Duration) []time.Time {
+	ts := make([]time.Time, len(d))
+	for i := range d {
+		ts[i] = simStart.Add(d[i])
+	}
+	return ts
+}
+
+func TestSimulateQuiet(t *testing.T) {
+	var trace []time.Time
+	for i := range 10 {
+		trace = append(trace, simAt(time.Duration(i)*100*time.Millisecond)...)
+	}
+	trace = append(trace, simAt(2*time.Second, 2*time.Second, 2500*time.Millisecond)...)
+	r, err := Simulate(NewWorker7().Config(), trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SimReport{IDs: 13, Intervals: 2, PeakPerInterval: 10, Capacity: 1024}
+	if r != want {
+		t.Errorf("report %+v, want %+v", r, want)
+	}
+}
+
+// A burst of 1030 requests half way through a 1s tick fills its 1024 IDs,
+// and the rest wait half a second for the next tick. Later requests that are
+// earlier than the worker's clock are served at its time.
+func TestSimulateBurst(t *testing.T) {
+	var trace []time.Time
+	for range 1030 {
+		trace = append(trace, simAt(500*time.Millisecond)...)
+	}
+	trace = append(trace, simAt(700*time.Millisecond, 1200*time.Millisecond)...)
+	r, err := Simulate(NewWorker7().Config(), trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SimReport{
+		IDs:             1032,
+		Intervals:       2,
+		PeakPerInterval: 1024,
+		Capacity:        1024,
+		ForcedWaits:     1,
+		TotalWait:       500 * time.Millisecond,
+		Blocked:         true,
+	}
+	if r != want {
+		t.Errorf("report %+v, want %+v", r, want)
+	}
+
+	seq, err := SimulateSeq(NewWorker7().Config(), slices.Values(trace))
+	if err != nil || seq != r {
+		t.Errorf("SimulateSeq = %+v, %v; Simulate %+v", seq, err, r)
+	}
+}
+
+func TestSimulateErrors(t *testing.T) {
+	cfg := NewWorker10(1).Config()
+	cfg.ID = 64
+	if _, err := Simulate(cfg, simAt(0)); !errors.Is(err, ErrInvalidWorkerID) {
+		t.Errorf("worker ID 64: %v", err)
+	}
+	// The trace starts before the layout's epoch.
+	early := []time.Time{NewWorker10(1).Layout().Epoch().Add(-time.Hour)}
+	if _, err := Simulate(NewWorker10(1).Config(), early); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Errorf("trace before epoch: %v", err)
+	}
+	if r, err := Simulate(NewWorker10(1).Config(), nil); err != nil || r.IDs != 0 {
+		t.Errorf("empty trace: %+v, %v", r, err)
+	}
+}
@@ -0,0 +1,52 @@
+package sanic
+
+import (
+	"slices"
+	"time"
+)
+
+// EstimateSkew estimates how far a peer's clock is ahead of the local one
+// from IDs the peer generated and the local times they were received. The
+// IDs are decoded with localWorker's layout, so the peer must use the same
+// one. remoteIDs[i] must have been received at receivedAt[i]; extra
+// elements in the longer slice are ignored.
+//
+// Each ID gives an offset, its receipt time minus the middle of the tick it
+// was stamped with, which is the network delay minus the skew. Delays only
+// ever add, so the quarter of the offsets with the least delay are kept and
+// the rest discarded as delayed, and skew is minus their median. A positive
+// skew means the peer's clock is ahead.
+//
+// confidence is between 0 and 1. It is the fraction of the kept offsets
+// within one tick of their median, scaled down when there are few of them:
+// by k/(k+4) for k kept offsets. With no IDs both results are 0.
+func EstimateSkew(localWorker *Worker, remoteIDs []int64,
+	receivedAt []time.Time) (skew time.Duration, confidence float64) {
+
+	n := min(len(remoteIDs), len(receivedAt))
+	if n == 0 {
+		return 0, 0
+	}
+	l := localWorker.Layout()
+	half := l.Frequency / 2
+
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		generated := l.Timestamp(remoteIDs[i]).Add(half)
+		offsets[i] = receivedAt[i].Sub(generated)
+	}
+	slices.Sort(offsets)
+	kept := offsets[:max((n+3)/4, 1)]
+	median := kept[len(kept)/2]
+	if len(kept)%2 == 0 {
+		median = (kept[len(kept)/2-1] + median) / 2
+	}
+
+	agree := 0
+	for _, o := range kept {
+		if d := o - median; d <= l.Frequency && d >= -l.Frequency {
+			agree++
+		}
+	}
+	return -median, float64(agree) / float64(len(kept)+4)
+}
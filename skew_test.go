@@ -0,0 +1,67 @@
+package sanic
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// skewTrace returns n IDs from a peer whose clock is skew ahead, and the
+// local times they arrived after a 2ms base delay plus exponential jitter
+// with the given mean.
+func skewTrace(w *Worker, n int, skew, jitter time.Duration) ([]int64, []time.Time) {
+	r := rand.New(rand.NewPCG(1, 2))
+	l := w.Layout()
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ids := make([]int64, n)
+	received := make([]time.Time, n)
+	for i := range ids {
+		sent := start.Add(time.Duration(i) * 7 * time.Millisecond)
+		ids[i] = w.compose(l.tick(sent.Add(skew)), 0, 0)
+		delay := 2*time.Millisecond + time.Duration(r.ExpFloat64()*float64(jitter))
+		received[i] = sent.Add(delay)
+	}
+	return ids, received
+}
+
+func TestEstimateSkew(t *testing.T) {
+	w := NewWorker10(1)
+	for _, skew := range []time.Duration{0, 250 * time.Millisecond, -3 * time.Second} {
+		var confidences []float64
+		for _, jitter := range []time.Duration{time.Millisecond, 20 * time.Millisecond} {
+			ids, received := skewTrace(w, 1000, skew, jitter)
+			got, confidence := EstimateSkew(w, ids, received)
+			// The estimate is short of the skew by about the base delay,
+			// give or take a tick.
+			if d := skew - got; d < time.Millisecond || d > 5*time.Millisecond {
+				t.Errorf("skew %s, jitter %s: estimated %s", skew, jitter, got)
+			}
+			confidences = append(confidences, confidence)
+		}
+		// Jitter much wider than a tick spreads even the least delayed
+		// offsets, which lowers the confidence.
+		if c := confidences; c[0] < 0.9 || c[0] > 1 || c[1] >= c[0] {
+			t.Errorf("skew %s: confidence %.2f with 1ms jitter, %.2f with 20ms", skew, c[0], c[1])
+		}
+	}
+}
+
+func TestEstimateSkewFewIDs(t *testing.T) {
+	w := NewWorker10(1)
+	ids, received := skewTrace(w, 8, time.Second, time.Millisecond)
+	_, few := EstimateSkew(w, ids, received)
+	ids, received = skewTrace(w, 800, time.Second, time.Millisecond)
+	_, many := EstimateSkew(w, ids, received)
+	if few >= many || few > 0.5 {
+		t.Errorf("confidence %.2f from 8 IDs, %.2f from 800", few, many)
+	}
+
+	// Extra IDs without receipt times are ignored.
+	skew, _ := EstimateSkew(w, ids, received[:400])
+	if half, _ := EstimateSkew(w, ids[:400], received[:400]); skew != half {
+		t.Errorf("unmatched IDs changed the estimate from %s to %s", half, skew)
+	}
+	if skew, confidence := EstimateSkew(w, nil, received); skew != 0 || confidence != 0 {
+		t.Errorf("no IDs: %s, %.2f", skew, confidence)
+	}
+}
@@ -0,0 +1,36 @@
+package sanic
+
+// loBias flips the sign bit of the low half so that, compared as signed
+// int32s, low halves sort in the same order as their unsigned values.
+const loBias = 1 << 31
+
+// SplitID splits id into two int32s for systems without 64-bit integers.
+// hi is the top 32 bits, keeping id's sign. lo is the bottom 32 bits with
+// its top bit flipped, so lo is negative for the lower half of its range.
+// Ordering pairs by hi, then lo, gives the same order as the IDs.
+func SplitID(id int64) (hi, lo int32) {
+	return int32(id >> 32), int32(uint32(id) ^ loBias)
+}
+
+// JoinID is the inverse of SplitID.
+func JoinID(hi, lo int32) int64 {
+	return int64(hi)<<32 | int64(uint32(lo)^loBias)
+}
+
+// IDPair is the SplitID form of an ID, for JSON consumers that cannot hold
+// 64-bit integers.
+type IDPair struct {
+	Hi int32 `json:"hi"`
+	Lo int32 `json:"lo"`
+}
+
+// PairOf returns id as an IDPair.
+func PairOf(id int64) IDPair {
+	hi, lo := SplitID(id)
+	return IDPair{hi, lo}
+}
+
+// Int64 returns the ID p holds.
+func (p IDPair) Int64() int64 {
+	return JoinID(p.Hi, p.Lo)
+}
@@ -0,0 +1,67 @@
+package sanic
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSplitIDRoundTrip(t *testing.T) {
+	ids := []int64{
+		0, 1, -1, math.MaxInt64, math.MinInt64,
+		1<<31 - 1, 1 << 31, 1<<32 - 1, 1 << 32, // lo at the edges of its range
+		-1 << 31, -1<<32 + 1,
+	}
+	for range 10000 {
+		ids = append(ids, int64(rand.Uint64()))
+	}
+	for _, id := range ids {
+		hi, lo := SplitID(id)
+		if back := JoinID(hi, lo); back != id {
+			t.Fatalf("JoinID(SplitID(%d)) = %d", id, back)
+		}
+		if p := PairOf(id); p.Hi != hi || p.Lo != lo || p.Int64() != id {
+			t.Fatalf("PairOf(%d) = %+v", id, p)
+		}
+	}
+}
+
+func TestSplitIDOrder(t *testing.T) {
+	comparePairs := func(a, b int64) int {
+		ahi, alo := SplitID(a)
+		bhi, blo := SplitID(b)
+		return cmp.Or(cmp.Compare(ahi, bhi), cmp.Compare(alo, blo))
+	}
+	// Pairs straddling the point where the low half's top bit flips.
+	edges := []int64{1<<31 - 1, 1 << 31, 1<<32 - 1, 1 << 32, -1, 0, math.MinInt64}
+	for _, a := range edges {
+		for _, b := range edges {
+			if got := comparePairs(a, b); got != cmp.Compare(a, b) {
+				t.Errorf("pairs of %d and %d compare %d", a, b, got)
+			}
+		}
+	}
+	for range 10000 {
+		a, b := int64(rand.Uint64()), int64(rand.Uint64())
+		if got := comparePairs(a, b); got != cmp.Compare(a, b) {
+			t.Fatalf("pairs of %d and %d compare %d", a, b, got)
+		}
+	}
+}
+
+func TestIDPairJSON(t *testing.T) {
+	id := NewWorker10(7).NextID()
+	b, err := json.Marshal(PairOf(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p IDPair
+	if err := json.Unmarshal(b, &p); err != nil || p.Int64() != id {
+		t.Errorf("%s decodes to %d, %v; want %d", b, p.Int64(), err, id)
+	}
+	if err := json.Unmarshal([]byte(`{"hi":1,"lo":-2147483648}`), &p); err != nil || p.Int64() != 1<<32 {
+		t.Errorf("hi 1, lo MinInt32 = %d, %v", p.Int64(), err)
+	}
+}
@@ -0,0 +1,22 @@
+package sanic
+
+// Stats counts notable events during generation.
+type Stats struct {
+	// Waits counts sequence exhaustions that made the worker wait for the
+	// next tick.
+	Waits int64
+	// ClockBackwards counts IDs for which the clock read earlier than the
+	// last ID, making the worker wait for it to catch up.
+	ClockBackwards int64
+	// Borrowed counts ticks the worker moved to early under its
+	// BorrowPolicy.
+	Borrowed int64
+}
+
+// Stats returns the worker's counters. It takes the worker's lock, so it must
+// not be called concurrently with UnsafeNextID.
+func (w *Worker) Stats() Stats {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.stats
+}
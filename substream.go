@@ -0,0 +1,147 @@
+package sanic
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// errSplit is the panic value for generating IDs from a worker that has
+// been split into substreams.
+var errSplit = errors.New("sanic: worker is split into substreams; " +
+	"generate IDs with them instead")
+
+// Substream generates IDs from one share of a worker's sequence space: the
+// sequences offset, offset+stride, offset+2*stride and so on in each tick.
+// Sibling substreams have distinct offsets and the same stride, so their IDs
+// can never collide, and each can generate on its own goroutine without
+// any locking between them.
+//
+// Once a worker has a substream, it generates IDs only through its
+// substreams: its own NextID and the like panic. Substreams do not borrow
+// ticks or record to Recent. As with NextID, a substream never goes back to
+// an earlier tick than a sibling has used, or than EnsureAfter,
+// WaitUntilAfter or NewHighWaterWorker fast-forwarded the worker past, even
+// if the clock does; it waits for the clock instead.
+type Substream struct {
+	w              *Worker
+	offset, stride int64
+	tick, sequence int64 // of the last ID
+}
+
+// substreams is the state sibling substreams share. stride and offsets are
+// under the worker's mutex.
+type substreams struct {
+	stride  int64
+	offsets map[int64]bool
+	floor   atomic.Int64 // the earliest tick a substream may use
+}
+
+// raise makes tick the earliest a substream may use, unless it already
+// must use a later one.
+func (s *substreams) raise(tick int64) {
+	for {
+		f := s.floor.Load()
+		if tick <= f || s.floor.CompareAndSwap(f, tick) {
+			return
+		}
+	}
+}
+
+// Substream returns a new substream of w taking the sequences congruent to
+// offset modulo stride. stride must divide the sequence space of 2 ^
+// SequenceBits, 0 <= offset < stride, and every substream of w must have the
+// same stride and a different offset. A worker with ReservedRanges cannot
+// be split.
+//
+// The substream starts after the worker's last ID, waiting for the next
+// tick if the worker has used the current one.
+func (w *Worker) Substream(offset, stride int64) (*Substream, error) {
+	if err := w.checkInitialized(); err != nil {
+		return nil, err
+	}
+	if stride <= 0 || (w.MaxSequence+1)%stride != 0 {
+		return nil, fmt.Errorf("sanic: substream stride %d does not divide "+
+			"the sequence space of %d", stride, w.MaxSequence+1)
+	}
+	if offset < 0 || offset >= stride {
+		return nil, fmt.Errorf("sanic: substream offset %d is not in "+
+			"[0, %d)", offset, stride)
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.ReservedRanges) > 0 {
+		return nil, errors.New("sanic: a worker with ReservedRanges " +
+			"cannot be split into substreams")
+	}
+	if w.split == nil {
+		w.split = &substreams{stride: stride, offsets: map[int64]bool{}}
+		w.split.raise(w.LastTimeStamp + 1)
+	}
+	switch {
+	case w.split.stride != stride:
+		return nil, fmt.Errorf("sanic: substream stride %d differs from "+
+			"its siblings' stride %d", stride, w.split.stride)
+	case w.split.offsets[offset]:
+		return nil, fmt.Errorf("sanic: substream offset %d already in use",
+			offset)
+	}
+	w.split.offsets[offset] = true
+	return &Substream{
+		w:        w,
+		offset:   offset,
+		stride:   stride,
+		tick:     w.LastTimeStamp,
+		sequence: w.MaxSequence, // the first ID needs a later tick
+	}, nil
+}
+
+// UnsafeNextID returns the substream's next ID. Like Worker.UnsafeNextID it
+// must be called from only one goroutine at a time, though siblings may be
+// called concurrently. It waits for the next tick when the substream's
+// share of the current one is used up or the clock is behind its last ID.
+//
+// OnNewInterval is called for each tick at most once across siblings, in
+// increasing order; a tick a substream reaches after a sibling has passed
+// it is not reported again.
+func (s *Substream) UnsafeNextID() int64 {
+	w := s.w
+	now := w.Time()
+	if floor := w.split.floor.Load(); now < floor {
+		// The clock is behind a tick a sibling used or the worker was
+		// fast-forwarded past.
+		now = w.awaitTick(floor - 1)
+	}
+	switch {
+	case now > s.tick:
+		s.tick, s.sequence = now, s.offset
+	case now == s.tick && s.sequence+s.stride <= w.MaxSequence:
+		s.sequence += s.stride
+	default:
+		s.tick, s.sequence = w.awaitTick(s.tick), s.offset
+	}
+	if s.tick-w.CustomEpoch > w.MaxTimeStamp {
+		w.blockExhausted(s.tick)
+	}
+	w.split.raise(s.tick)
+	if s.sequence == s.offset && w.OnNewInterval != nil {
+		s.newInterval()
+	}
+	return w.compose(s.tick, 0, s.sequence)
+}
+
+// newInterval calls OnNewInterval for the substream's tick unless it has
+// been called for it or a later tick.
+func (s *Substream) newInterval() {
+	w := s.w
+	if s.tick <= w.firedTick.Load() {
+		return
+	}
+	w.hookMutex.Lock()
+	defer w.hookMutex.Unlock()
+	if s.tick > w.firedTick.Load() {
+		w.OnNewInterval(s.tick)
+		w.firedTick.Store(s.tick)
+	}
+}
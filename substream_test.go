@@ -0,0 +1,127 @@
+package sanic
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubstreamsUnique(t *testing.T) {
+	for _, n := range []int64{2, 4} {
+		w := NewWorker10(9)
+		subs := make([]*Substream, n)
+		for i := range subs {
+			s, err := w.Substream(int64(i), n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			subs[i] = s
+		}
+		ids := make([][]int64, n)
+		var wg sync.WaitGroup
+		for i, s := range subs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 20000 {
+					ids[i] = append(ids[i], s.UnsafeNextID())
+				}
+			}()
+		}
+		wg.Wait()
+
+		seen := map[int64]bool{}
+		for i, list := range ids {
+			for j, id := range list {
+				if seen[id] {
+					t.Fatalf("%d substreams: duplicate %d", n, id)
+				}
+				seen[id] = true
+				if j > 0 && id <= list[j-1] {
+					t.Fatalf("substream %d went back from %d to %d", i, list[j-1], id)
+				}
+				if p := w.Decompose(id); p.Sequence%n != int64(i) || p.WorkerID != 9 {
+					t.Fatalf("substream %d issued %+v", i, p)
+				}
+			}
+		}
+	}
+}
+
+func TestSubstreamErrors(t *testing.T) {
+	w := NewWorker10(9)
+	for _, c := range [][2]int64{{0, 0}, {0, 3}, {2, 2}, {-1, 2}} {
+		if _, err := w.Substream(c[0], c[1]); err == nil {
+			t.Errorf("Substream(%d, %d) accepted", c[0], c[1])
+		}
+	}
+	if _, err := w.Substream(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Substream(0, 2); err == nil {
+		t.Error("repeated offset accepted")
+	}
+	if _, err := w.Substream(1, 4); err == nil {
+		t.Error("different stride accepted")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NextID on a split worker did not panic")
+		}
+	}()
+	w.NextID()
+}
+
+// blocked reports whether ch stays empty for a while.
+func blocked(ch <-chan int64) bool {
+	select {
+	case <-ch:
+		return false
+	case <-time.After(20 * time.Millisecond):
+		return true
+	}
+}
+
+func TestSubstreamClockRegression(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 9, Layout: NewWorker10(0).Layout()}, tick)
+	a, _ := w.Substream(0, 2)
+	b, _ := w.Substream(1, 2)
+	clock.set(tick + 10)
+	high := a.UnsafeNextID()
+
+	// b has not generated since it was made, before tick+10. After the
+	// clock goes back it must still not go below a's tick.
+	clock.set(tick + 5)
+	got := make(chan int64, 1)
+	go func() { got <- b.UnsafeNextID() }()
+	if !blocked(got) {
+		t.Fatal("substream generated while the clock was behind a sibling")
+	}
+	clock.set(tick + 10)
+	if id := <-got; w.Decompose(id).Tick != w.Decompose(high).Tick {
+		t.Errorf("b issued %+v after a's %+v", w.Decompose(id), w.Decompose(high))
+	}
+}
+
+func TestSubstreamEnsureAfter(t *testing.T) {
+	tick := NewWorker10(0).Time()
+	w, clock := fakeClockWorker(t, WorkerConfig{ID: 9, Layout: NewWorker10(0).Layout()}, tick)
+	w.MaxEnsureWait = time.Second
+	s, _ := w.Substream(0, 2)
+	s.UnsafeNextID()
+
+	other := NewWorker10(3).compose(tick+3, 0, 0)
+	if err := w.EnsureAfter(other); err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan int64, 1)
+	go func() { got <- s.UnsafeNextID() }()
+	if !blocked(got) {
+		t.Fatal("substream generated before the clock passed EnsureAfter's ID")
+	}
+	clock.set(tick + 4)
+	if id := <-got; id <= other {
+		t.Errorf("substream issued %d, not after %d", id, other)
+	}
+}
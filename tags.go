@@ -0,0 +1,65 @@
+package sanic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NextIDTagged is NextID with tag stored in the layout's tag bits. It returns
+// ErrInvalidTag unless 0 <= tag <= MaxTag, ErrPaused while paused under
+// PauseError, ErrNoTick when a tick-driven worker under TickError would
+// wait for a tick, and ErrEpochExhausted once every tick of the layout has
+// been used.
+func (w *Worker) NextIDTagged(tag int64) (int64, error) {
+	if err := w.checkInitialized(); err != nil {
+		return 0, err
+	}
+	if tag < 0 || tag > w.MaxTag {
+		return 0, fmt.Errorf("%w: %d does not fit in %d bits",
+			ErrInvalidTag, tag, w.TagBits)
+	}
+
+	w.mutex.Lock()
+	if err := w.waitTurn(context.Background(), true); err != nil {
+		w.mutex.Unlock()
+		return 0, err
+	}
+	if err := w.checkTick(); err != nil {
+		w.mutex.Unlock()
+		return 0, err
+	}
+	id, tick, err := w.tryGenerate(context.Background(), tag)
+	w.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
+	}
+	return id, nil
+}
+
+// TaggedString is IDString preceded by the prefix registered in TagPrefixes
+// for id's tag, if any.
+func (w *Worker) TaggedString(id int64) string {
+	return w.TagPrefixes[w.Decompose(id).Tag] + w.IDString(id)
+}
+
+// ParseTagPrefix splits a string from TaggedString into the tag its prefix
+// is registered for and the remaining ID string. The longest matching
+// prefix wins. It returns ErrUnknownTagPrefix if no registered prefix
+// matches.
+func (w *Worker) ParseTagPrefix(s string) (tag int64, rest string, err error) {
+	best := -1
+	for t, prefix := range w.TagPrefixes {
+		if strings.HasPrefix(s, prefix) && len(prefix) > best {
+			tag, best = t, len(prefix)
+		}
+	}
+	if best < 0 {
+		return 0, "", fmt.Errorf("%w: %q", ErrUnknownTagPrefix, s)
+	}
+	return tag, s[best:], nil
+}
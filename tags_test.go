@@ -0,0 +1,79 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextIDTagged(t *testing.T) {
+	w, err := New(WorkerConfig{ID: 9, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for tag := int64(0); tag <= w.Layout().MaxTag(); tag++ {
+		id, err := w.NextIDTagged(tag)
+		if err != nil {
+			t.Fatalf("tag %d: %v", tag, err)
+		}
+		if p := w.Decompose(id); p.Tag != tag || p.WorkerID != 9 {
+			t.Errorf("tag %d: decoded %+v", tag, p)
+		}
+		if id <= last {
+			t.Errorf("tag %d: ID %d not after %d", tag, id, last)
+		}
+		last = id
+	}
+	for _, tag := range []int64{-1, w.Layout().MaxTag() + 1} {
+		if _, err := w.NextIDTagged(tag); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("NextIDTagged(%d) = %v, want ErrInvalidTag", tag, err)
+		}
+	}
+}
+
+func TestNextIDTaggedUntagged(t *testing.T) {
+	w := NewWorker10(9)
+	id, err := w.NextIDTagged(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := w.Decompose(id); p.Tag != 0 || p.WorkerID != 9 {
+		t.Errorf("decoded %+v", p)
+	}
+	if w.TaggedString(id) != w.IDString(id) {
+		t.Errorf("TaggedString %q without prefixes, IDString %q",
+			w.TaggedString(id), w.IDString(id))
+	}
+	if _, err := w.NextIDTagged(1); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("untagged layout accepted tag 1: %v", err)
+	}
+}
+
+func TestTagPrefixes(t *testing.T) {
+	w, err := New(WorkerConfig{ID: 9, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "usr_" and "usr_adm_" overlap, so parsing needs the longest match.
+	w.TagPrefixes = map[int64]string{1: "usr_", 2: "usr_adm_", 3: "ord_"}
+	for tag := int64(0); tag <= 3; tag++ {
+		id, _ := w.NextIDTagged(tag)
+		s := w.TaggedString(id)
+		if tag == 0 {
+			if s != w.IDString(id) {
+				t.Errorf("tag 0 has no prefix but got %q", s)
+			}
+			continue
+		}
+		gotTag, rest, err := w.ParseTagPrefix(s)
+		if err != nil || gotTag != tag {
+			t.Fatalf("ParseTagPrefix(%q) = %d, %v; want %d", s, gotTag, err, tag)
+		}
+		if back, err := w.ParseIDString(rest); err != nil || back != id {
+			t.Errorf("ParseIDString(%q) = %d, %v; want %d", rest, back, err, id)
+		}
+	}
+	if _, _, err := w.ParseTagPrefix("evt_0000000000"); !errors.Is(err, ErrUnknownTagPrefix) {
+		t.Errorf("unknown prefix: %v", err)
+	}
+}
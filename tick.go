@@ -0,0 +1,151 @@
+package sanic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// TickPolicy says what generation that can fail does when a tick-driven
+// worker would have to wait for a tick to arrive.
+type TickPolicy int
+
+const (
+	// TickBlock makes generation wait for the tick.
+	TickBlock TickPolicy = iota
+	// TickError makes generation that can fail return ErrNoTick at once.
+	// NextID and NextIDs cannot report errors, so they still wait.
+	TickError
+)
+
+// NewTickDrivenWorker is New for a worker whose clock is the ticks received
+// from ticks, absolute times in units of the layout's Frequency as in
+// Parts.Tick, instead of the wall clock, which it never reads.
+//
+// Generation uses the latest tick received, taking every tick waiting in
+// the channel first, so a tick sent on a buffered channel before a call is
+// seen by that call and the IDs generated depend only on the ticks sent and
+// the calls made. Ticks no later than the latest are ignored. When the
+// sequence is exhausted, or before the first tick, generation blocks on the
+// channel for the next tick rather than spinning, or fails with ErrNoTick
+// under TickError. If the channel is closed while NextID or NextIDs waits,
+// they panic.
+func NewTickDrivenWorker(cfg WorkerConfig, ticks <-chan int64) (*Worker, error) {
+	w, err := fromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w.ticks = &tickSource{ch: ticks}
+	w.start()
+	return w, nil
+}
+
+// tickSource is the clock of a tick-driven worker.
+type tickSource struct {
+	mutex  sync.Mutex
+	ch     <-chan int64
+	tick   int64
+	have   bool // a tick has arrived
+	closed bool
+}
+
+// now returns the latest tick, waiting for the first if need be.
+func (s *tickSource) now() int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.drain()
+	if !s.have {
+		s.receive()
+	}
+	return s.tick
+}
+
+// after waits for a tick later than tick and returns it.
+func (s *tickSource) after(tick int64) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.drain()
+	for !s.have || s.tick <= tick {
+		s.receive()
+	}
+	return s.tick
+}
+
+// afterContext is after, returning ctx's error if ctx is done first and
+// ErrNoTick if the channel is closed.
+func (s *tickSource) afterContext(ctx context.Context, tick int64) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.drain()
+	for !s.have || s.tick <= tick {
+		if s.closed {
+			return 0, fmt.Errorf("%w: tick channel closed", ErrNoTick)
+		}
+		select {
+		case t, ok := <-s.ch:
+			s.update(t, ok)
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return s.tick, nil
+}
+
+// peek returns the latest tick without waiting, and whether there is one.
+func (s *tickSource) peek() (int64, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.drain()
+	return s.tick, s.have
+}
+
+// drain takes every tick already waiting in the channel. s.mutex must be
+// held.
+func (s *tickSource) drain() {
+	for !s.closed {
+		select {
+		case t, ok := <-s.ch:
+			s.update(t, ok)
+		default:
+			return
+		}
+	}
+}
+
+// receive waits for one tick. s.mutex must be held.
+func (s *tickSource) receive() {
+	if s.closed {
+		panic(fmt.Errorf("%w: tick channel closed", ErrNoTick))
+	}
+	t, ok := <-s.ch
+	s.update(t, ok)
+}
+
+func (s *tickSource) update(t int64, ok bool) {
+	switch {
+	case !ok:
+		s.closed = true
+	case !s.have || t > s.tick:
+		s.tick, s.have = t, true
+	}
+}
+
+// checkTick returns ErrNoTick if w is tick-driven under TickError and the
+// next ID would have to wait for a tick. Borrowing is not attempted.
+// w.mutex must be held.
+func (w *Worker) checkTick() error {
+	if w.ticks == nil || w.TickPolicy != TickError {
+		return nil
+	}
+	now, ok := w.ticks.peek()
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: no tick has arrived", ErrNoTick)
+	case w.LastTimeStamp > now && !w.leading(now):
+		return fmt.Errorf("%w: tick %d is behind the last ID's tick %d",
+			ErrNoTick, now, w.LastTimeStamp)
+	case w.LastTimeStamp == now && w.Sequence == w.SequenceMask:
+		return fmt.Errorf("%w: sequence exhausted in tick %d", ErrNoTick, now)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package sanic
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// tickWorker returns a tick-driven worker with NewWorker10's layout whose
+// TimeFunc fails the test if it is ever read.
+func tickWorker(t *testing.T, ticks <-chan int64) *Worker {
+	t.Helper()
+	w, err := NewTickDrivenWorker(WorkerConfig{ID: 5, Layout: NewWorker10(0).Layout()}, ticks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.TimeFunc = func() time.Time {
+		t.Error("tick-driven worker read its TimeFunc")
+		return time.Time{}
+	}
+	return w
+}
+
+// The IDs depend only on the ticks sent and the calls made.
+func TestTickDrivenReproducible(t *testing.T) {
+	tick := NewWorker10(0).CustomEpoch + 1_000_000
+	run := func() []int64 {
+		ticks := make(chan int64, 4)
+		w := tickWorker(t, ticks)
+		var ids []int64
+		ticks <- tick
+		ids = append(ids, w.NextID(), w.NextID(), w.NextID())
+		ticks <- tick + 5
+		ids = append(ids, w.NextID())
+		ticks <- tick + 2 // behind the latest, so ignored
+		ids = append(ids, w.NextID())
+		ticks <- tick + 6
+		ticks <- tick + 9 // only the latest waiting tick counts
+		ids = append(ids, w.NextID())
+		return ids
+	}
+	ref := NewWorker10(5)
+	want := []int64{
+		ref.compose(tick, 0, 0), ref.compose(tick, 0, 1), ref.compose(tick, 0, 2),
+		ref.compose(tick+5, 0, 0), ref.compose(tick+5, 0, 1), ref.compose(tick+9, 0, 0),
+	}
+	if got := run(); !slices.Equal(got, want) {
+		t.Errorf("IDs %v, want %v", got, want)
+	}
+	if a, b := run(), run(); !slices.Equal(a, b) {
+		t.Error("two runs with the same ticks differ")
+	}
+}
+
+func TestTickDrivenBlocks(t *testing.T) {
+	tick := NewWorker10(0).CustomEpoch + 1_000_000
+	ticks := make(chan int64, 1)
+	w := tickWorker(t, ticks)
+
+	ch := make(chan int64, 1)
+	go func() { ch <- w.NextID() }()
+	if !blocked(ch) {
+		t.Fatal("NextID returned before the first tick")
+	}
+	ticks <- tick
+	<-ch
+	w.NextIDs(make([]int64, w.MaxSequence))
+
+	// The sequence is exhausted, so the next ID waits for a tick.
+	go func() { ch <- w.NextID() }()
+	if !blocked(ch) {
+		t.Fatal("NextID returned with the sequence exhausted")
+	}
+	ticks <- tick + 1
+	if p := w.Decompose(<-ch); p.Tick != tick+1 || p.Sequence != 0 {
+		t.Errorf("ID after the tick at +%d sequence %d", p.Tick-tick, p.Sequence)
+	}
+}
+
+func TestTickDrivenError(t *testing.T) {
+	tick := NewWorker10(0).CustomEpoch + 1_000_000
+	ticks := make(chan int64, 1)
+	w := tickWorker(t, ticks)
+	w.TickPolicy = TickError
+	if _, err := w.NextIDContext(context.Background()); !errors.Is(err, ErrNoTick) {
+		t.Errorf("before the first tick: %v", err)
+	}
+	ticks <- tick
+	if _, err := w.NextIDTagged(0); err != nil {
+		t.Fatal(err)
+	}
+	w.NextIDs(make([]int64, w.MaxSequence))
+	if _, err := w.NextIDContext(context.Background()); !errors.Is(err, ErrNoTick) {
+		t.Errorf("sequence exhausted: %v", err)
+	}
+	if _, err := w.NextIDTagged(0); !errors.Is(err, ErrNoTick) {
+		t.Errorf("NextIDTagged with the sequence exhausted: %v", err)
+	}
+	ticks <- tick + 1
+	if id, err := w.NextIDContext(context.Background()); err != nil || w.Decompose(id).Tick != tick+1 {
+		t.Errorf("after the next tick: %d, %v", id, err)
+	}
+}
+
+func TestTickDrivenClosed(t *testing.T) {
+	ticks := make(chan int64, 1)
+	w := tickWorker(t, ticks)
+	ticks <- NewWorker10(0).CustomEpoch + 1_000_000
+	w.NextIDs(make([]int64, w.MaxSequence+1))
+	close(ticks)
+	defer func() {
+		if err, _ := recover().(error); !errors.Is(err, ErrNoTick) {
+			t.Errorf("NextID with the channel closed panicked with %v", err)
+		}
+	}()
+	w.NextID()
+	t.Error("NextID returned with the channel closed")
+}
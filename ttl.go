@@ -0,0 +1,41 @@
+package sanic
+
+import (
+	"fmt"
+	"time"
+)
+
+// NextExpiringID is NextIDTagged with the tag used as an index into
+// TTLClasses, so that the ID carries its own lifetime. It returns
+// ErrInvalidTag if the layout has no tag bits or ttlClass is not an index
+// into TTLClasses that fits in them.
+func (w *Worker) NextExpiringID(ttlClass int) (int64, error) {
+	if w.TagBits == 0 {
+		return 0, fmt.Errorf("%w: layout has no tag bits for TTL classes",
+			ErrInvalidTag)
+	}
+	if ttlClass < 0 || ttlClass >= len(w.TTLClasses) {
+		return 0, fmt.Errorf("%w: TTL class %d is not one of the %d configured",
+			ErrInvalidTag, ttlClass, len(w.TTLClasses))
+	}
+	return w.NextIDTagged(int64(ttlClass))
+}
+
+// ExpiresAt returns the time id expires: the start of the tick it was
+// generated in plus the duration of its TTL class. It returns the zero time
+// if id is not positive or its tag is not a configured TTL class.
+func (w *Worker) ExpiresAt(id int64) time.Time {
+	p := w.Decompose(id)
+	if p.Time.IsZero() || p.Tag >= int64(len(w.TTLClasses)) {
+		return time.Time{}
+	}
+	return p.Time.Add(w.TTLClasses[p.Tag])
+}
+
+// Expired reports whether id has expired at now, that is, whether now is
+// not before ExpiresAt(id). IDs without a configured TTL class are always
+// expired.
+func (w *Worker) Expired(id int64, now time.Time) bool {
+	at := w.ExpiresAt(id)
+	return at.IsZero() || !now.Before(at)
+}
@@ -0,0 +1,53 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextExpiringID(t *testing.T) {
+	w, err := New(WorkerConfig{ID: 3, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.TTLClasses = []time.Duration{time.Minute, time.Hour}
+	for class, ttl := range w.TTLClasses {
+		id, err := w.NextExpiringID(class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := w.ExpiresAt(id)
+		if want := w.Timestamp(id).Add(ttl); !at.Equal(want) {
+			t.Errorf("class %d expires at %s, want %s", class, at, want)
+		}
+		if w.Expired(id, at.Add(-time.Nanosecond)) || !w.Expired(id, at) {
+			t.Errorf("class %d: Expired wrong either side of %s", class, at)
+		}
+	}
+	for _, class := range []int{-1, 2, 4} {
+		if _, err := w.NextExpiringID(class); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("NextExpiringID(%d) = %v, want ErrInvalidTag", class, err)
+		}
+	}
+}
+
+func TestExpiresAtUnconfigured(t *testing.T) {
+	w, err := New(WorkerConfig{ID: 3, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.TTLClasses = []time.Duration{time.Minute}
+	id, _ := w.NextIDTagged(3) // a tag with no TTL class
+	for _, id := range []int64{id, 0, -1} {
+		if at := w.ExpiresAt(id); !at.IsZero() || !w.Expired(id, time.Time{}) {
+			t.Errorf("ID %d expires at %s", id, at)
+		}
+	}
+
+	u := NewWorker10(1)
+	u.TTLClasses = []time.Duration{time.Minute}
+	if _, err := u.NextExpiringID(0); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("layout without tag bits: %v", err)
+	}
+}
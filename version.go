@@ -0,0 +1,52 @@
+package sanic
+
+import "time"
+
+// Version returns the version flag of an ID from a layout with VersionBit:
+// 1 if its sign bit is set, so that it is negative, and 0 otherwise.
+//
+// Version 1 IDs are negative as int64s, so they sort before every version 0
+// ID numerically, but after them as strings, hex and Keys, which read the
+// bits as unsigned. Sort mixed versions by one of those, or by Parts.Time.
+// They round-trip through JSON and signed 64-bit database columns
+// unchanged.
+func Version(id int64) int {
+	if id < 0 {
+		return 1
+	}
+	return 0
+}
+
+// VersionedDecoder decodes IDs from both sides of an in-place layout
+// migration, routing each to the layout registered for its Version.
+type VersionedDecoder struct {
+	layouts [2]Layout
+}
+
+// NewVersionedDecoder returns a VersionedDecoder for version 0 IDs in
+// layout v0 and version 1 IDs in layout v1. VersionBit is set on both.
+func NewVersionedDecoder(v0, v1 Layout) *VersionedDecoder {
+	v0.VersionBit, v1.VersionBit = true, true
+	return &VersionedDecoder{layouts: [2]Layout{v0, v1}}
+}
+
+// Layout returns the layout for id's version.
+func (d *VersionedDecoder) Layout(id int64) Layout {
+	return d.layouts[Version(id)]
+}
+
+// Decompose unpacks id with the layout for its version.
+func (d *VersionedDecoder) Decompose(id int64) Parts {
+	return d.Layout(id).Decompose(id)
+}
+
+// Timestamp returns the time id was generated, to its layout's Frequency.
+func (d *VersionedDecoder) Timestamp(id int64) time.Time {
+	return d.Layout(id).Timestamp(id)
+}
+
+// Validate returns ErrInvalidID if id could not have been generated with
+// the layout for its version.
+func (d *VersionedDecoder) Validate(id int64) error {
+	return d.Layout(id).Validate(id)
+}
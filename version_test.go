@@ -0,0 +1,126 @@
+package sanic
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	for id, want := range map[int64]int{1: 0, math.MaxInt64: 0, -1: 1, math.MinInt64: 1} {
+		if got := Version(id); got != want {
+			t.Errorf("Version(%d) = %d, want %d", id, got, want)
+		}
+	}
+}
+
+// versionWorkers returns a version 0 and a version 1 worker sharing
+// NewWorker10's layout, as on either side of an in-place migration.
+func versionWorkers(t *testing.T) (v0, v1 *Worker) {
+	t.Helper()
+	l := NewWorker10(0).Layout()
+	l.VersionBit = true
+	v0 = NewWorker10(4)
+	v1, err := New(WorkerConfig{ID: 4, Layout: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v0, v1
+}
+
+func TestVersionOrder(t *testing.T) {
+	v0, v1 := versionWorkers(t)
+	old, cur := v0.NextID(), v1.NextID()
+	if Version(old) != 0 || Version(cur) != 1 {
+		t.Fatalf("versions %d, %d", Version(old), Version(cur))
+	}
+	if cur >= old {
+		t.Errorf("version 1 ID %d does not sort numerically before %d", cur, old)
+	}
+	if a, b := v1.IDString(old), v1.IDString(cur); a >= b {
+		t.Errorf("IDString %q does not sort before %q", a, b)
+	}
+	if a, b := v1.IDHex(old), v1.IDHex(cur); a >= b {
+		t.Errorf("IDHex %q does not sort before %q", a, b)
+	}
+	if !KeyOf(old).Before(KeyOf(cur)) {
+		t.Error("version 1 Key does not sort after version 0")
+	}
+	if got := v1.Decompose(cur); got.Version != 1 || got.WorkerID != 4 {
+		t.Errorf("Decompose = %+v", got)
+	}
+}
+
+func TestVersionRoundTrip(t *testing.T) {
+	v0, v1 := versionWorkers(t)
+	for _, id := range []int64{v0.NextID(), v1.NextID()} {
+		s := v1.IDString(id)
+		if len(s) != v1.StringLength() || strings.HasPrefix(s, "-") {
+			t.Errorf("IDString(%d) = %q", id, s)
+		}
+		if got, err := v1.ParseIDString(s); got != id || err != nil {
+			t.Errorf("ParseIDString(%q) = %d, %v, want %d", s, got, err, id)
+		}
+		if got, err := v1.ParseHex(v1.IDHex(id)); got != id || err != nil {
+			t.Errorf("ParseHex(%q) = %d, %v, want %d", v1.IDHex(id), got, err, id)
+		}
+		b, err := json.Marshal(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got int64
+		if err := json.Unmarshal(b, &got); got != id || err != nil {
+			t.Errorf("JSON %s = %d, %v, want %d", b, got, err, id)
+		}
+	}
+	// Without VersionBit, negative IDs stay invalid.
+	if _, err := v0.ParseIDString(v1.IDString(v1.NextID())); err == nil {
+		t.Error("a version 1 string parsed without VersionBit")
+	}
+}
+
+func TestVersionedDecoder(t *testing.T) {
+	l0, l1 := NewWorker10(0).Layout(), NewWorker9(0).Layout()
+	d := NewVersionedDecoder(l0, l1)
+	l0.VersionBit, l1.VersionBit = true, true
+	w0, err := New(WorkerConfig{ID: 9, Layout: l0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1, err := New(WorkerConfig{ID: 2, Layout: l1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// w0 mints version 1 IDs only, so take an older version 0 one from its
+	// twin.
+	twin := NewWorker10(9)
+	old, cur := twin.compose(twin.Time()-1000, 0, 0), w1.NextID()
+	if d.Layout(old) != l0 || d.Layout(cur) != l1 {
+		t.Error("Layout did not route by version")
+	}
+	if p := d.Decompose(old); p.WorkerID != 9 || p.Version != 0 {
+		t.Errorf("Decompose(version 0) = %+v", p)
+	}
+	if p := d.Decompose(cur); p.WorkerID != 2 || p.Version != 1 {
+		t.Errorf("Decompose(version 1) = %+v", p)
+	}
+	for _, id := range []int64{old, cur} {
+		if err := d.Validate(id); err != nil {
+			t.Errorf("Validate(%d) = %v", id, err)
+		}
+		if !d.Timestamp(id).Equal(d.Decompose(id).Time) {
+			t.Errorf("Timestamp(%d) disagrees with Decompose", id)
+		}
+	}
+	// A version 1 ID from the old layout is too wide for the new one.
+	if err := d.Validate(w0.NextID()); err == nil {
+		t.Error("Validate accepted a version 1 ID from the wrong layout")
+	}
+	ids := []int64{cur, old}
+	slices.SortFunc(ids, func(a, b int64) int { return d.Timestamp(a).Compare(d.Timestamp(b)) })
+	if ids[0] != old {
+		t.Error("ordering by Timestamp did not put the older ID first")
+	}
+}
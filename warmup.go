@@ -0,0 +1,121 @@
+package sanic
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Warmup re-reads the clock and checks it against the worker's layout and
+// state before any ID is generated, so that a misconfigured clock or layout
+// is reported here rather than discovered by the first NextID.
+//
+// If no ID has been generated yet, the state set at construction (which may
+// be long stale) is replaced so the next ID starts a fresh tick at sequence
+// 0. Otherwise the clock must not be behind the last generated ID, nor more
+// than MaxClockJump ahead of it when MaxClockJump is positive.
+func (w *Worker) Warmup() error {
+	if err := w.checkInitialized(); err != nil {
+		return err
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	tick := w.Time()
+	if err := w.checkClock(tick, w.MaxClockJump); err != nil {
+		return err
+	}
+	if !w.issued {
+		w.LastTimeStamp = tick - 1
+		w.Sequence = 0
+	}
+	return nil
+}
+
+// checkClock reports whether tick is usable for generation given the
+// worker's layout and the last generated ID, allowing the clock to be at
+// most maxJump ahead of that ID when maxJump is positive. w.mutex must be
+// held.
+func (w *Worker) checkClock(tick int64, maxJump time.Duration) error {
+	l := w.Layout()
+	if tick < w.CustomEpoch {
+		return fmt.Errorf("%w: clock reads %s, epoch is %s",
+			ErrClockBeforeEpoch, l.tickTime(tick), l.Epoch())
+	}
+	if tick-w.CustomEpoch > w.MaxTimeStamp {
+		return fmt.Errorf("%w: clock reads %s, layout exhausted at %s",
+			ErrEpochExhausted, l.tickTime(tick), l.Exhausts())
+	}
+	if !w.issued {
+		return nil
+	}
+	if tick < w.LastTimeStamp {
+		return fmt.Errorf("%w: clock reads %s, last ID was at %s",
+			ErrClockBackwards, l.tickTime(tick), l.tickTime(w.LastTimeStamp))
+	}
+	if jump := l.tickTime(tick).Sub(l.tickTime(w.LastTimeStamp)); maxJump > 0 &&
+		jump > maxJump {
+		return fmt.Errorf("%w: clock is %s ahead of the last ID, limit is %s",
+			ErrClockJumped, jump, maxJump)
+	}
+	return nil
+}
+
+// checkInitialized returns ErrUninitialized if w was not made by one of the
+// constructors. Generation that cannot fail panics instead.
+func (w *Worker) checkInitialized() error {
+	if !w.initialized {
+		return errNotConstructed
+	}
+	return nil
+}
+
+// fastForward moves the worker's state to at least (tick, sequence), so
+// every later ID is greater than any ID with that tick and sequence. If the
+// clock is behind tick, the next ID waits for it. Substreams skip the rest of
+// tick whatever sequence is.
+func (w *Worker) fastForward(tick, sequence int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if tick > w.LastTimeStamp ||
+		(tick == w.LastTimeStamp && sequence > w.Sequence) {
+		w.LastTimeStamp = tick
+		w.Sequence = sequence
+		w.forwarded = tick
+	}
+	if w.split != nil {
+		w.split.raise(tick + 1)
+	}
+	w.issued = true
+}
+
+// epochExhausted handles generation needing tick, which is past the
+// layout's last. Generation that can fail gets ErrEpochExhausted; anything
+// else warns and blocks forever, as no later ID exists. If locked is set,
+// w.mutex is held, and is released first so that other callers still get
+// their errors, and the caller's per-call state is cleared.
+func (w *Worker) epochExhausted(tick int64, locked bool) {
+	if w.canFail {
+		w.genErr = fmt.Errorf("%w: layout exhausted at %s",
+			ErrEpochExhausted, w.Layout().Exhausts())
+		return
+	}
+	if locked {
+		w.gen, w.timeWaits = GenInfo{}, false
+		w.mutex.Unlock()
+	}
+	w.blockExhausted(tick)
+}
+
+// blockExhausted calls OnEpochExhausted or warns, and blocks forever. It
+// must be called without w.mutex held.
+func (w *Worker) blockExhausted(tick int64) {
+	if w.OnEpochExhausted != nil {
+		w.OnEpochExhausted(tick)
+	} else {
+		log.Printf("sanic: %s exhausted at %s; blocking forever", w,
+			w.Layout().Exhausts())
+	}
+	select {}
+}
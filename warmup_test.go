@@ -0,0 +1,72 @@
+package sanic
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWarmupFreshWorker(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	// Idle for days, well past MaxClockJump, before the first ID.
+	w.MaxClockJump = time.Minute
+	later := tick + int64(3*24*time.Hour/w.Frequency)
+	c.set(later)
+	if err := w.Warmup(); err != nil {
+		t.Fatal(err)
+	}
+	if p := w.Decompose(w.NextID()); p.Tick != later || p.Sequence != 0 {
+		t.Errorf("first ID after warmup at tick %d sequence %d, want %d and 0",
+			p.Tick, p.Sequence, later)
+	}
+}
+
+func TestWarmupClock(t *testing.T) {
+	ref := NewWorker10(5)
+	l := ref.Layout()
+	tests := []struct {
+		name   string
+		tick   int64 // clock at Warmup, relative to the last ID
+		issued bool
+		jump   time.Duration
+		want   error
+	}{
+		{"unissued behind", -10, false, 0, nil},
+		{"same tick", 0, true, 0, nil},
+		{"backwards", -1, true, 0, ErrClockBackwards},
+		{"ahead without limit", 1e6, true, 0, nil},
+		{"ahead within limit", 5, true, 5 * time.Millisecond, nil},
+		{"jumped", 6, true, 5 * time.Millisecond, ErrClockJumped},
+	}
+	for _, tt := range tests {
+		tick := ref.Time()
+		w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: l}, tick)
+		if tt.issued {
+			w.NextID()
+		}
+		w.MaxClockJump = tt.jump
+		c.set(tick + tt.tick)
+		if err := w.Warmup(); !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+			t.Errorf("%s: Warmup() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestWarmupLayoutBounds(t *testing.T) {
+	l := NewWorker10(5).Layout()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: l}, l.CustomEpoch)
+	c.set(l.CustomEpoch - 1)
+	if err := w.Warmup(); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Errorf("before epoch: Warmup() = %v", err)
+	}
+	c.set(l.CustomEpoch + l.MaxTimeStamp() + 1)
+	if err := w.Warmup(); !errors.Is(err, ErrEpochExhausted) {
+		t.Errorf("past exhaustion: Warmup() = %v", err)
+	}
+	c.set(l.CustomEpoch + l.MaxTimeStamp())
+	if err := w.Warmup(); err != nil {
+		t.Errorf("last tick: Warmup() = %v", err)
+	}
+}
@@ -1,31 +1,185 @@
 package sanic
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+//go:generate go run gen_presets.go
+
 type Worker struct {
-	ID             int64 // 0 - 2 ^ IDBits
-	IDBits         uint64
-	IDShift        uint64
-	Sequence       int64 // 0 - 2 ^ SequenceBits
-	SequenceBits   uint64
-	LastTimeStamp  int64
-	TimeStampBits  uint64
+	ID int64 // 0 - 2 ^ IDBits
+
+	// The layout fields below are set by the constructors, and changing
+	// them breaks the worker's IDs. They will be unexported in a future
+	// major version.
+
+	// Deprecated: use Layout().IDBits.
+	IDBits uint64
+	// Deprecated: IDShift is Layout().SequenceBits + Layout().TagBits.
+	IDShift uint64
+	// Deprecated: use Layout().TagBits, which is 0 unless the worker was
+	// made with NewTaggedWorker.
+	TagBits uint64
+	// Deprecated: TagShift is Layout().SequenceBits.
+	TagShift uint64
+	// Deprecated: use LastIssued.
+	Sequence int64 // 0 - 2 ^ SequenceBits
+	// Deprecated: use Layout().SequenceBits.
+	SequenceBits uint64
+	// Deprecated: use Layout().MaxSequence, which is the same mask.
+	SequenceMask int64
+	// Deprecated: use LastIssued.
+	LastTimeStamp int64
+	// Deprecated: use Layout().TimeStampBits.
+	TimeStampBits uint64
+	// Deprecated: TimeStampShift is Layout().TotalBits() - 1 -
+	// Layout().TimeStampBits.
 	TimeStampShift uint64
-	Frequency      time.Duration
-	TotalBits      uint64
-	CustomEpoch    int64
-	mutex          sync.Mutex
+	// Deprecated: use Layout().Frequency.
+	Frequency time.Duration
+	// Deprecated: use Layout().TotalBits.
+	TotalBits uint64
+	// Deprecated: use Layout().CustomEpoch.
+	CustomEpoch int64
+	// Deprecated: use Layout().MaxWorkerID.
+	MaxWorkerID int64
+	// Deprecated: use Layout().MaxTag.
+	MaxTag int64
+	// Deprecated: use Layout().MaxSequence.
+	MaxSequence int64
+	// Deprecated: use Layout().MaxTimeStamp.
+	MaxTimeStamp int64 // relative to CustomEpoch
+
+	// TagPrefixes maps tags to the human-readable prefixes used by
+	// TaggedString and ParseTagPrefix, such as "usr_".
+	TagPrefixes map[int64]string
+	// OnNewInterval, if set, is called with each tick (a timestamp in units
+	// of Frequency, as in Parts.Tick) the first time an ID is generated in
+	// it. It is called outside the worker's lock, exactly once per tick
+	// used, in increasing tick order; ticks with no IDs are skipped. It must
+	// be set before the first ID is generated.
+	OnNewInterval func(tick int64)
+	// TimeFunc, if set, replaces time.Now as the worker's clock, for tests
+	// and simulations.
+	TimeFunc func() time.Time
+	// MaxClockJump, if positive, makes Warmup fail when the clock is more
+	// than this far ahead of the last generated ID.
+	MaxClockJump time.Duration
+	// Borrow, if set, lets the worker stamp IDs with future ticks instead of
+	// waiting when its sequence is exhausted too often. See BorrowPolicy.
+	Borrow *BorrowPolicy
+	// Health, if set, makes the worker track how often its sequence is
+	// exhausted, for HealthCheck.
+	Health *HealthPolicy
+	// PausePolicy says what generation does between Pause and Resume.
+	PausePolicy PausePolicy
+	// ReservedRanges lists inclusive ranges of IDs the worker must never
+	// issue, sorted and with no two overlapping or adjacent, as New leaves
+	// them. Generation skips past them, waiting for a later tick if a range
+	// covers the rest of the current one.
+	ReservedRanges [][2]int64
+	// TTLClasses is the table of lifetimes NextExpiringID stores the index
+	// of in the tag bits, for ExpiresAt and Expired.
+	TTLClasses []time.Duration
+	// TickPolicy says what generation that can fail does when a worker made
+	// by NewTickDrivenWorker would have to wait for the next tick.
+	TickPolicy TickPolicy
+	// Recent, if set by NewRecentIndex, records the IDs generated in a
+	// recent window of time.
+	Recent *RecentIndex
+	// VersionBit makes the worker mint version 1 IDs, with the sign bit set,
+	// for an in-place migration to its layout. See Layout.VersionBit.
+	VersionBit bool
+	// RangeWorkerIDs are worker IDs set aside for this worker to lease to
+	// offline clients with AllocateRange. No other worker, including this
+	// one, may use them.
+	RangeWorkerIDs []int64
+	// OnAnomaly, if set, is called by NextIDContext with its context and
+	// the GenInfo of each ID whose sequence rolled over or whose clock
+	// regressed, outside the worker's lock. Package sanicotel records these
+	// as OpenTelemetry span events.
+	OnAnomaly func(ctx context.Context, info GenInfo)
+	// OnEpochExhausted, if set, is called instead of logging a warning when
+	// generation that cannot fail needs a tick past the layout's last one,
+	// with that tick. Generation then blocks forever. OnEpochExhausted is
+	// called without the worker's lock held, so it may use the worker, and
+	// may panic to fail instead of blocking.
+	OnEpochExhausted func(tick int64)
+	// MaxEnsureWait is the longest EnsureAfter lets the next ID wait for the
+	// clock to pass the ID it was given. Zero allows no wait.
+	MaxEnsureWait time.Duration
+	// OnRelease, if set, is called once the worker, and every worker derived
+	// from it by WithID, has been closed, to release what they share, such
+	// as the producer of a tick-driven worker's channel. Derived workers
+	// share the OnRelease of the first worker in their family.
+	OnRelease func()
+
+	mutex        sync.Mutex
+	initialized  bool // set only by the constructors, through start
+	issued       bool
+	ticks        *tickSource // set by NewTickDrivenWorker
+	paused       bool
+	pauseQueue   []chan struct{} // callers waiting for Resume, oldest first
+	stats        Stats
+	borrowed     int64   // the latest tick taken by borrowing
+	forwarded    int64   // the latest tick set by fastForward
+	exhaustions  []int64 // ring of recent exhaustion ticks, for Borrow
+	exhaustNext  int
+	health       []healthBucket
+	hookMutex    sync.Mutex
+	pendingTicks []int64
+	firedTick    atomic.Int64
+	split        *substreams // set by Substream
+	ranges       *rangePool  // for AllocateRange, set by start
+	family       *family     // set by WithID
+	closed       bool
+	reservations reservationBook // for Reserve
+	canFail      bool            // set by tryGenerate
+	genCtx       context.Context // the caller's, set by tryGenerate
+	genErr       error           // the error for tryGenerate
+	timeWaits    bool            // set by NextIDInfo
+	gen          GenInfo         // built by nextID for NextIDInfo
 }
 
+// errNotConstructed is the panic value for generating IDs from a Worker
+// that no constructor made, such as a zero Worker, a struct literal or one
+// decoded from JSON, whose layout cannot be trusted.
+var errNotConstructed = fmt.Errorf("%w; use NewWorker, NewTaggedWorker, "+
+	"New or a preset such as NewWorker10", ErrUninitialized)
+
 func NewWorker(
 	id, epoch int64, idBits, sequenceBits, timestampBits uint64,
 	frequency time.Duration) *Worker {
 
-	totalBits := idBits + sequenceBits + timestampBits + 1
+	return NewTaggedWorker(id, epoch, idBits, 0, sequenceBits, timestampBits,
+		frequency)
+}
+
+// NewTaggedWorker is NewWorker with tagBits of tag space placed between the
+// worker ID and the sequence, for use with NextIDTagged.
+func NewTaggedWorker(
+	id, epoch int64, idBits, tagBits, sequenceBits, timestampBits uint64,
+	frequency time.Duration) *Worker {
+
+	w := newTaggedWorker(id, epoch, idBits, tagBits, sequenceBits,
+		timestampBits, frequency)
+	w.start()
+	return w
+}
+
+// newTaggedWorker is NewTaggedWorker without the call to start.
+func newTaggedWorker(
+	id, epoch int64, idBits, tagBits, sequenceBits, timestampBits uint64,
+	frequency time.Duration) *Worker {
+
+	totalBits := idBits + tagBits + sequenceBits + timestampBits + 1
 	if totalBits%6 != 0 {
 		log.Fatal("totalBits + 1 must be evenly divisible by 6")
 	}
@@ -33,99 +187,357 @@ func NewWorker(
 	w := &Worker{
 		ID:             id,
 		IDBits:         idBits,
-		IDShift:        sequenceBits,
+		IDShift:        sequenceBits + tagBits,
+		TagBits:        tagBits,
+		TagShift:       sequenceBits,
 		Sequence:       0,
 		SequenceBits:   sequenceBits,
+		SequenceMask:   1<<sequenceBits - 1,
 		TimeStampBits:  timestampBits,
-		TimeStampShift: sequenceBits + idBits,
+		TimeStampShift: sequenceBits + tagBits + idBits,
 		Frequency:      frequency,
 		TotalBits:      totalBits,
 		CustomEpoch:    epoch,
+		MaxWorkerID:    1<<idBits - 1,
+		MaxTag:         1<<tagBits - 1,
+		MaxSequence:    1<<sequenceBits - 1,
+		MaxTimeStamp:   1<<timestampBits - 1,
+	}
+	return w
+}
+
+// start readies a newly constructed worker for its first NextID. Every
+// constructor must call it.
+func (w *Worker) start() {
+	w.initialized = true
+	w.ranges = &rangePool{}
+	if w.ticks != nil {
+		// The first tick has not arrived, and the clock must not be read.
+		w.LastTimeStamp = w.CustomEpoch - 1
+		return
 	}
 	// guarantee that the first NextID will start at sequence 0
 	w.LastTimeStamp = w.Time() - int64(2*time.Second)
-	return w
 }
 
-// Easy Worker generation given an ID and using a default configuration
-// with a custom epoch of "2016-01-01 00:00:00 +0000 UTC"
+func (w *Worker) NextID() int64 {
+	w.mutex.Lock()
+	w.waitTurn(context.Background(), false)
+	id, tick := w.generate(0)
+	w.mutex.Unlock()
 
-// NewWorker10 will generate up to 4096000 unique ids/second for 69 years
-// NewWorker10 will return nil if the ID is greater than 63 or less than 0
-func NewWorker10(id int64) *Worker {
-	if id > 63 || id < 0 { // 2<<6, 6 bits of ID space
-		return nil
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
 	}
-	return NewWorker(id, 1451606400000, 6, 12, 41, time.Millisecond)
+	return id
 }
 
-// NewWorker9 will generate up to 819200 unique ids/second for 87 years
-// NewWorker9 will return nil if the ID is greater than 3 or less than 0
-func NewWorker9(id int64) *Worker {
-	if id > 3 || id < 0 { // 2<<2, 2 bits of ID space
-		return nil
+// NextIDs fills ids with new IDs, taking the lock once for the whole batch.
+func (w *Worker) NextIDs(ids []int64) {
+	if len(ids) == 0 {
+		return
 	}
-	return NewWorker(id, 145160640000, 2, 13, 38, time.Millisecond*10)
-}
+	var tick int64
+	w.mutex.Lock()
+	w.waitTurn(context.Background(), false)
+	for i := range ids {
+		ids[i], tick = w.generate(0)
+	}
+	w.mutex.Unlock()
 
-// NewWorker8 will generate up to 81920 unique ids/second for 54 years
-// NewWorker8 is the only worker of it's size with this configuration
-func NewWorker8() *Worker {
-	return NewWorker(0, 14516064000, 0, 13, 34, time.Millisecond*100)
+	if w.OnNewInterval != nil {
+		w.fireIntervals(tick)
+	}
 }
 
-// NewWorker7 will generate up to 1024 unique ids/second for 68 years
-// NewWorker7 is the only worker of its size with this configuration
-func NewWorker7() *Worker {
-	return NewWorker(0, 1451606400, 0, 10, 31, time.Second)
+// tryGenerate is generate for callers that can fail, which get
+// ErrEpochExhausted instead of blocking once the layout is exhausted, and
+// the errors of waitTurn with ctx if generation has to wait its turn again.
+func (w *Worker) tryGenerate(ctx context.Context, tag int64) (id, tick int64, err error) {
+	w.canFail, w.genCtx = true, ctx
+	id, tick = w.generate(tag)
+	w.canFail, w.genCtx = false, nil
+	err, w.genErr = w.genErr, nil
+	return id, tick, err
 }
 
-func (w *Worker) NextID() int64 {
-	w.mutex.Lock()
-	defer w.mutex.Unlock()
-
-	return w.UnsafeNextID()
+// generate is nextID for callers holding the mutex, queueing new ticks for
+// fireIntervals.
+func (w *Worker) generate(tag int64) (id, tick int64) {
+	id, tick, newTick := w.nextID(tag, true)
+	if newTick && w.OnNewInterval != nil {
+		w.pendingTicks = append(w.pendingTicks, tick)
+	}
+	return id, tick
 }
 
 // UnsafeNextID is faster than NextID, but must be called within
 // only one goroutine, otherwise ID uniqueness is not guaranteed.
+//
+// UnsafeNextID never allocates. While the sequence has room in the current
+// tick it reads the clock once and does a fixed amount of arithmetic; only
+// when the sequence is exhausted, or the clock moved backwards, does it spin
+// until the next tick.
 func (w *Worker) UnsafeNextID() int64 {
-	timestamp := w.Time()
+	id, tick, newTick := w.nextID(0, false)
+	if newTick && w.OnNewInterval != nil {
+		w.OnNewInterval(tick)
+	}
+	return id
+}
+
+// nextID generates the next ID with the given tag and reports the tick it
+// used, and whether that tick is used for the first time. locked reports
+// whether the caller holds w.mutex, which long waits then release.
+func (w *Worker) nextID(tag int64, locked bool) (id, tick int64, newTick bool) {
+	if !w.initialized || w.split != nil {
+		if w.split != nil {
+			panic(errSplit)
+		}
+		panic(errNotConstructed)
+	}
+	last, sequence := w.LastTimeStamp, w.Sequence
+	now := w.Time()
+	timestamp := now
 
 	if w.LastTimeStamp > timestamp {
-		w.waitForNextTime()
+		if w.leading(now) {
+			timestamp = w.LastTimeStamp
+		} else if w.LastTimeStamp <= w.forwarded {
+			// The worker was fast-forwarded past the clock, which has not
+			// moved back; wait for it as for an exhausted sequence.
+			w.stats.Waits++
+			timestamp = w.tickAfter(w.LastTimeStamp)
+		} else {
+			// Wait for a tick after the last one, which is then new and
+			// starts again at sequence 0.
+			w.stats.ClockBackwards++
+			w.gen.ClockRegressed = true
+			w.gen.ClockDrift = time.Duration(w.LastTimeStamp-now) * w.Frequency
+			timestamp = w.tickAfter(w.LastTimeStamp)
+		}
 	}
 
 	if w.LastTimeStamp == timestamp {
-		w.Sequence = (w.Sequence + 1) % (1 << w.SequenceBits)
+		w.Sequence = (w.Sequence + 1) & w.SequenceMask
 		if w.Sequence == 0 {
-			w.waitForNextTime()
-			timestamp = w.LastTimeStamp
+			w.gen.RolledOver = true
+			if w.Health != nil {
+				w.recordHealth(w.LastTimeStamp, true)
+			}
+			if w.borrow(now) {
+				timestamp = w.LastTimeStamp + 1
+			} else {
+				w.stats.Waits++
+				w.waitForNextTime()
+				timestamp = w.LastTimeStamp
+			}
 		}
 	} else {
 		w.Sequence = 0
 	}
 
+	if timestamp-w.CustomEpoch > w.MaxTimeStamp {
+		// Leave the worker at the layout's last ID.
+		w.LastTimeStamp, w.Sequence = last, sequence
+		w.epochExhausted(timestamp, locked)
+		return 0, last, false
+	}
+
 	w.LastTimeStamp = timestamp
+	if len(w.ReservedRanges) > 0 {
+		var err error
+		if timestamp, err = w.skipReserved(timestamp, tag, locked); err != nil {
+			w.genErr = err
+			return 0, last, false
+		}
+		if timestamp-w.CustomEpoch > w.MaxTimeStamp {
+			// The reserved range covered the rest of the last tick.
+			w.LastTimeStamp = w.CustomEpoch + w.MaxTimeStamp
+			w.Sequence = w.MaxSequence
+			w.epochExhausted(timestamp, locked)
+			return 0, last, false
+		}
+	}
+	w.issued = true
+	if w.Health != nil && timestamp != last {
+		w.recordHealth(timestamp, false)
+	}
 
-	return (timestamp-w.CustomEpoch)<<w.TimeStampShift |
+	id = w.compose(timestamp, tag, w.Sequence)
+	if w.Recent != nil {
+		w.Recent.push(id)
+	}
+	return id, timestamp, timestamp != last
+}
+
+// compose builds the worker's ID for the given tick, tag and sequence.
+func (w *Worker) compose(timestamp, tag, sequence int64) int64 {
+	id := (timestamp-w.CustomEpoch)<<w.TimeStampShift |
 		w.ID<<w.IDShift |
-		w.Sequence
+		tag<<w.TagShift |
+		sequence
+	if w.VersionBit {
+		id |= math.MinInt64
+	}
+	return id
+}
+
+// fireIntervals calls OnNewInterval for every queued tick up to and including
+// tick. Ticks are queued in increasing order under the worker's mutex, and
+// drained in that order under hookMutex, so each fires exactly once and in
+// order, and no caller returns an ID before its tick's hook has returned.
+func (w *Worker) fireIntervals(tick int64) {
+	if tick <= w.firedTick.Load() {
+		return
+	}
+	w.hookMutex.Lock()
+	defer w.hookMutex.Unlock()
+
+	w.mutex.Lock()
+	ticks := w.pendingTicks
+	w.pendingTicks = nil
+	w.mutex.Unlock()
+
+	for _, t := range ticks {
+		w.OnNewInterval(t)
+		w.firedTick.Store(t)
+	}
 }
 
+// IDString returns id's fixed-width string form, StringLength characters
+// from the alphabet EncodeInt documents, which sort as the IDs do. Strings
+// from releases before that alphabet are in a different format; see
+// ParseLegacyIDString.
 func (w *Worker) IDString(id int64) string {
-	str, _ := IntToString(id, w.TotalBits)
+	str, _ := IntToString(w.Layout().stringBits(id), w.TotalBits)
 	return str
 }
 
+// ParseIDString is the inverse of IDString. It returns a *LengthError if s
+// is not StringLength characters long, ErrMalformedID if it has characters
+// outside the encoding alphabet, and ErrInvalidID if the decoded ID does not
+// fit the worker's layout.
+func (w *Worker) ParseIDString(s string) (int64, error) {
+	if n := w.StringLength(); len(s) != n {
+		return 0, &LengthError{
+			Expected: n,
+			Actual:   len(s),
+			Matches:  presetsWithLength(len(s)),
+		}
+	}
+	v, err := StringToInt(s, w.TotalBits)
+	if err != nil {
+		return 0, err
+	}
+	id := w.Layout().fromStringBits(v)
+	if err := w.Validate(id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// StringLength is the length of every string IDString returns.
+func (w *Worker) StringLength() int {
+	return StringLength(w.TotalBits)
+}
+
+// LastIssued returns the tick and sequence of the worker's latest ID. Before
+// the first ID they are below anything the worker will issue, but need not
+// be those of any ID.
+func (w *Worker) LastIssued() (tick, sequence int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.LastTimeStamp, w.Sequence
+}
+
 func (w *Worker) waitForNextTime() {
+	w.LastTimeStamp = w.tickAfter(w.LastTimeStamp)
+}
+
+// tickAfter spins until the clock is past tick and returns its reading. A
+// tick-driven worker waits for such a tick to arrive instead. For
+// NextIDInfo, the time waited is added to the GenInfo being built.
+func (w *Worker) tickAfter(tick int64) int64 {
+	if !w.timeWaits {
+		return w.awaitTick(tick)
+	}
+	start := w.clockNanos()
+	ts := w.awaitTick(tick)
+	w.gen.WaitedFor += time.Duration(w.clockNanos() - start)
+	return ts
+}
+
+func (w *Worker) awaitTick(tick int64) int64 {
+	if w.ticks != nil {
+		return w.ticks.after(tick)
+	}
 	ts := w.Time()
-	for ts <= w.LastTimeStamp {
+	for ts <= tick {
 		ts = w.Time()
 	}
-	w.LastTimeStamp = ts
+	return ts
 }
 
 func (w *Worker) Time() int64 {
-	return time.Now().UnixNano() / int64(w.Frequency)
+	if w.ticks != nil {
+		return w.ticks.now()
+	}
+	return w.clockNanos() / int64(w.Frequency)
+}
+
+// clockNanos reads the worker's clock in nanoseconds since the unix epoch,
+// to the precision of a tick for a tick-driven worker.
+func (w *Worker) clockNanos() int64 {
+	if w.ticks != nil {
+		return w.ticks.now() * int64(w.Frequency)
+	}
+	now := time.Now
+	if w.TimeFunc != nil {
+		now = w.TimeFunc
+	}
+	return now().UnixNano()
+}
+
+// String describes the worker's configuration. It only reads fields that are
+// fixed at construction, so it never takes the mutex and is safe to call from
+// anywhere, including while an ID is being generated.
+func (w *Worker) String() string {
+	return fmt.Sprintf(
+		"sanic.Worker{id=%d layout=%s freq=%s epoch=%s strlen=%d exhausts=%s}",
+		w.ID, w.layoutString(), w.Frequency,
+		w.Epoch().Format(time.RFC3339), w.TotalBits/6,
+		w.Exhausts().Format(time.DateOnly))
+}
+
+// LogValue implements slog.LogValuer with the same fields as String.
+func (w *Worker) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("id", w.ID),
+		slog.String("layout", w.layoutString()),
+		slog.Duration("freq", w.Frequency),
+		slog.Time("epoch", w.Epoch()),
+		slog.Uint64("strlen", w.TotalBits/6),
+		slog.Time("exhausts", w.Exhausts()),
+	)
+}
+
+// layoutString gives the bit widths as id/sequence/timestamp, or
+// id/tag/sequence/timestamp for tagged layouts.
+func (w *Worker) layoutString() string {
+	if w.TagBits > 0 {
+		return fmt.Sprintf("%d/%d/%d/%d",
+			w.IDBits, w.TagBits, w.SequenceBits, w.TimeStampBits)
+	}
+	return fmt.Sprintf("%d/%d/%d", w.IDBits, w.SequenceBits, w.TimeStampBits)
+}
+
+// Epoch returns the worker's custom epoch as a time.
+func (w *Worker) Epoch() time.Time {
+	return w.Layout().Epoch()
+}
+
+// Exhausts returns the first time that no longer fits in the timestamp bits.
+func (w *Worker) Exhausts() time.Time {
+	return w.Layout().Exhausts()
 }
@@ -0,0 +1,380 @@
+package sanic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerString(t *testing.T) {
+	for _, c := range []struct {
+		w    *Worker
+		want string
+	}{
+		{NewWorker10(7), "sanic.Worker{id=7 layout=6/12/41 freq=1ms epoch=2016-01-01T00:00:00Z strlen=10 exhausts=2085-09-06}"},
+		{NewWorker9(2), "sanic.Worker{id=2 layout=2/13/38 freq=10ms epoch=2016-01-01T00:00:00Z strlen=9 exhausts=2103-02-08}"},
+		{NewWorker8(), "sanic.Worker{id=0 layout=0/13/34 freq=100ms epoch=2016-01-01T00:00:00Z strlen=8 exhausts=2070-06-10}"},
+		{NewWorker7(), "sanic.Worker{id=0 layout=0/10/31 freq=1s epoch=2016-01-01T00:00:00Z strlen=7 exhausts=2084-01-19}"},
+		{NewTaggedWorker(3, 0, 4, 2, 10, 43, time.Millisecond), "sanic.Worker{id=3 layout=4/2/10/43 freq=1ms epoch=1970-01-01T00:00:00Z strlen=10 exhausts=2248-09-26}"},
+	} {
+		if got := c.w.String(); got != c.want {
+			t.Errorf("got  %s\nwant %s", got, c.want)
+		}
+	}
+}
+
+func TestWorkerLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	noTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+	slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: noTime})).
+		Info("start", "worker", NewWorker10(7))
+	want := "level=INFO msg=start worker.id=7 worker.layout=6/12/41 worker.freq=1ms " +
+		"worker.epoch=2016-01-01T00:00:00.000Z worker.strlen=10 " +
+		"worker.exhausts=2085-09-06T15:47:35.552Z\n"
+	if buf.String() != want {
+		t.Errorf("got  %s\nwant %s", buf.String(), want)
+	}
+}
+
+// String must not take the mutex, so it works mid-generation.
+func TestWorkerStringWhileLocked(t *testing.T) {
+	w := NewWorker10(7)
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if !strings.HasPrefix(w.String(), "sanic.Worker{id=7 ") {
+		t.Error(w.String())
+	}
+}
+
+func TestNextIDOrdered(t *testing.T) {
+	for _, w := range []*Worker{NewWorker10(7), NewWorker9(2), NewWorker8()} {
+		last := int64(0)
+		for range 50000 {
+			id := w.NextID()
+			if id <= last {
+				t.Fatalf("%s: %d after %d", w, id, last)
+			}
+			if p := w.Decompose(id); p.WorkerID != w.ID {
+				t.Fatalf("%s: worker ID %d in %+v", w, p.WorkerID, p)
+			}
+			last = id
+		}
+	}
+}
+
+func TestNextIDConcurrent(t *testing.T) {
+	w := NewWorker10(7)
+	const goroutines, each = 8, 20000
+	ids := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for g := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[g] = make([]int64, each)
+			for i := range each / 100 {
+				if i%2 == 0 {
+					w.NextIDs(ids[g][i*100 : (i+1)*100])
+				} else {
+					for j := range 100 {
+						ids[g][i*100+j] = w.NextID()
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	seen := make(map[int64]bool, goroutines*each)
+	for _, list := range ids {
+		for i, id := range list {
+			if seen[id] {
+				t.Fatalf("duplicate %d", id)
+			}
+			seen[id] = true
+			if i > 0 && id <= list[i-1] {
+				t.Fatalf("goroutine saw %d after %d", id, list[i-1])
+			}
+		}
+	}
+}
+
+func TestUnsafeNextIDAllocs(t *testing.T) {
+	w := NewWorker10(7)
+	if n := testing.AllocsPerRun(10000, func() { w.UnsafeNextID() }); n != 0 {
+		t.Errorf("UnsafeNextID allocates %v times per call", n)
+	}
+}
+
+func TestUninitializedWorker(t *testing.T) {
+	var decoded Worker
+	if err := json.Unmarshal([]byte(`{"ID":1,"SequenceBits":12,"Frequency":1000000}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for name, w := range map[string]*Worker{
+		"zero":    {},
+		"literal": {ID: 1, IDBits: 6, SequenceBits: 12, TimeStampBits: 41, Frequency: time.Millisecond},
+		"json":    &decoded,
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrUninitialized) || !strings.Contains(err.Error(), "NewWorker10") {
+					t.Errorf("%s: recovered %v, want ErrUninitialized naming the constructors", name, err)
+				}
+			}()
+			w.NextID()
+		}()
+		if _, err := w.NextIDContext(context.Background()); !errors.Is(err, ErrUninitialized) {
+			t.Errorf("%s: NextIDContext = %v, want ErrUninitialized", name, err)
+		}
+		if err := w.Warmup(); !errors.Is(err, ErrUninitialized) {
+			t.Errorf("%s: Warmup = %v", name, err)
+		}
+	}
+}
+
+func TestOnNewInterval(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	var fired []int64
+	w.OnNewInterval = func(tick int64) { fired = append(fired, tick) }
+
+	ids := make([]int64, 3)
+	for _, k := range []int64{0, 1, 2, 5} {
+		c.set(tick + k)
+		w.NextID()
+		w.NextIDs(ids)
+		w.NextID()
+	}
+	want := []int64{tick, tick + 1, tick + 2, tick + 5}
+	if !slices.Equal(fired, want) {
+		t.Errorf("fired %v, want %v", fired, want)
+	}
+
+	fired = nil
+	c.set(tick + 6)
+	w.UnsafeNextID()
+	w.UnsafeNextID()
+	if !slices.Equal(fired, []int64{tick + 6}) {
+		t.Errorf("UnsafeNextID fired %v, want [%d]", fired, tick+6)
+	}
+}
+
+func TestOnNewIntervalConcurrent(t *testing.T) {
+	ref := NewWorker10(5)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 5, Layout: ref.Layout()}, tick)
+	var fired []int64
+	w.OnNewInterval = func(tick int64) { fired = append(fired, tick) }
+
+	const goroutines = 4
+	used := make([]map[int64]bool, goroutines)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := range used {
+		used[g] = map[int64]bool{}
+		wg.Add(1)
+		go func(used map[int64]bool) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				used[w.Decompose(w.NextID()).Tick] = true
+			}
+		}(used[g])
+	}
+	for k := int64(1); k <= 20; k++ {
+		time.Sleep(time.Millisecond)
+		c.set(tick + k)
+	}
+	close(done)
+	wg.Wait()
+	// A last ID flushes ticks queued by a goroutine that lost the race to
+	// fire them.
+	used[0][w.Decompose(w.NextID()).Tick] = true
+
+	all := map[int64]bool{}
+	for _, u := range used {
+		for t := range u {
+			all[t] = true
+		}
+	}
+	for i := 1; i < len(fired); i++ {
+		if fired[i] <= fired[i-1] {
+			t.Fatalf("ticks fired out of order: %v", fired)
+		}
+	}
+	if len(fired) != len(all) {
+		t.Errorf("fired %d ticks, IDs used %d", len(fired), len(all))
+	}
+	for _, f := range fired {
+		if !all[f] {
+			t.Errorf("fired tick %d that no ID used", f)
+		}
+	}
+}
+
+// The hot path masks the sequence rather than taking it modulo the tick's
+// capacity, so it must run 0 through MaxSequence and then wait for the next
+// tick.
+func TestUnsafeNextIDRollover(t *testing.T) {
+	ref := NewWorker10(7)
+	tick := ref.Time()
+	w, c := fakeClockWorker(t, WorkerConfig{ID: 7, Layout: ref.Layout()}, tick)
+	for seq := int64(0); seq <= w.MaxSequence; seq++ {
+		if id := w.UnsafeNextID(); id != ref.compose(tick, 0, seq) {
+			t.Fatalf("ID %d decodes to %+v, want sequence %d", id, w.Decompose(id), seq)
+		}
+	}
+	next := make(chan int64)
+	go func() { next <- w.UnsafeNextID() }()
+	c.spinning()
+	c.set(tick + 1)
+	if id := <-next; id != ref.compose(tick+1, 0, 0) {
+		t.Errorf("ID after rollover decodes to %+v", w.Decompose(id))
+	}
+}
+
+func TestWorkerMaxima(t *testing.T) {
+	tagged, err := New(WorkerConfig{ID: 3, Layout: taggedLayout()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived, err := NewWorker10(1).WithID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range append(presetWorkers(), tagged, derived) {
+		if w.MaxWorkerID != 1<<w.IDBits-1 || w.MaxTag != 1<<w.TagBits-1 ||
+			w.MaxSequence != 1<<w.SequenceBits-1 || w.SequenceMask != w.MaxSequence ||
+			w.MaxTimeStamp != 1<<w.TimeStampBits-1 {
+			t.Errorf("%s: maxima %d/%d/%d/%d, mask %d", w, w.MaxWorkerID, w.MaxTag,
+				w.MaxSequence, w.MaxTimeStamp, w.SequenceMask)
+		}
+	}
+}
+
+// Every preset generates through its last tick, at max-1 and max, and
+// fails cleanly at max+1 with the worker left at its last ID.
+func TestLastTick(t *testing.T) {
+	for _, ref := range presetWorkers() {
+		l := ref.Layout()
+		last := l.CustomEpoch + l.MaxTimeStamp()
+		w, c := fakeClockWorker(t, WorkerConfig{ID: l.MaxWorkerID(), Layout: l}, last-1)
+		ctx := context.Background()
+
+		id, err := w.NextIDContext(ctx)
+		if err != nil || w.Decompose(id).Tick != last-1 {
+			t.Fatalf("%s: at max-1: %+v, %v", w, w.Decompose(id), err)
+		}
+		c.set(last)
+		var prev string
+		for seq := int64(0); seq <= l.MaxSequence(); seq++ {
+			id, err := w.NextIDContext(ctx)
+			p := w.Decompose(id)
+			if err != nil || id <= 0 || p.Tick != last || p.Sequence != seq || w.Validate(id) != nil {
+				t.Fatalf("%s: at max, sequence %d: %d %+v, %v", w, seq, id, p, err)
+			}
+			s := w.IDString(id)
+			if back, err := w.ParseIDString(s); back != id || err != nil || s <= prev {
+				t.Fatalf("%s: IDString(%d) = %q, parsed as %d, %v", w, id, s, back, err)
+			}
+			prev = s
+		}
+		top := w.compose(last, l.MaxTag(), l.MaxSequence())
+		if top>>l.TotalBits() != 0 || !w.Timestamp(top).Equal(l.tickTime(last)) ||
+			!l.Exhausts().Equal(l.tickTime(last+1)) {
+			t.Errorf("%s: last ID %d at %s, exhausts at %s", w, top, w.Timestamp(top), l.Exhausts())
+		}
+
+		// The sequence is exhausted, so the next ID waits for max+1.
+		done := make(chan error, 1)
+		go func() {
+			_, err := w.NextIDContext(ctx)
+			done <- err
+		}()
+		c.spinning()
+		c.set(last + 1)
+		if err := <-done; !errors.Is(err, ErrEpochExhausted) {
+			t.Errorf("%s: at max+1: NextIDContext = %v", w, err)
+		}
+		if _, err := w.NextIDTagged(0); !errors.Is(err, ErrEpochExhausted) {
+			t.Errorf("%s: at max+1: NextIDTagged = %v", w, err)
+		}
+		if tick, seq := w.LastIssued(); tick != last || seq != l.MaxSequence() {
+			t.Errorf("%s: left at tick %d sequence %d", w, tick, seq)
+		}
+	}
+}
+
+// Generation that cannot fail calls OnEpochExhausted past the last tick,
+// which may panic instead of blocking.
+func TestLastTickNextID(t *testing.T) {
+	l := NewWorker8().Layout()
+	last := l.CustomEpoch + l.MaxTimeStamp()
+	w, c := fakeClockWorker(t, WorkerConfig{Layout: l}, last)
+	c.set(last + 1)
+	type exhausted struct{ tick int64 }
+	w.OnEpochExhausted = func(tick int64) { panic(exhausted{tick}) }
+
+	got := make(chan any, 1)
+	go func() {
+		defer func() { got <- recover() }()
+		w.NextID()
+	}()
+	if r := <-got; r != (exhausted{last + 1}) {
+		t.Errorf("NextID recovered %v, want OnEpochExhausted at tick %d", r, last+1)
+	}
+}
+
+// A legacy call blocked past the last tick does not hold the lock, so the
+// hook can use the worker and the error API still fails cleanly.
+func TestLastTickMixed(t *testing.T) {
+	l := NewWorker8().Layout()
+	last := l.CustomEpoch + l.MaxTimeStamp()
+	w, c := fakeClockWorker(t, WorkerConfig{Layout: l}, last)
+	c.set(last + 1)
+	called := make(chan Stats, 1)
+	w.OnEpochExhausted = func(int64) { called <- w.Stats() }
+
+	go w.NextID() // blocks forever
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnEpochExhausted could not read the worker's Stats")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.NextIDContext(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrEpochExhausted) {
+			t.Errorf("NextIDContext = %v, want ErrEpochExhausted", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("NextIDContext hung behind a blocked NextID")
+	}
+	if _, err := w.NextIDTagged(0); !errors.Is(err, ErrEpochExhausted) {
+		t.Errorf("NextIDTagged = %v, want ErrEpochExhausted", err)
+	}
+	w.Pause()
+	w.Close()
+}